@@ -0,0 +1,16 @@
+package main
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// NewS3Client creates a new client to interact with AWS S3 in given region.
+func newS3Client(awsRegion *string) *s3.S3 {
+	awsConfig := aws.NewConfig()
+	if awsRegion != nil {
+		awsConfig = awsConfig.WithRegion(*awsRegion)
+	}
+	return s3.New(session.Must(session.NewSession(awsConfig)))
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"time"
+
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+// CalculateDayBalances compares working time of passed records with target hours for each day
+// in given range. Target hours of vacation and illness days are fulfilled. Passed holidays, names
+// per date, are non-working days without target hours.
+func calculateDayBalances(deviceId string, records []timetracker.TimeTrackingRecord, start, end time.Time, location *time.Location, targets *WorkingTimeTargets, holidays map[string]string) []DayBalance {
+
+	daySummaries := make(map[string]DaySummary)
+	for _, day := range summarizeDays(records, location) {
+		daySummaries[day.Date] = day
+	}
+
+	balances := []DayBalance{}
+	for day := startOfDay(start, location); day.Before(end); day = day.AddDate(0, 0, 1) {
+
+		date := day.Format(dateLayout)
+		balance := DayBalance{
+			Date:   date,
+			Target: int(targets.targetFor(deviceId, day.Weekday()).Minutes()),
+		}
+		if holiday, ok := holidays[date]; ok {
+			balance.Target = 0
+			balance.Holiday = holiday
+		}
+		if summary, ok := daySummaries[date]; ok {
+			balance.WorkingTime = summary.WorkingTime
+			balance.Absence = summary.Absence
+		}
+		balance.Balance = balance.WorkingTime - balance.Target
+		if balance.Absence != "" {
+			balance.Balance = balance.WorkingTime
+		}
+		balances = append(balances, balance)
+	}
+	return balances
+}
+
+// NewBalanceReport creates a balance report for passed day balances. Running balance contains
+// all days since given start date.
+func newBalanceReport(deviceId string, dayBalances []DayBalance, start, end time.Time, startDate string) BalanceReport {
+
+	report := BalanceReport{
+		DeviceId: deviceId,
+		From:     &APITime{Time: start},
+		To:       &APITime{Time: end},
+		Since:    startDate,
+		Days:     []DayBalance{},
+		Months:   []MonthBalance{},
+	}
+
+	firstDate := start.Format(dateLayout)
+	for _, day := range dayBalances {
+
+		if day.Date >= startDate {
+			report.RunningBalance += day.Balance
+		}
+		if day.Date < firstDate {
+			continue
+		}
+
+		report.Days = append(report.Days, day)
+		report.Target += day.Target
+		report.WorkingTime += day.WorkingTime
+		report.Balance += day.Balance
+
+		month := day.Date[:7]
+		if len(report.Months) == 0 || report.Months[len(report.Months)-1].Month != month {
+			report.Months = append(report.Months, MonthBalance{Month: month})
+		}
+		monthBalance := &report.Months[len(report.Months)-1]
+		monthBalance.Target += day.Target
+		monthBalance.WorkingTime += day.WorkingTime
+		monthBalance.Balance += day.Balance
+	}
+	return report
+}
+
+// StartOfDay returns midnight of passed point in time in given location.
+func startOfDay(t time.Time, location *time.Location) time.Time {
+	t = t.In(location)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, location)
+}
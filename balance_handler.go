@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	config "github.com/tommzn/go-config"
+	log "github.com/tommzn/go-log"
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+// NewTimeTrackingBalanceHandler returns a handler to compare working time with target hours. Target hours and
+// the start date of a running balance and the max time range records are listed for at once are read from passed config. Holidays are looked up in passed calendar.
+func newTimeTrackingBalanceHandler(recordHandler *TimeTrackingRecordHandler, calendar *HolidayCalendar, conf config.Config, logger log.Logger) (*TimeTrackingBalanceHandler, error) {
+
+	targets, err := newWorkingTimeTargets(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	startDate := conf.Get("hob.balance.startdate", nil)
+	if startDate != nil {
+		if _, err := time.Parse(dateLayout, *startDate); err != nil {
+			return nil, err
+		}
+	}
+
+	return &TimeTrackingBalanceHandler{
+		logger:        logger,
+		recordHandler: recordHandler,
+		calendar:      calendar,
+		targets:       targets,
+		startDate:     startDate,
+		maxRange:      *conf.GetAsDuration("hob.balance.maxrange", config.AsDurationPtr(366*24*time.Hour)),
+		now:           time.Now,
+	}, nil
+}
+
+// Process returns daily and monthly over or under time of a device for a requested time range and
+// a running balance since configured start date. Days in the future are not included. Balance of days between
+// start date and requested time range is carried forward in chunks of max range.
+func (handler *TimeTrackingBalanceHandler) Process(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	if request.HTTPMethod != http.MethodGet {
+		err := errors.New("Unsupported HTTP method.")
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusMethodNotAllowed), err
+	}
+
+	deviceId, ok := request.QueryStringParameters["deviceid"]
+	if !ok || deviceId == "" {
+		err := errors.New("Missing device id.")
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusBadRequest), err
+	}
+
+	start, end, err := handler.recordHandler.timeRangeFromRequest(request)
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusBadRequest), err
+	}
+
+	location, err := handler.recordHandler.locationFor(request.QueryStringParameters)
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusBadRequest), err
+	}
+
+	start = startOfDay(start, location)
+	if tomorrow := startOfDay(handler.now(), location).AddDate(0, 0, 1); end.After(tomorrow) {
+		end = tomorrow
+	}
+	runningBalanceStart := start
+	if handler.startDate != nil {
+		runningBalanceStart, _ = time.ParseInLocation(dateLayout, *handler.startDate, location)
+	}
+
+	carriedBalance := 0
+	for chunkStart := runningBalanceStart; chunkStart.Before(start); {
+		chunkEnd := handler.chunkEnd(chunkStart)
+		if chunkEnd.After(start) {
+			chunkEnd = start
+		}
+		dayBalances, statusCode, err := handler.dayBalancesBetween(deviceId, chunkStart, chunkEnd, location, request.QueryStringParameters)
+		if err != nil {
+			handler.logger.Error(err)
+			return errorResponseWithStatus(err, statusCode), err
+		}
+		for _, day := range dayBalances {
+			carriedBalance += day.Balance
+		}
+		chunkStart = chunkEnd
+	}
+
+	dayBalances, statusCode, err := handler.dayBalancesBetween(deviceId, start, end, location, request.QueryStringParameters)
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, statusCode), err
+	}
+
+	report := newBalanceReport(deviceId, dayBalances, start, end, runningBalanceStart.Format(dateLayout))
+	report.RunningBalance += carriedBalance
+	responseContent, err := json.Marshal(report)
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusInternalServerError), err
+	}
+	return responseWithContent(string(responseContent), http.StatusOK), nil
+}
+
+// DayBalancesBetween lists records and holidays within passed time range and calculates the balance of each day.
+// Returns with a status code for an error, if records or holidays can't be looked up.
+func (handler *TimeTrackingBalanceHandler) dayBalancesBetween(deviceId string, start, end time.Time, location *time.Location, queryParams map[string]string) ([]DayBalance, int, error) {
+
+	records := []timetracker.TimeTrackingRecord{}
+	if end.After(start) {
+		var err error
+		records, err = handler.recordHandler.listRecords([]string{deviceId}, start, end, RecordFilter{location: location})
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+	}
+	handler.logger.Debugf("Calculate balance of %s for %d record(s)", deviceId, len(records))
+
+	holidays, err := handler.calendar.holidaysBetween(start, end, queryParams)
+	if err != nil {
+		return nil, holidayErrorStatus(err), err
+	}
+	return calculateDayBalances(deviceId, records, start, end, location, handler.targets, holidays), http.StatusOK, nil
+}
+
+// ChunkEnd returns the end of a chunk of a running balance, which starts at passed day. A chunk contains
+// at least one day and is not longer than max range.
+func (handler *TimeTrackingBalanceHandler) chunkEnd(chunkStart time.Time) time.Time {
+	days := int(handler.maxRange / (24 * time.Hour))
+	if days < 1 {
+		days = 1
+	}
+	return chunkStart.AddDate(0, 0, days)
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/suite"
+	config "github.com/tommzn/go-config"
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+type TimeTrackingBalanceTestSuite struct {
+	suite.Suite
+}
+
+func TestTimeTrackingBalanceTestSuite(t *testing.T) {
+	suite.Run(t, new(TimeTrackingBalanceTestSuite))
+}
+
+func (suite *TimeTrackingBalanceTestSuite) TestCalculateDayBalances() {
+
+	targets, _ := newWorkingTimeTargets(configForTest())
+	records := []timetracker.TimeTrackingRecord{
+		recordForTest(timetracker.WORKDAY, time.Date(2022, 1, 3, 8, 0, 0, 0, time.UTC)),
+		recordForTest(timetracker.WORKDAY, time.Date(2022, 1, 3, 17, 0, 0, 0, time.UTC)),
+		recordForTest(timetracker.WORKDAY, time.Date(2022, 1, 4, 8, 0, 0, 0, time.UTC)),
+		recordForTest(timetracker.WORKDAY, time.Date(2022, 1, 4, 15, 0, 0, 0, time.UTC)),
+		recordForTest(timetracker.VACATION, time.Date(2022, 1, 5, 8, 0, 0, 0, time.UTC)),
+		recordForTest(timetracker.ILLNESS, time.Date(2022, 1, 6, 8, 0, 0, 0, time.UTC)),
+		recordForTest(timetracker.WORKDAY, time.Date(2022, 1, 8, 10, 0, 0, 0, time.UTC)),
+		recordForTest(timetracker.WORKDAY, time.Date(2022, 1, 8, 12, 0, 0, 0, time.UTC)),
+	}
+	start := time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2022, 1, 10, 0, 0, 0, 0, time.UTC)
+
+	balances := calculateDayBalances("Device01", records, start, end, time.UTC, targets, map[string]string{})
+	suite.Len(balances, 7)
+	suite.Equal(DayBalance{Date: "2022-01-03", Target: 480, WorkingTime: 540, Balance: 60}, balances[0])
+	suite.Equal(DayBalance{Date: "2022-01-04", Target: 480, WorkingTime: 420, Balance: -60}, balances[1])
+	suite.Equal(DayBalance{Date: "2022-01-05", Target: 480, Balance: 0, Absence: timetracker.VACATION}, balances[2])
+	suite.Equal(DayBalance{Date: "2022-01-06", Target: 480, Balance: 0, Absence: timetracker.ILLNESS}, balances[3])
+	suite.Equal(DayBalance{Date: "2022-01-07", Target: 480, Balance: -480}, balances[4])
+	suite.Equal(DayBalance{Date: "2022-01-08", Target: 0, WorkingTime: 120, Balance: 120}, balances[5])
+	suite.Equal(DayBalance{Date: "2022-01-09", Target: 0, Balance: 0}, balances[6])
+
+	report := newBalanceReport("Device01", balances, time.Date(2022, 1, 4, 0, 0, 0, 0, time.UTC), end, "2022-01-03")
+	suite.Len(report.Days, 6)
+	suite.Equal(4*480, report.Target)
+	suite.Equal(420+120, report.WorkingTime)
+	suite.Equal(-420, report.Balance)
+	suite.Equal(-360, report.RunningBalance)
+	suite.Equal([]MonthBalance{{Month: "2022-01", Target: 4 * 480, WorkingTime: 540, Balance: -420}}, report.Months)
+}
+
+func (suite *TimeTrackingBalanceTestSuite) TestCalculateDayBalancesOnDstTransition() {
+
+	targets, _ := newWorkingTimeTargets(configForTest())
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	start := time.Date(2022, 3, 26, 0, 0, 0, 0, berlin)
+	end := time.Date(2022, 3, 29, 0, 0, 0, 0, berlin)
+
+	balances := calculateDayBalances("Device01", []timetracker.TimeTrackingRecord{}, start, end, berlin, targets, map[string]string{})
+	suite.Len(balances, 3)
+	suite.Equal("2022-03-26", balances[0].Date)
+	suite.Equal("2022-03-28", balances[2].Date)
+	suite.Equal(-480, balances[2].Balance)
+}
+
+func (suite *TimeTrackingBalanceTestSuite) TestCalculateDayBalancesOnHolidays() {
+
+	targets, _ := newWorkingTimeTargets(configForTest())
+	records := []timetracker.TimeTrackingRecord{
+		recordForTest(timetracker.WORKDAY, time.Date(2022, 1, 6, 8, 0, 0, 0, time.UTC)),
+		recordForTest(timetracker.WORKDAY, time.Date(2022, 1, 6, 10, 0, 0, 0, time.UTC)),
+	}
+	start := time.Date(2022, 1, 5, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2022, 1, 8, 0, 0, 0, 0, time.UTC)
+
+	balances := calculateDayBalances("Device01", records, start, end, time.UTC, targets, map[string]string{"2022-01-06": "Epiphany"})
+	suite.Len(balances, 3)
+	suite.Equal(DayBalance{Date: "2022-01-05", Target: 480, Balance: -480}, balances[0])
+	suite.Equal(DayBalance{Date: "2022-01-06", Target: 0, WorkingTime: 120, Balance: 120, Holiday: "Epiphany"}, balances[1])
+	suite.Equal(DayBalance{Date: "2022-01-07", Target: 480, Balance: -480}, balances[2])
+}
+
+func (suite *TimeTrackingBalanceTestSuite) TestProcessBalanceRequest() {
+
+	handler := suite.handlerForTest(configForTest())
+	prepareForTest(handler.recordHandler.timeTrackingManager)
+
+	response1, err1 := handler.Process(suite.requestForTest(map[string]string{"deviceid": "Device01", "month": "2022-01"}))
+	suite.Nil(err1)
+	suite.Equal(http.StatusOK, response1.StatusCode)
+
+	var report BalanceReport
+	suite.Nil(json.Unmarshal([]byte(response1.Body), &report))
+	suite.Len(report.Days, 3)
+	suite.Equal(480, report.Target)
+	suite.Equal(480, report.WorkingTime)
+	suite.Equal(0, report.Balance)
+	suite.Equal("2021-12-27", report.Since)
+	suite.Equal(-5*480, report.RunningBalance)
+	suite.Len(report.Months, 1)
+	suite.Equal("New Year's Day", report.Days[0].Holiday)
+
+	response1_1, err1_1 := handler.Process(suite.requestForTest(map[string]string{"deviceid": "Device01", "from": "2022-01-01", "to": "2022-01-01"}))
+	suite.Nil(err1_1)
+	suite.Nil(json.Unmarshal([]byte(response1_1.Body), &report))
+	suite.Len(report.Days, 1)
+	suite.Equal(0, report.Target)
+	suite.Equal(480, report.Balance)
+
+	response2, err2 := handler.Process(suite.requestForTest(map[string]string{"deviceid": "Device01", "month": "2022-02"}))
+	suite.Nil(err2)
+	suite.Equal(http.StatusOK, response2.StatusCode)
+	suite.Nil(json.Unmarshal([]byte(response2.Body), &report))
+	suite.Len(report.Days, 0)
+
+	response3, err3 := handler.Process(suite.requestForTest(map[string]string{"month": "2022-01"}))
+	suite.NotNil(err3)
+	suite.Equal(http.StatusBadRequest, response3.StatusCode)
+
+	response4, err4 := handler.Process(suite.requestForTest(map[string]string{"deviceid": "Device01", "date": "2022-01-03"}))
+	suite.Nil(err4)
+	suite.Nil(json.Unmarshal([]byte(response4.Body), &report))
+	runningBalance := report.RunningBalance
+
+	handler.maxRange = 2 * 24 * time.Hour
+	response4_1, err4_1 := handler.Process(suite.requestForTest(map[string]string{"deviceid": "Device01", "date": "2022-01-03"}))
+	suite.Nil(err4_1)
+	suite.Nil(json.Unmarshal([]byte(response4_1.Body), &report))
+	suite.Equal(runningBalance, report.RunningBalance)
+
+	conf, _ := config.NewStaticConfigSource("hob:\n  balance:\n    startdate: xxx\n").Load()
+	_, err5 := newTimeTrackingBalanceHandler(timeTrackingRecordHandlerForTest(), holidayCalendarForTest(), conf, loggerForTest())
+	suite.NotNil(err5)
+}
+
+func (suite *TimeTrackingBalanceTestSuite) TestRunningBalanceOlderThanMaxRange() {
+
+	conf, _ := config.NewStaticConfigSource("hob:\n  balance:\n    startdate: 2020-12-28\n").Load()
+	handler := suite.handlerForTest(conf)
+	prepareForTest(handler.recordHandler.timeTrackingManager)
+	listMock := &timeTrackerListMock{TimeTracker: handler.recordHandler.timeTracker}
+	handler.recordHandler.timeTracker = listMock
+	handler.recordHandler.listChunk = 0
+	request := suite.requestForTest(map[string]string{"deviceid": "Device01", "date": "2022-01-03"})
+
+	response1, err1 := handler.Process(request)
+	suite.Nil(err1)
+	suite.Equal(http.StatusOK, response1.StatusCode)
+	suite.Equal(3, listMock.calls)
+	var report1 BalanceReport
+	suite.Nil(json.Unmarshal([]byte(response1.Body), &report1))
+	suite.Equal("2020-12-28", report1.Since)
+	suite.Len(report1.Days, 1)
+
+	handler.maxRange = 1000 * 24 * time.Hour
+	response2, err2 := handler.Process(request)
+	suite.Nil(err2)
+	var report2 BalanceReport
+	suite.Nil(json.Unmarshal([]byte(response2.Body), &report2))
+	suite.Equal(report2.RunningBalance, report1.RunningBalance)
+	suite.True(report1.RunningBalance < -200*480)
+}
+
+func (suite *TimeTrackingBalanceTestSuite) handlerForTest(conf config.Config) *TimeTrackingBalanceHandler {
+	handler, err := newTimeTrackingBalanceHandler(timeTrackingRecordHandlerForTest(), holidayCalendarForTest(), conf, loggerForTest())
+	suite.Nil(err)
+	handler.now = func() time.Time {
+		return time.Date(2022, 1, 3, 12, 0, 0, 0, time.UTC)
+	}
+	return handler
+}
+
+func (suite *TimeTrackingBalanceTestSuite) requestForTest(queryParams map[string]string) events.APIGatewayProxyRequest {
+	request := timeTrackingRecordHandlerRequestForTest(http.MethodGet)
+	request.Resource = "/timetrackingrecords/balance"
+	request.QueryStringParameters = queryParams
+	return request
+}
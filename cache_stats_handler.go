@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	log "github.com/tommzn/go-log"
+)
+
+// NewCacheStatsHandler returns a handler for metrics of passed record cache. Cache is nil if caching is disabled.
+func newCacheStatsHandler(cache *CachingRepository, logger log.Logger) *CacheStatsHandler {
+	return &CacheStatsHandler{
+		logger: logger,
+		cache:  cache,
+	}
+}
+
+// Process returns hits, misses and size of the record cache of current container.
+func (handler *CacheStatsHandler) Process(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	if request.HTTPMethod != http.MethodGet {
+		err := errors.New("Unsupported HTTP method.")
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusMethodNotAllowed), err
+	}
+
+	if handler.cache == nil {
+		err := errors.New("Record cache is disabled.")
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusNotFound), err
+	}
+
+	stats := handler.cache.Stats()
+	handler.logger.Debugf("Record cache stats: %+v", stats)
+	responseContent, err := json.Marshal(stats)
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusInternalServerError), err
+	}
+	return responseWithContent(string(responseContent), http.StatusOK), nil
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"container/list"
+	"time"
+
+	config "github.com/tommzn/go-config"
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+// NewRecordCache wraps passed repository with a cache for listed records if a cache ttl is configured.
+// Returns passed repository and no cache if caching is disabled.
+func newRecordCache(conf config.Config, repository TimeTrackingRepository) (TimeTrackingRepository, *CachingRepository) {
+
+	ttl := conf.GetAsDuration("hob.records.cache.ttl", config.AsDurationPtr(0))
+	if *ttl <= 0 {
+		return repository, nil
+	}
+	maxEntries := conf.GetAsInt("hob.records.cache.maxentries", config.AsIntPtr(1000))
+	cache := newCachingRepository(repository, conf.Get("aws.s3.basepath", nil), *ttl, *maxEntries)
+	return cache, cache
+}
+
+// NewCachingRepository wraps passed repository to cache listed records for given ttl. At most max entries
+// listings are cached.
+func newCachingRepository(repository TimeTrackingRepository, basePath *string, ttl time.Duration, maxEntries int) *CachingRepository {
+	if maxEntries < 1 {
+		maxEntries = 1
+	}
+	return &CachingRepository{
+		repository:  repository,
+		basePath:    basePath,
+		ttl:         ttl,
+		maxEntries:  maxEntries,
+		entries:     make(map[string]*list.Element),
+		lru:         list.New(),
+		generations: make(map[string]uint64),
+		now:         time.Now,
+	}
+}
+
+// Capture will create a time tracking record with passed type at time this method has been called.
+func (repo *CachingRepository) Capture(deviceId string, recordType timetracker.RecordType) error {
+	return repo.Captured(deviceId, recordType, time.Now())
+}
+
+// Captured creates a time tracking record for passed point in time and invalidates cached records of its device.
+func (repo *CachingRepository) Captured(deviceId string, recordType timetracker.RecordType, timestamp time.Time) error {
+	defer repo.invalidate(deviceId)
+	return repo.repository.Captured(deviceId, recordType, timestamp)
+}
+
+// ListRecords returns cached records for given range or lists them from wrapped repository.
+// Errors are not cached.
+func (repo *CachingRepository) ListRecords(deviceId string, start time.Time, end time.Time) ([]timetracker.TimeTrackingRecord, error) {
+
+	key := deviceId + "|" + start.UTC().Format(time.RFC3339Nano) + "|" + end.UTC().Format(time.RFC3339Nano)
+	records, generation, ok := repo.get(key, deviceId)
+	if ok {
+		return records, nil
+	}
+
+	records, err := repo.repository.ListRecords(deviceId, start, end)
+	if err != nil {
+		return records, err
+	}
+	repo.put(key, deviceId, generation, records)
+	return copyRecords(records), nil
+}
+
+// Add creates a new time tracking record and invalidates cached records of its device.
+func (repo *CachingRepository) Add(record timetracker.TimeTrackingRecord) (timetracker.TimeTrackingRecord, error) {
+	defer repo.invalidate(record.DeviceId)
+	return repo.repository.Add(record)
+}
+
+// Delete will remove time tracking record by passed key and invalidates cached records of its device.
+// If there's no device id in passed key, all cached records are invalidated.
+func (repo *CachingRepository) Delete(key string) error {
+	if deviceId, _, err := parseRecordKey(key, repo.basePath); err == nil {
+		defer repo.invalidate(deviceId)
+	} else {
+		defer repo.invalidateAll()
+	}
+	return repo.repository.Delete(key)
+}
+
+// Stats returns current metrics of this cache.
+func (repo *CachingRepository) Stats() CacheStats {
+
+	repo.Lock()
+	defer repo.Unlock()
+
+	stats := repo.stats
+	stats.Entries = repo.lru.Len()
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
+
+// Get returns cached records for passed key, if they're not expired, and current generation of given device.
+func (repo *CachingRepository) get(key, deviceId string) ([]timetracker.TimeTrackingRecord, uint64, bool) {
+
+	repo.Lock()
+	defer repo.Unlock()
+
+	generation := repo.generation(deviceId)
+	if element, ok := repo.entries[key]; ok {
+		entry := element.Value.(*cachedRecords)
+		if repo.now().Before(entry.expires) {
+			repo.lru.MoveToFront(element)
+			repo.stats.Hits++
+			return copyRecords(entry.records), generation, true
+		}
+		repo.remove(element)
+	}
+	repo.stats.Misses++
+	return nil, generation, false
+}
+
+// Put caches passed records, unless records of given device have been invalidated since listing has been started.
+// Least recently used listings are evicted if max entries are exceeded.
+func (repo *CachingRepository) put(key, deviceId string, generation uint64, records []timetracker.TimeTrackingRecord) {
+
+	repo.Lock()
+	defer repo.Unlock()
+
+	if repo.generation(deviceId) != generation {
+		return
+	}
+	if element, ok := repo.entries[key]; ok {
+		repo.remove(element)
+	}
+	entry := &cachedRecords{key: key, deviceId: deviceId, records: copyRecords(records), expires: repo.now().Add(repo.ttl)}
+	repo.entries[key] = repo.lru.PushFront(entry)
+	for repo.lru.Len() > repo.maxEntries {
+		repo.remove(repo.lru.Back())
+		repo.stats.Evictions++
+	}
+}
+
+// Invalidate removes all cached records of passed device.
+func (repo *CachingRepository) invalidate(deviceId string) {
+
+	repo.Lock()
+	defer repo.Unlock()
+
+	repo.generations[deviceId]++
+	for element := repo.lru.Front(); element != nil; {
+		next := element.Next()
+		if element.Value.(*cachedRecords).deviceId == deviceId {
+			repo.remove(element)
+			repo.stats.Invalidations++
+		}
+		element = next
+	}
+}
+
+// InvalidateAll removes all cached records.
+func (repo *CachingRepository) invalidateAll() {
+
+	repo.Lock()
+	defer repo.Unlock()
+
+	repo.epoch++
+	repo.stats.Invalidations += int64(repo.lru.Len())
+	repo.entries = make(map[string]*list.Element)
+	repo.lru.Init()
+}
+
+// Generation returns a counter of invalidations of passed device. Caller has to hold the lock.
+func (repo *CachingRepository) generation(deviceId string) uint64 {
+	return repo.epoch + repo.generations[deviceId]
+}
+
+// Remove deletes passed element from cache. Caller has to hold the lock.
+func (repo *CachingRepository) remove(element *list.Element) {
+	delete(repo.entries, element.Value.(*cachedRecords).key)
+	repo.lru.Remove(element)
+}
+
+// CopyRecords returns a copy of passed records, so cached records can't be changed by callers.
+func copyRecords(records []timetracker.TimeTrackingRecord) []timetracker.TimeTrackingRecord {
+	return append([]timetracker.TimeTrackingRecord{}, records...)
+}
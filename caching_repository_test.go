@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/suite"
+	config "github.com/tommzn/go-config"
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+type CachingRepositoryTestSuite struct {
+	suite.Suite
+}
+
+func TestCachingRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(CachingRepositoryTestSuite))
+}
+
+func (suite *CachingRepositoryTestSuite) TestCacheListedRecords() {
+
+	repo, mock := suite.repositoryForTest(10)
+	start, end := suite.timeRange()
+	mock.TimeTracker.(TimeTrackingRepository).Add(recordForTest(timetracker.WORKDAY, start.Add(8*time.Hour)))
+
+	records1, err1 := repo.ListRecords("Device01", start, end)
+	suite.Nil(err1)
+	suite.Len(records1, 1)
+	records2, err2 := repo.ListRecords("Device01", start, end)
+	suite.Nil(err2)
+	suite.Equal(records1, records2)
+
+	stats := repo.Stats()
+	suite.Equal(int64(1), stats.Hits)
+	suite.Equal(int64(1), stats.Misses)
+	suite.Equal(0.5, stats.HitRatio)
+	suite.Equal(1, stats.Entries)
+
+	// Records added to underlying repository directly are not visible until ttl expires.
+	mock.TimeTracker.(TimeTrackingRepository).Add(recordForTest(timetracker.WORKDAY, start.Add(9*time.Hour)))
+	records3, _ := repo.ListRecords("Device01", start, end)
+	suite.Len(records3, 1)
+
+	now := time.Now().Add(2 * time.Minute)
+	repo.now = func() time.Time {
+		return now
+	}
+	records4, _ := repo.ListRecords("Device01", start, end)
+	suite.Len(records4, 2)
+	suite.Equal(int64(2), repo.Stats().Misses)
+}
+
+func (suite *CachingRepositoryTestSuite) TestInvalidateOnChanges() {
+
+	repo, _ := suite.repositoryForTest(10)
+	start, end := suite.timeRange()
+
+	_, err1 := repo.ListRecords("Device01", start, end)
+	suite.Nil(err1)
+	_, err2 := repo.ListRecords("Device02", start, end)
+	suite.Nil(err2)
+
+	record, err3 := repo.Add(recordForTest(timetracker.WORKDAY, start.Add(8*time.Hour)))
+	suite.Nil(err3)
+	suite.Equal(1, repo.Stats().Entries)
+	records1, _ := repo.ListRecords("Device01", start, end)
+	suite.Len(records1, 1)
+
+	suite.Nil(repo.Captured("Device01", timetracker.WORKDAY, start.Add(9*time.Hour)))
+	records2, _ := repo.ListRecords("Device01", start, end)
+	suite.Len(records2, 2)
+
+	suite.Nil(repo.Delete(record.Key))
+	records3, _ := repo.ListRecords("Device01", start, end)
+	suite.Len(records3, 1)
+
+	suite.NotNil(repo.Delete("xxx"))
+	suite.Equal(0, repo.Stats().Entries)
+	suite.Equal(int64(5), repo.Stats().Invalidations)
+}
+
+func (suite *CachingRepositoryTestSuite) TestSkipStaleListings() {
+
+	repo, _ := suite.repositoryForTest(10)
+	_, generation, ok := repo.get("key", "Device01")
+	suite.False(ok)
+
+	repo.invalidate("Device01")
+	repo.put("key", "Device01", generation, []timetracker.TimeTrackingRecord{})
+	suite.Equal(0, repo.Stats().Entries)
+
+	_, generation, _ = repo.get("key", "Device01")
+	repo.invalidateAll()
+	repo.put("key", "Device01", generation, []timetracker.TimeTrackingRecord{})
+	suite.Equal(0, repo.Stats().Entries)
+}
+
+func (suite *CachingRepositoryTestSuite) TestEvictLeastRecentlyUsed() {
+
+	repo, _ := suite.repositoryForTest(2)
+	start, _ := suite.timeRange()
+	listDay := func(offset int) {
+		_, err := repo.ListRecords("Device01", start.AddDate(0, 0, offset), start.AddDate(0, 0, offset+1))
+		suite.Nil(err)
+	}
+
+	listDay(0)
+	listDay(1)
+	listDay(0)
+	listDay(2)
+
+	stats := repo.Stats()
+	suite.Equal(2, stats.Entries)
+	suite.Equal(int64(1), stats.Evictions)
+
+	listDay(0)
+	suite.Equal(int64(2), repo.Stats().Hits)
+	listDay(1)
+	suite.Equal(int64(4), repo.Stats().Misses)
+}
+
+func (suite *CachingRepositoryTestSuite) TestErrorsAreNotCached() {
+
+	repo, mock := suite.repositoryForTest(10)
+	start, end := suite.timeRange()
+	mock.failedDevices["Device01"] = true
+
+	_, err := repo.ListRecords("Device01", start, end)
+	suite.NotNil(err)
+	suite.Equal(0, repo.Stats().Entries)
+}
+
+func (suite *CachingRepositoryTestSuite) TestCacheFromConfig() {
+
+	repository := timetracker.NewLocaLRepository()
+	repo1, cache1 := newRecordCache(configForTest(), repository)
+	suite.Nil(cache1)
+	suite.Equal(repository, repo1)
+
+	conf, _ := config.NewStaticConfigSource("hob:\n  records:\n    cache:\n      ttl: 30s\n      maxentries: 50\n").Load()
+	repo2, cache2 := newRecordCache(conf, repository)
+	suite.NotNil(cache2)
+	suite.Equal(cache2, repo2)
+	suite.Equal(30*time.Second, cache2.ttl)
+	suite.Equal(50, cache2.maxEntries)
+}
+
+func (suite *CachingRepositoryTestSuite) TestCacheStatsHandler() {
+
+	repo, _ := suite.repositoryForTest(10)
+	start, end := suite.timeRange()
+	repo.ListRecords("Device01", start, end)
+	repo.ListRecords("Device01", start, end)
+
+	request := events.APIGatewayProxyRequest{Resource: "/metrics/cache", HTTPMethod: http.MethodGet}
+	response1, err1 := newCacheStatsHandler(repo, loggerForTest()).Process(request)
+	suite.Nil(err1)
+	suite.Equal(http.StatusOK, response1.StatusCode)
+	var stats CacheStats
+	suite.Nil(json.Unmarshal([]byte(response1.Body), &stats))
+	suite.Equal(int64(1), stats.Hits)
+	suite.Equal(int64(1), stats.Misses)
+
+	response2, err2 := newCacheStatsHandler(nil, loggerForTest()).Process(request)
+	suite.NotNil(err2)
+	suite.Equal(http.StatusNotFound, response2.StatusCode)
+}
+
+func (suite *CachingRepositoryTestSuite) repositoryForTest(maxEntries int) (*CachingRepository, *timeTrackerListMock) {
+	mock := &timeTrackerListMock{TimeTracker: timetracker.NewLocaLRepository(), delays: make(map[string]time.Duration), failedDevices: make(map[string]bool)}
+	return newCachingRepository(&recordManagerListMock{TimeTrackingRepository: mock.TimeTracker.(TimeTrackingRepository), timeTracker: mock}, nil, time.Minute, maxEntries), mock
+}
+
+func (suite *CachingRepositoryTestSuite) timeRange() (time.Time, time.Time) {
+	return time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC), time.Date(2022, 1, 4, 0, 0, 0, 0, time.UTC)
+}
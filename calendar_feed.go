@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+const (
+	icsDateLayout     = "20060102"
+	icsDateTimeLayout = "20060102T150405Z"
+)
+
+// AbsenceEvents creates all-day events for vacation and illness days of passed records. Consecutive
+// days with same absence type are merged into a single multi-day event.
+func absenceEvents(deviceId string, records []timetracker.TimeTrackingRecord, location *time.Location) []CalendarEvent {
+
+	events := []CalendarEvent{}
+	var current *CalendarEvent
+	var currentType timetracker.RecordType
+	for _, day := range summarizeDays(records, location) {
+
+		if day.Absence == "" {
+			continue
+		}
+		date, _ := time.ParseInLocation(dateLayout, day.Date, location)
+		if current != nil && currentType == day.Absence && current.End.Equal(date) {
+			current.End = date.AddDate(0, 0, 1)
+			continue
+		}
+
+		events = append(events, CalendarEvent{
+			Uid:     fmt.Sprintf("%s-%s-%s@hob-apigw-handler", deviceId, day.Absence, date.Format(icsDateLayout)),
+			Summary: absenceSummary(day.Absence),
+			Start:   date,
+			End:     date.AddDate(0, 0, 1),
+			AllDay:  true,
+		})
+		current = &events[len(events)-1]
+		currentType = day.Absence
+	}
+	return events
+}
+
+// WorkSessionEvents creates an event for each work session of passed records.
+func workSessionEvents(deviceId string, records []timetracker.TimeTrackingRecord, location *time.Location) []CalendarEvent {
+
+	events := []CalendarEvent{}
+	for _, day := range summarizeDays(records, location) {
+		for _, session := range day.Sessions {
+			events = append(events, CalendarEvent{
+				Uid:     fmt.Sprintf("%s-session-%s@hob-apigw-handler", deviceId, session.Start.AsTime().UTC().Format(icsDateTimeLayout)),
+				Summary: "Work, " + formatDuration(time.Duration(session.Duration)*time.Minute),
+				Start:   session.Start.AsTime(),
+				End:     session.End.AsTime(),
+			})
+		}
+	}
+	return events
+}
+
+// AbsenceSummary returns the title of an absence event.
+func absenceSummary(recordType timetracker.RecordType) string {
+	if recordType == timetracker.ILLNESS {
+		return "Illness"
+	}
+	return "Vacation"
+}
+
+// RenderCalendar renders passed events as iCalendar, RFC 5545.
+func renderCalendar(name string, events []CalendarEvent, now time.Time) string {
+
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//tommzn//hob-apigw-handler//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:" + escapeIcsText(name),
+	}
+	for _, event := range events {
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			"UID:"+escapeIcsText(event.Uid),
+			"DTSTAMP:"+now.UTC().Format(icsDateTimeLayout),
+		)
+		if event.AllDay {
+			lines = append(lines,
+				"DTSTART;VALUE=DATE:"+event.Start.Format(icsDateLayout),
+				"DTEND;VALUE=DATE:"+event.End.Format(icsDateLayout),
+			)
+		} else {
+			lines = append(lines,
+				"DTSTART:"+event.Start.UTC().Format(icsDateTimeLayout),
+				"DTEND:"+event.End.UTC().Format(icsDateTimeLayout),
+			)
+		}
+		lines = append(lines,
+			"SUMMARY:"+escapeIcsText(event.Summary),
+			"TRANSP:"+eventTransparency(event),
+			"END:VEVENT",
+		)
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	content := strings.Builder{}
+	for _, line := range lines {
+		content.WriteString(foldIcsLine(line))
+		content.WriteString("\r\n")
+	}
+	return content.String()
+}
+
+// EventTransparency returns TRANSPARENT for all-day events, so they don't block time in calendars.
+func eventTransparency(event CalendarEvent) string {
+	if event.AllDay {
+		return "TRANSPARENT"
+	}
+	return "OPAQUE"
+}
+
+// EscapeIcsText escapes special characters of iCalendar text values.
+func escapeIcsText(value string) string {
+	return strings.NewReplacer("\\", "\\\\", ";", "\\;", ",", "\\,", "\n", "\\n").Replace(value)
+}
+
+// FoldIcsLine splits lines longer than 75 octets, continuation lines start with a space.
+func foldIcsLine(line string) string {
+
+	folded := strings.Builder{}
+	length := 0
+	for _, char := range line {
+		size := len(string(char))
+		if length+size > 75 {
+			folded.WriteString("\r\n ")
+			length = 1
+		}
+		folded.WriteRune(char)
+		length += size
+	}
+	return folded.String()
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	config "github.com/tommzn/go-config"
+	log "github.com/tommzn/go-log"
+	secrets "github.com/tommzn/go-secrets"
+)
+
+// CalendarFeedSuffix is the file extension feeds have to be requested with.
+const calendarFeedSuffix = ".ics"
+
+// CalendarFeedTokenSecretPrefix is the prefix of default secret names of feed tokens.
+const calendarFeedTokenSecretPrefix = "CALENDAR_FEED_TOKEN_"
+
+// NewCalendarFeedHandler returns a handler for iCalendar feeds. Available feeds and the time window
+// of records included in feeds are read from passed config. Tokens of feeds are obtained from passed
+// secrets manager, by the secret name of a feed or by default as CALENDAR_FEED_TOKEN_<DEVICEID>.
+func newCalendarFeedHandler(recordHandler *TimeTrackingRecordHandler, conf config.Config, secretsManager secrets.SecretsManager, logger log.Logger) (*CalendarFeedHandler, error) {
+
+	feeds := make(map[string]CalendarFeed)
+	for _, entry := range conf.GetAsSliceOfMaps("hob.calendar.feeds") {
+		feed := CalendarFeed{
+			DeviceId: entry["deviceid"],
+			Workdays: strings.ToLower(entry["workdays"]) == "true",
+		}
+		if feed.DeviceId == "" {
+			return nil, errors.New("Missing device id in calendar feed.")
+		}
+		secretName, ok := entry["secret"]
+		if !ok || secretName == "" {
+			secretName = calendarFeedTokenSecretPrefix + strings.ToUpper(feed.DeviceId)
+		}
+		token, err := secretsManager.Obtain(secretName)
+		if err != nil || token == nil || *token == "" {
+			return nil, fmt.Errorf("Missing token of calendar feed %s, secret: %s", feed.DeviceId, secretName)
+		}
+		feed.Token = *token
+		feeds[feed.DeviceId] = feed
+	}
+
+	return &CalendarFeedHandler{
+		logger:        logger,
+		recordHandler: recordHandler,
+		feeds:         feeds,
+		past:          *conf.GetAsDuration("hob.calendar.past", config.AsDurationPtr(90*24*time.Hour)),
+		future:        *conf.GetAsDuration("hob.calendar.future", config.AsDurationPtr(90*24*time.Hour)),
+		cacheTtl:      *conf.GetAsDuration("hob.calendar.cachettl", config.AsDurationPtr(15*time.Minute)),
+		cache:         make(map[string]cachedCalendarFeed),
+		now:           time.Now,
+	}, nil
+}
+
+// Process renders vacation and illness records of a device, and optional work sessions, as iCalendar feed.
+// A feed is requested as <deviceid>.ics and the token of this feed has to be passed as query parameter.
+// Work sessions are included if they're enabled for a feed or if they're requested with workdays=true.
+func (handler *CalendarFeedHandler) Process(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	if request.HTTPMethod != http.MethodGet {
+		err := errors.New("Unsupported HTTP method.")
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusMethodNotAllowed), err
+	}
+
+	feedName := request.PathParameters["feed"]
+	if !strings.HasSuffix(feedName, calendarFeedSuffix) {
+		err := errors.New("Unknown calendar feed.")
+		handler.logger.Error(err, " Feed: ", feedName)
+		return errorResponseWithStatus(err, http.StatusNotFound), err
+	}
+
+	deviceId := strings.TrimSuffix(feedName, calendarFeedSuffix)
+	feed, ok := handler.feeds[deviceId]
+	if !ok || subtle.ConstantTimeCompare([]byte(feed.Token), []byte(request.QueryStringParameters["token"])) != 1 {
+		err := errors.New("Invalid calendar feed or token.")
+		handler.logger.Error(err, " Feed: ", deviceId)
+		return errorResponseWithStatus(err, http.StatusForbidden), err
+	}
+
+	includeWorkdays := feed.Workdays
+	if workdays, ok := request.QueryStringParameters["workdays"]; ok {
+		includeWorkdays = strings.ToLower(workdays) == "true"
+	}
+
+	now := handler.now()
+	cacheKey := deviceId + "/" + strconv.FormatBool(includeWorkdays)
+	calendarFeed, ok := handler.cachedFeed(cacheKey, now)
+	if !ok {
+		content, err := handler.renderFeed(deviceId, includeWorkdays, now)
+		if err != nil {
+			handler.logger.Error(err)
+			return errorResponseWithStatus(err, http.StatusInternalServerError), err
+		}
+		calendarFeed = cachedCalendarFeed{content: content, etag: recordETag([]byte(content)), expires: now.Add(handler.cacheTtl)}
+		handler.cacheFeed(cacheKey, calendarFeed)
+	}
+
+	if ifNoneMatch, ok := headerValue(request, "If-None-Match"); ok && ifNoneMatch == calendarFeed.etag {
+		response := responseWithStatus(http.StatusNotModified)
+		response.Headers = map[string]string{"ETag": calendarFeed.etag}
+		return response, nil
+	}
+
+	response := responseWithContent(calendarFeed.content, http.StatusOK)
+	response.Headers = map[string]string{
+		"Content-Type":  "text/calendar; charset=utf-8",
+		"Cache-Control": "private, max-age=900",
+		"ETag":          calendarFeed.etag,
+	}
+	return response, nil
+}
+
+// RenderFeed lists records of passed device in the time window of feeds and renders them as iCalendar feed.
+func (handler *CalendarFeedHandler) renderFeed(deviceId string, includeWorkdays bool, now time.Time) (string, error) {
+
+	queryParams := map[string]string{"deviceid": deviceId}
+	location, err := handler.recordHandler.locationFor(queryParams)
+	if err != nil {
+		return "", err
+	}
+
+	start := startOfDay(now.Add(-1*handler.past), location)
+	end := startOfDay(now.Add(handler.future), location).AddDate(0, 0, 1)
+	records, err := handler.recordHandler.listRecords([]string{deviceId}, start, end, RecordFilter{location: location})
+	if err != nil {
+		return "", err
+	}
+	handler.logger.Debugf("Render calendar feed of %s for %d record(s)", deviceId, len(records))
+
+	calendarEvents := absenceEvents(deviceId, records, location)
+	if includeWorkdays {
+		calendarEvents = append(calendarEvents, workSessionEvents(deviceId, records, location)...)
+	}
+	sort.SliceStable(calendarEvents, func(i, j int) bool {
+		return calendarEvents[i].Start.Before(calendarEvents[j].Start)
+	})
+	return renderCalendar("Time Tracking "+deviceId, calendarEvents, now), nil
+}
+
+// CachedFeed returns a rendered feed for passed cache key if it's not expired.
+func (handler *CalendarFeedHandler) cachedFeed(cacheKey string, now time.Time) (cachedCalendarFeed, bool) {
+
+	handler.Lock()
+	defer handler.Unlock()
+
+	calendarFeed, ok := handler.cache[cacheKey]
+	if !ok || !now.Before(calendarFeed.expires) {
+		delete(handler.cache, cacheKey)
+		return cachedCalendarFeed{}, false
+	}
+	return calendarFeed, true
+}
+
+// CacheFeed keeps a rendered feed for passed cache key, if caching is enabled.
+func (handler *CalendarFeedHandler) cacheFeed(cacheKey string, calendarFeed cachedCalendarFeed) {
+
+	if handler.cacheTtl <= 0 {
+		return
+	}
+
+	handler.Lock()
+	defer handler.Unlock()
+	handler.cache[cacheKey] = calendarFeed
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/suite"
+	config "github.com/tommzn/go-config"
+	secrets "github.com/tommzn/go-secrets"
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+type CalendarFeedTestSuite struct {
+	suite.Suite
+}
+
+func TestCalendarFeedTestSuite(t *testing.T) {
+	suite.Run(t, new(CalendarFeedTestSuite))
+}
+
+func (suite *CalendarFeedTestSuite) TestAbsenceEvents() {
+
+	records := []timetracker.TimeTrackingRecord{
+		recordForTest(timetracker.VACATION, time.Date(2022, 1, 3, 8, 0, 0, 0, time.UTC)),
+		recordForTest(timetracker.VACATION, time.Date(2022, 1, 4, 8, 0, 0, 0, time.UTC)),
+		recordForTest(timetracker.VACATION, time.Date(2022, 1, 5, 8, 0, 0, 0, time.UTC)),
+		recordForTest(timetracker.ILLNESS, time.Date(2022, 1, 6, 8, 0, 0, 0, time.UTC)),
+		recordForTest(timetracker.WORKDAY, time.Date(2022, 1, 7, 8, 0, 0, 0, time.UTC)),
+		recordForTest(timetracker.VACATION, time.Date(2022, 1, 10, 8, 0, 0, 0, time.UTC)),
+	}
+
+	events := absenceEvents("Device01", records, time.UTC)
+	suite.Len(events, 3)
+	suite.Equal("Vacation", events[0].Summary)
+	suite.True(events[0].AllDay)
+	suite.Equal(time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC), events[0].Start)
+	suite.Equal(time.Date(2022, 1, 6, 0, 0, 0, 0, time.UTC), events[0].End)
+	suite.Equal("Illness", events[1].Summary)
+	suite.Equal(time.Date(2022, 1, 7, 0, 0, 0, 0, time.UTC), events[1].End)
+	suite.Equal(time.Date(2022, 1, 10, 0, 0, 0, 0, time.UTC), events[2].Start)
+	suite.NotEqual(events[0].Uid, events[2].Uid)
+}
+
+func (suite *CalendarFeedTestSuite) TestWorkSessionEvents() {
+
+	records := []timetracker.TimeTrackingRecord{
+		recordForTest(timetracker.WORKDAY, time.Date(2022, 1, 3, 8, 0, 0, 0, time.UTC)),
+		recordForTest(timetracker.WORKDAY, time.Date(2022, 1, 3, 16, 30, 0, 0, time.UTC)),
+		recordForTest(timetracker.WORKDAY, time.Date(2022, 1, 4, 8, 0, 0, 0, time.UTC)),
+	}
+
+	events := workSessionEvents("Device01", records, time.UTC)
+	suite.Len(events, 1)
+	suite.Equal("Work, 8h30m", events[0].Summary)
+	suite.False(events[0].AllDay)
+	suite.Equal(time.Date(2022, 1, 3, 16, 30, 0, 0, time.UTC), events[0].End.UTC())
+}
+
+func (suite *CalendarFeedTestSuite) TestRenderCalendar() {
+
+	events := []CalendarEvent{
+		{Uid: "1", Summary: "Vacation", Start: time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC), End: time.Date(2022, 1, 6, 0, 0, 0, 0, time.UTC), AllDay: true},
+		{Uid: "2", Summary: "Work, 8h30m; office", Start: time.Date(2022, 1, 7, 8, 0, 0, 0, time.UTC), End: time.Date(2022, 1, 7, 16, 30, 0, 0, time.UTC)},
+	}
+	content := renderCalendar("Time Tracking", events, time.Date(2022, 1, 8, 0, 0, 0, 0, time.UTC))
+
+	suite.True(strings.HasPrefix(content, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	suite.True(strings.HasSuffix(content, "END:VCALENDAR\r\n"))
+	suite.Equal(2, strings.Count(content, "BEGIN:VEVENT\r\n"))
+	suite.Contains(content, "DTSTART;VALUE=DATE:20220103\r\nDTEND;VALUE=DATE:20220106\r\n")
+	suite.Contains(content, "DTSTART:20220107T080000Z\r\nDTEND:20220107T163000Z\r\n")
+	suite.Contains(content, "SUMMARY:Work\\, 8h30m\\; office\r\n")
+	suite.Contains(content, "DTSTAMP:20220108T000000Z\r\n")
+
+	folded := foldIcsLine("SUMMARY:" + strings.Repeat("x", 100))
+	lines := strings.Split(folded, "\r\n")
+	suite.Len(lines, 2)
+	suite.Len(lines[0], 75)
+	suite.True(strings.HasPrefix(lines[1], " "))
+}
+
+func (suite *CalendarFeedTestSuite) TestProcessFeedRequest() {
+
+	handler := suite.handlerForTest()
+	handler.recordHandler.timeTrackingManager.Add(recordForTest(timetracker.VACATION, time.Date(2022, 1, 3, 8, 0, 0, 0, time.UTC)))
+	handler.recordHandler.timeTrackingManager.Add(recordForTest(timetracker.VACATION, time.Date(2022, 1, 4, 8, 0, 0, 0, time.UTC)))
+	prepareForTest(handler.recordHandler.timeTrackingManager)
+
+	response1, err1 := handler.Process(suite.requestForTest("Device01.ics", map[string]string{"token": "feed-token-01"}))
+	suite.Nil(err1)
+	suite.Equal(http.StatusOK, response1.StatusCode)
+	suite.Equal("text/calendar; charset=utf-8", response1.Headers["Content-Type"])
+	suite.Equal(1, strings.Count(response1.Body, "BEGIN:VEVENT"))
+	suite.Contains(response1.Body, "DTEND;VALUE=DATE:20220105")
+
+	response2, err2 := handler.Process(suite.requestForTest("Device01.ics", map[string]string{"token": "feed-token-01", "workdays": "true"}))
+	suite.Nil(err2)
+	suite.Equal(2, strings.Count(response2.Body, "BEGIN:VEVENT"))
+	suite.Contains(response2.Body, "SUMMARY:Work\\, 8h00m")
+
+	response3, err3 := handler.Process(suite.requestForTest("Device01.ics", map[string]string{"token": "xxx"}))
+	suite.NotNil(err3)
+	suite.Equal(http.StatusForbidden, response3.StatusCode)
+
+	response4, err4 := handler.Process(suite.requestForTest("Device02.ics", map[string]string{"token": "feed-token-01"}))
+	suite.NotNil(err4)
+	suite.Equal(http.StatusForbidden, response4.StatusCode)
+
+	response5, err5 := handler.Process(suite.requestForTest("Device01.ics", map[string]string{}))
+	suite.NotNil(err5)
+	suite.Equal(http.StatusForbidden, response5.StatusCode)
+
+	response6, err6 := handler.Process(suite.requestForTest("Device01", map[string]string{"token": "feed-token-01"}))
+	suite.NotNil(err6)
+	suite.Equal(http.StatusNotFound, response6.StatusCode)
+}
+
+func (suite *CalendarFeedTestSuite) TestCacheRenderedFeeds() {
+
+	handler := suite.handlerForTest()
+	handler.recordHandler.timeTrackingManager.Add(recordForTest(timetracker.VACATION, time.Date(2022, 1, 3, 8, 0, 0, 0, time.UTC)))
+	listMock := &timeTrackerListMock{TimeTracker: handler.recordHandler.timeTracker}
+	handler.recordHandler.timeTracker = listMock
+	handler.recordHandler.listChunk = 0
+	request := suite.requestForTest("Device01.ics", map[string]string{"token": "feed-token-01"})
+
+	response1, err1 := handler.Process(request)
+	suite.Nil(err1)
+	suite.Equal(http.StatusOK, response1.StatusCode)
+	suite.NotEqual("", response1.Headers["ETag"])
+	suite.Equal(1, listMock.calls)
+
+	handler.recordHandler.timeTrackingManager.Add(recordForTest(timetracker.VACATION, time.Date(2022, 1, 10, 8, 0, 0, 0, time.UTC)))
+	response2, err2 := handler.Process(request)
+	suite.Nil(err2)
+	suite.Equal(response1.Body, response2.Body)
+	suite.Equal(1, listMock.calls)
+
+	request.Headers = map[string]string{"if-none-match": response1.Headers["ETag"]}
+	response3, err3 := handler.Process(request)
+	suite.Nil(err3)
+	suite.Equal(http.StatusNotModified, response3.StatusCode)
+	suite.Equal("", response3.Body)
+	suite.Equal(1, listMock.calls)
+
+	response4, err4 := handler.Process(suite.requestForTest("Device01.ics", map[string]string{"token": "feed-token-01", "workdays": "true"}))
+	suite.Nil(err4)
+	suite.Equal(http.StatusOK, response4.StatusCode)
+	suite.Equal(2, listMock.calls)
+
+	handler.now = func() time.Time {
+		return time.Date(2022, 1, 15, 12, 15, 0, 0, time.UTC)
+	}
+	response5, err5 := handler.Process(request)
+	suite.Nil(err5)
+	suite.Equal(http.StatusOK, response5.StatusCode)
+	suite.Equal(2, strings.Count(response5.Body, "BEGIN:VEVENT"))
+	suite.Equal(3, listMock.calls)
+
+	handler.cacheTtl = 0
+	handler.cache = make(map[string]cachedCalendarFeed)
+	handler.Process(request)
+	handler.Process(request)
+	suite.Equal(5, listMock.calls)
+}
+
+func (suite *CalendarFeedTestSuite) TestFeedTokensFromSecrets() {
+
+	conf, _ := config.NewStaticConfigSource("hob:\n  calendar:\n    feeds:\n      - deviceid: Device01\n        secret: FEED_TOKEN\n").Load()
+	handler, err := newCalendarFeedHandler(timeTrackingRecordHandlerForTest(), conf, secrets.NewStaticSecretsManager(map[string]string{"FEED_TOKEN": "feed-token-02"}), loggerForTest())
+	suite.Nil(err)
+	suite.Equal("feed-token-02", handler.feeds["Device01"].Token)
+
+	_, err2 := newCalendarFeedHandler(timeTrackingRecordHandlerForTest(), conf, secrets.NewStaticSecretsManager(map[string]string{}), loggerForTest())
+	suite.NotNil(err2)
+}
+
+func (suite *CalendarFeedTestSuite) TestInvalidFeedConfig() {
+
+	conf, _ := config.NewStaticConfigSource("hob:\n  calendar:\n    feeds:\n      - secret: CALENDAR_FEED_TOKEN_DEVICE01\n").Load()
+	_, err := newCalendarFeedHandler(timeTrackingRecordHandlerForTest(), conf, calendarFeedSecretsForTest(), loggerForTest())
+	suite.NotNil(err)
+}
+
+func (suite *CalendarFeedTestSuite) handlerForTest() *CalendarFeedHandler {
+	handler, err := newCalendarFeedHandler(timeTrackingRecordHandlerForTest(), configForTest(), calendarFeedSecretsForTest(), loggerForTest())
+	suite.Nil(err)
+	handler.now = func() time.Time {
+		return time.Date(2022, 1, 15, 12, 0, 0, 0, time.UTC)
+	}
+	return handler
+}
+
+func calendarFeedSecretsForTest() secrets.SecretsManager {
+	return secrets.NewStaticSecretsManager(map[string]string{"CALENDAR_FEED_TOKEN_DEVICE01": "feed-token-01"})
+}
+
+func (suite *CalendarFeedTestSuite) requestForTest(feed string, queryParams map[string]string) events.APIGatewayProxyRequest {
+	request := timeTrackingRecordHandlerRequestForTest(http.MethodGet)
+	request.Resource = "/calendar/{feed}"
+	request.PathParameters = map[string]string{"feed": feed}
+	request.QueryStringParameters = queryParams
+	return request
+}
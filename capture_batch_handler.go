@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	log "github.com/tommzn/go-log"
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+// NewCaptureBatchRequestHandler create a handler to process a list of captured time tracking events.
+func newCaptureBatchRequestHandler(timeTracker TimeTrackingRepository, clickTypeMapping *ClickTypeMapping, timestampValidator *TimestampValidator, recordIds *RecordIdCodec, maxBatchSize int, logger log.Logger) *CaptureBatchRequestHandler {
+	return &CaptureBatchRequestHandler{
+		logger:             logger,
+		timeTracker:        timeTracker,
+		clickTypeMapping:   clickTypeMapping,
+		timestampValidator: timestampValidator,
+		recordIds:          recordIds,
+		maxBatchSize:       maxBatchSize,
+	}
+}
+
+// Process will persist each captured time tracking event of a batch and returns a result for each of them.
+// Captures without a timestamp are persisted with current time.
+func (handler *CaptureBatchRequestHandler) Process(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	var captures []TimeTrackingCapture
+	if err := json.Unmarshal([]byte(request.Body), &captures); err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusBadRequest), err
+	}
+
+	if len(captures) == 0 {
+		err := errors.New("Empty capture batch.")
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusBadRequest), err
+	}
+	if len(captures) > handler.maxBatchSize {
+		err := fmt.Errorf("Capture batch exceeds max size of %d items.", handler.maxBatchSize)
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusRequestEntityTooLarge), err
+	}
+	handler.logger.Statusf("Receive capture batch with %d item(s)", len(captures))
+
+	results := []CaptureBatchItemResult{}
+	for idx, capture := range captures {
+		results = append(results, handler.captureItem(idx, capture))
+	}
+
+	responseContent, err := json.Marshal(results)
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusInternalServerError), err
+	}
+	return withServerTime(responseWithContent(string(responseContent), http.StatusMultiStatus), time.Now()), nil
+}
+
+// CaptureItem persists a single item of a capture batch. Key of a created record is returned as opaque id.
+func (handler *CaptureBatchRequestHandler) captureItem(idx int, capture TimeTrackingCapture) CaptureBatchItemResult {
+
+	result := CaptureBatchItemResult{Index: idx}
+	if capture.DeviceId == "" {
+		result.Status = http.StatusBadRequest
+		result.Error = "Missing device id."
+		return result
+	}
+
+	recordType, err := handler.clickTypeMapping.recordTypeForCapture(capture)
+	if err != nil {
+		result.Status = http.StatusBadRequest
+		result.Error = err.Error()
+		return result
+	}
+
+	timestamp := time.Now()
+	if capture.Timestamp != nil {
+		timestamp, err = handler.timestampValidator.validate(capture.Timestamp.AsTime())
+		if err != nil {
+			result.Status = http.StatusBadRequest
+			result.Error = err.Error()
+			return result
+		}
+	}
+
+	record, err := handler.timeTracker.Add(timetracker.TimeTrackingRecord{DeviceId: capture.DeviceId, Type: recordType, Timestamp: timestamp})
+	if err != nil {
+		handler.logger.Errorf("Unable to capture batch item %d, reason: %s", idx, err)
+		result.Status = http.StatusInternalServerError
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Status = http.StatusCreated
+	result.Key = handler.recordIds.encode(record.Key)
+	result.RecordType = recordType
+	result.Timestamp = &APITime{Time: timestamp}
+	return result
+}
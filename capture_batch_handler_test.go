@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/suite"
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+type CaptureBatchHandlerTestSuite struct {
+	suite.Suite
+}
+
+func TestCaptureBatchHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(CaptureBatchHandlerTestSuite))
+}
+
+func (suite *CaptureBatchHandlerTestSuite) TestProcessBatch() {
+
+	handler := captureBatchHandlerForTest(5)
+	timestamp := time.Date(2022, 1, 3, 8, 12, 0, 0, time.UTC)
+	captures := []TimeTrackingCapture{
+		{DeviceId: "Device01", ClickType: SINGLE_CLICK, Timestamp: &APITime{Time: timestamp}},
+		{DeviceId: "Device01", ClickType: IotClickType("TRIPLE"), Timestamp: &APITime{Time: timestamp}},
+		{DeviceId: "Device01", ClickType: SINGLE_CLICK, Timestamp: &APITime{Time: timestamp.Add(8 * time.Hour)}},
+		{DeviceId: "", ClickType: SINGLE_CLICK},
+		{DeviceId: "Device02", ClickType: LONG_PRESS},
+	}
+
+	res1, err1 := handler.Process(suite.requestForTest(captures))
+	suite.Nil(err1)
+	suite.Equal(http.StatusMultiStatus, res1.StatusCode)
+
+	var results []CaptureBatchItemResult
+	suite.Nil(json.Unmarshal([]byte(res1.Body), &results))
+	suite.Len(results, 5)
+	suite.Equal(http.StatusCreated, results[0].Status)
+	suite.NotEqual("", results[0].Key)
+	suite.Equal(http.StatusBadRequest, results[1].Status)
+	suite.NotEqual("", results[1].Error)
+	suite.Equal(http.StatusCreated, results[2].Status)
+	suite.NotEqual("", results[2].Key)
+	suite.NotEqual(results[0].Key, results[2].Key)
+	suite.Equal(http.StatusBadRequest, results[3].Status)
+	suite.Equal(http.StatusCreated, results[4].Status)
+	suite.Equal(timetracker.VACATION, results[4].RecordType)
+	suite.NotEqual("", results[4].Key)
+
+	records, err := handler.timeTracker.ListRecords("Device01", timestamp.Add(-1*time.Hour), timestamp.Add(12*time.Hour))
+	suite.Nil(err)
+	suite.Len(records, 2)
+	suite.Equal(recordIdCodecForTest().encode(records[0].Key), results[0].Key)
+	suite.Equal(recordIdCodecForTest().encode(records[1].Key), results[2].Key)
+}
+
+func (suite *CaptureBatchHandlerTestSuite) TestProcessBatchWithFailingRepository() {
+
+	handler := captureBatchHandlerForTest(5)
+	handler.timeTracker = &recordManagerErrorMock{TimeTrackingRepository: timetracker.NewLocaLRepository()}
+	captures := []TimeTrackingCapture{{DeviceId: "Device01", ClickType: SINGLE_CLICK}}
+
+	res1, err1 := handler.Process(suite.requestForTest(captures))
+	suite.Nil(err1)
+	var results []CaptureBatchItemResult
+	suite.Nil(json.Unmarshal([]byte(res1.Body), &results))
+	suite.Equal(http.StatusInternalServerError, results[0].Status)
+	suite.Equal("", results[0].Key)
+}
+
+func (suite *CaptureBatchHandlerTestSuite) TestProcessInvalidBatch() {
+
+	handler := captureBatchHandlerForTest(1)
+	captures := []TimeTrackingCapture{
+		{DeviceId: "Device01", ClickType: SINGLE_CLICK},
+		{DeviceId: "Device01", ClickType: SINGLE_CLICK},
+	}
+
+	res1, err1 := handler.Process(suite.requestForTest(captures))
+	suite.NotNil(err1)
+	suite.Equal(http.StatusRequestEntityTooLarge, res1.StatusCode)
+
+	res2, err2 := handler.Process(suite.requestForTest([]TimeTrackingCapture{}))
+	suite.NotNil(err2)
+	suite.Equal(http.StatusBadRequest, res2.StatusCode)
+
+	res3, err3 := handler.Process(events.APIGatewayProxyRequest{Body: "{}"})
+	suite.NotNil(err3)
+	suite.Equal(http.StatusBadRequest, res3.StatusCode)
+}
+
+func (suite *CaptureBatchHandlerTestSuite) requestForTest(captures []TimeTrackingCapture) events.APIGatewayProxyRequest {
+	content, err := json.Marshal(captures)
+	suite.Nil(err)
+	return events.APIGatewayProxyRequest{Resource: "/capture/batch", Body: string(content)}
+}
+
+func captureBatchHandlerForTest(maxBatchSize int) *CaptureBatchRequestHandler {
+	clickTypeMapping, _ := newClickTypeMapping(configForTest())
+	return newCaptureBatchRequestHandler(timetracker.NewLocaLRepository(), clickTypeMapping, newTimestampValidator(configForTest()), recordIdCodecForTest(), maxBatchSize, loggerForTest())
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/aws/aws-lambda-go/events"
+	log "github.com/tommzn/go-log"
+	core "github.com/tommzn/hob-core"
+	timetracker "github.com/tommzn/hob-timetracker"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// NewCaptureQueueConsumer returns a consumer to persist capture events received from AWS SQS.
+// If a webhook notifier is passed, webhook deliveries received from AWS SQS are send as well.
+func newCaptureQueueConsumer(timeTracker timetracker.TimeTracker, webhookNotifier *WebhookNotifier, logger log.Logger) *CaptureQueueConsumer {
+	return &CaptureQueueConsumer{
+		logger:          logger,
+		timeTracker:     timeTracker,
+		webhookNotifier: webhookNotifier,
+	}
+}
+
+// Consume persists all capture events of passed SQS event. Messages which can not be processed
+// are reported as batch item failures, so they will be redelivered.
+func (consumer *CaptureQueueConsumer) Consume(event events.SQSEvent) (events.SQSEventResponse, error) {
+
+	defer consumer.logger.Flush()
+	consumer.logger.Debugf("Receive %d capture event(s)", len(event.Records))
+
+	response := events.SQSEventResponse{BatchItemFailures: []events.SQSBatchItemFailure{}}
+	for _, message := range event.Records {
+		if err := consumer.processMessage(message); err != nil {
+			consumer.logger.Errorf("Unable to process capture event %s, reason: %s", message.MessageId, err)
+			response.BatchItemFailures = append(response.BatchItemFailures, events.SQSBatchItemFailure{ItemIdentifier: message.MessageId})
+		}
+	}
+	return response, nil
+}
+
+// ProcessMessage decodes a capture event from passed message and persists it. Webhook deliveries
+// are send to their webhook.
+func (consumer *CaptureQueueConsumer) processMessage(message events.SQSMessage) error {
+
+	captureEvent := &structpb.Struct{}
+	if err := core.DeserializeEvent(message.Body, captureEvent); err != nil {
+		return err
+	}
+
+	if isWebhookDeliveryEvent(captureEvent) {
+		return consumer.processWebhookDelivery(captureEvent)
+	}
+
+	deviceId, recordType, timestamp, err := fromCaptureEvent(captureEvent)
+	if err != nil {
+		return err
+	}
+	consumer.logger.Statusf("Persist capture event (%s) from %s at %s", recordType, deviceId, timestamp)
+	return consumer.timeTracker.Captured(deviceId, recordType, timestamp)
+}
+
+// ProcessWebhookDelivery sends a webhook event of passed delivery event.
+func (consumer *CaptureQueueConsumer) processWebhookDelivery(deliveryEvent *structpb.Struct) error {
+
+	if consumer.webhookNotifier == nil {
+		return errors.New("No webhook notifier available.")
+	}
+	url, event, err := fromWebhookDeliveryEvent(deliveryEvent)
+	if err != nil {
+		return err
+	}
+	return consumer.webhookNotifier.deliverQueued(url, event)
+}
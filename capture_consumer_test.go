@@ -0,0 +1,99 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/suite"
+	core "github.com/tommzn/hob-core"
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+type CaptureConsumerTestSuite struct {
+	suite.Suite
+}
+
+func TestCaptureConsumerTestSuite(t *testing.T) {
+	suite.Run(t, new(CaptureConsumerTestSuite))
+}
+
+func (suite *CaptureConsumerTestSuite) TestConsumeCaptureEvents() {
+
+	repo := timetracker.NewLocaLRepository()
+	consumer := newCaptureQueueConsumer(repo, nil, loggerForTest())
+	timestamp := time.Date(2022, 1, 3, 8, 12, 0, 0, time.UTC)
+
+	event := events.SQSEvent{Records: []events.SQSMessage{
+		suite.messageForTest("Msg01", "Device01", timetracker.WORKDAY, timestamp),
+		{MessageId: "Msg02", Body: "xxx"},
+		suite.messageForTest("Msg03", "", timetracker.WORKDAY, timestamp),
+		suite.messageForTest("Msg04", "Device01", timetracker.ILLNESS, timestamp.Add(time.Hour)),
+	}}
+
+	response, err := consumer.Consume(event)
+	suite.Nil(err)
+	suite.Len(response.BatchItemFailures, 2)
+	suite.Equal("Msg02", response.BatchItemFailures[0].ItemIdentifier)
+	suite.Equal("Msg03", response.BatchItemFailures[1].ItemIdentifier)
+
+	records, err := repo.ListRecords("Device01", timestamp.Add(-1*time.Hour), timestamp.Add(2*time.Hour))
+	suite.Nil(err)
+	suite.Len(records, 2)
+}
+
+func (suite *CaptureConsumerTestSuite) TestConsumeWithFailingTimeTracker() {
+
+	consumer := newCaptureQueueConsumer(&timeTrackerErrorMock{}, nil, loggerForTest())
+	event := events.SQSEvent{Records: []events.SQSMessage{
+		suite.messageForTest("Msg01", "Device01", timetracker.WORKDAY, time.Now()),
+	}}
+
+	response, err := consumer.Consume(event)
+	suite.Nil(err)
+	suite.Len(response.BatchItemFailures, 1)
+}
+
+func (suite *CaptureConsumerTestSuite) TestConsumeWebhookDeliveries() {
+
+	receiver := newWebhookReceiverForTest(3)
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	subscription := WebhookSubscription{Url: server.URL}
+	deliveryLog := newInMemoryWebhookDeliveryLog(10)
+	notifier := newWebhookNotifierWithSubscriptions([]WebhookSubscription{subscription}, server.Client(), 2, time.Millisecond, deliveryLog, loggerForTest())
+	consumer := newCaptureQueueConsumer(timetracker.NewLocaLRepository(), notifier, loggerForTest())
+
+	deliveryEvent, err := newWebhookDeliveryEvent(subscription, WebhookEvent{Event: RECORD_DELETED, DeviceId: "Device01"})
+	suite.Nil(err)
+	body, err := core.SerializeEvent(deliveryEvent)
+	suite.Nil(err)
+	event := events.SQSEvent{Records: []events.SQSMessage{{MessageId: "Msg01", EventSource: sqsEventSource, Body: body}}}
+
+	response1, err1 := consumer.Consume(event)
+	suite.Nil(err1)
+	suite.Len(response1.BatchItemFailures, 1)
+
+	response2, err2 := consumer.Consume(event)
+	suite.Nil(err2)
+	suite.Len(response2.BatchItemFailures, 0)
+	suite.Len(receiver.requests, 1)
+	deliveries, err := deliveryLog.List(time.Now(), 10)
+	suite.Nil(err)
+	suite.Len(deliveries, 2)
+
+	consumer.webhookNotifier = nil
+	response3, err3 := consumer.Consume(event)
+	suite.Nil(err3)
+	suite.Len(response3.BatchItemFailures, 1)
+}
+
+func (suite *CaptureConsumerTestSuite) messageForTest(messageId, deviceId string, recordType timetracker.RecordType, timestamp time.Time) events.SQSMessage {
+	captureEvent, err := newCaptureEvent(deviceId, recordType, timestamp)
+	suite.Nil(err)
+	body, err := core.SerializeEvent(captureEvent)
+	suite.Nil(err)
+	return events.SQSMessage{MessageId: messageId, EventSource: sqsEventSource, Body: body}
+}
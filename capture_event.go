@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	timetracker "github.com/tommzn/hob-timetracker"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	captureEventDeviceId   = "deviceid"
+	captureEventRecordType = "recordtype"
+	captureEventTimestamp  = "timestamp"
+)
+
+// NewCaptureEvent creates an event for a captured time tracking record, which can be published to a queue.
+func newCaptureEvent(deviceId string, recordType timetracker.RecordType, timestamp time.Time) (*structpb.Struct, error) {
+	return structpb.NewStruct(map[string]interface{}{
+		captureEventDeviceId:   deviceId,
+		captureEventRecordType: string(recordType),
+		captureEventTimestamp:  timestamp.UTC().Format(time.RFC3339Nano),
+	})
+}
+
+// FromCaptureEvent extracts device id, record type and timestamp from passed capture event.
+func fromCaptureEvent(event *structpb.Struct) (string, timetracker.RecordType, time.Time, error) {
+
+	fields := event.GetFields()
+	deviceId := fields[captureEventDeviceId].GetStringValue()
+	if deviceId == "" {
+		return "", "", time.Time{}, errors.New("Missing device id in capture event.")
+	}
+
+	recordType, err := toRecordType(fields[captureEventRecordType].GetStringValue())
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+
+	timestamp, err := time.Parse(time.RFC3339Nano, fields[captureEventTimestamp].GetStringValue())
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	return deviceId, recordType, timestamp, nil
+}
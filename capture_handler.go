@@ -2,6 +2,8 @@ package main
 
 import (
 	"encoding/json"
+	"net/http"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	log "github.com/tommzn/go-log"
@@ -9,14 +11,25 @@ import (
 )
 
 // NewRequestHandler create a handler to process API Gateway requests.
-func newCaptureRequestHandler(timeTracker timetracker.TimeTracker, logger log.Logger) *CaptureRequestHandler {
+// Passed publisher is optional. If it's available captures are send to a queue in async mode or
+// if they can't be persisted in sync mode.
+func newCaptureRequestHandler(timeTracker timetracker.TimeTracker, clickTypeMapping *ClickTypeMapping, timestampValidator *TimestampValidator, publisher Publisher, asyncCapture bool, recordIds *RecordIdCodec, timezones *TimezoneSettings, logger log.Logger) *CaptureRequestHandler {
 	return &CaptureRequestHandler{
-		logger:      logger,
-		timeTracker: timeTracker,
+		logger:             logger,
+		timeTracker:        timeTracker,
+		clickTypeMapping:   clickTypeMapping,
+		timestampValidator: timestampValidator,
+		publisher:          publisher,
+		asyncCapture:       asyncCapture && publisher != nil,
+		recordIds:          recordIds,
+		timezones:          timezones,
 	}
 }
 
 // Process will process time tracking request and persist it using time tracker repository.
+// Response contains the created record and the derived work state of its day.
+// In async mode a capture event is published to a queue and status 202 is returned. Same happens
+// in sync mode, if a publisher is available and a capture can't be persisted directly.
 func (handler *CaptureRequestHandler) Process(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 
 	timeTrackingRecord, err := toTimeTrackingRecord(request.Body)
@@ -27,37 +40,120 @@ func (handler *CaptureRequestHandler) Process(request events.APIGatewayProxyRequ
 
 	handler.logger.Debugf("TimeTrackingRecord: %+v", timeTrackingRecord)
 	handler.logger.Statusf("Receive capture request (%s) from %s at %s", timeTrackingRecord.ClickType, timeTrackingRecord.DeviceId, timeTrackingRecord.Timestamp)
+	if timeTrackingRecord.RemainingLife != nil {
+		handler.logger.Infof("Remaining life of %s: %.2f%%", timeTrackingRecord.DeviceId, *timeTrackingRecord.RemainingLife)
+	}
+
+	recordType, err := handler.clickTypeMapping.recordTypeForCapture(timeTrackingRecord)
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusBadRequest), err
+	}
+
+	serverTime := time.Now()
+	timestamp := serverTime
+	if timeTrackingRecord.Timestamp != nil {
+		timestamp, err = handler.timestampValidator.validate(timeTrackingRecord.Timestamp.AsTime())
+		if err != nil {
+			handler.logger.Error(err)
+			return withServerTime(errorResponseWithStatus(err, http.StatusBadRequest), serverTime), err
+		}
+	}
+
+	response := CaptureResponse{
+		DeviceId:   timeTrackingRecord.DeviceId,
+		RecordType: recordType,
+		Timestamp:  &APITime{Time: timestamp},
+		ServerTime: &APITime{Time: serverTime},
+	}
 
-	recordType := toTimeTrackingRecordType(timeTrackingRecord.ClickType)
-	if timeTrackingRecord.Timestamp == nil {
-		err = handler.timeTracker.Capture(timeTrackingRecord.DeviceId, recordType)
-	} else {
-		err = handler.timeTracker.Captured(timeTrackingRecord.DeviceId, recordType, timeTrackingRecord.Timestamp.AsTime())
+	if handler.asyncCapture {
+		publishErr := handler.publishCapture(timeTrackingRecord.DeviceId, recordType, timestamp)
+		if publishErr == nil {
+			return handler.captureResponse(response, http.StatusAccepted)
+		}
+		handler.logger.Error("Unable to publish capture event, try to persist it directly. Reason: ", publishErr)
 	}
 
+	err = handler.timeTracker.Captured(timeTrackingRecord.DeviceId, recordType, timestamp)
 	if err != nil {
 		handler.logger.Error("Unable to capture time tracking recoed, reason: ", err)
+		if !handler.asyncCapture && handler.publisher != nil {
+			if publishErr := handler.publishCapture(timeTrackingRecord.DeviceId, recordType, timestamp); publishErr == nil {
+				handler.logger.Info("Capture event has been forwarded to queue.")
+				return handler.captureResponse(response, http.StatusAccepted)
+			}
+		}
 		return errorResponse(err), err
 	}
-	return successfulResponse(), nil
+
+	handler.assignRecordAndState(&response, serverTime)
+	return handler.captureResponse(response, http.StatusOK)
 }
 
-// ToTimeTrackingRecordType converts a AWS IOT click type to a time tracking record type.
-func toTimeTrackingRecordType(clickType IotClickType) timetracker.RecordType {
-	switch clickType {
-	case SINGLE_CLICK:
-		return timetracker.WORKDAY
-	case DOUBLE_CLICK:
-		return timetracker.ILLNESS
-	case LONG_PRESS:
-		return timetracker.VACATION
-	default:
-		return timetracker.WORKDAY
+// AssignRecordAndState lists all records for the day of a capture, in timezone of capturing device, to assign
+// the key of a created record and the derived work state to passed response. Errors are logged, only.
+func (handler *CaptureRequestHandler) assignRecordAndState(response *CaptureResponse, serverTime time.Time) {
+
+	location := handler.timezones.locationOfDevice(response.DeviceId)
+	start := startOfDay(response.Timestamp.AsTime(), location)
+	end := start.AddDate(0, 0, 1)
+	dayRecords, err := handler.timeTracker.ListRecords(response.DeviceId, start, end)
+	if err != nil {
+		handler.logger.Error("Unable to list records for capture response, reason: ", err)
+		return
+	}
+	records := []timetracker.TimeTrackingRecord{}
+	for _, record := range dayRecords {
+		if record.Timestamp.Before(end) {
+			records = append(records, record)
+		}
+	}
+
+	if record := findRecord(records, response.RecordType, response.Timestamp.AsTime(), nil); record != nil {
+		response.Key = handler.recordIds.encode(record.Key)
 	}
+	state := deriveWorkState(records, serverTime, location)
+	response.State = &state
+}
+
+// CaptureResponse returns passed capture response as JSON with given status code.
+func (handler *CaptureRequestHandler) captureResponse(response CaptureResponse, statusCode int) (events.APIGatewayProxyResponse, error) {
+
+	responseContent, err := json.Marshal(response)
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponse(err), err
+	}
+	return withServerTime(responseWithContent(string(responseContent), statusCode), response.ServerTime.AsTime()), nil
+}
+
+// PublishCapture sends a capture event for passed values to a queue.
+func (handler *CaptureRequestHandler) publishCapture(deviceId string, recordType timetracker.RecordType, timestamp time.Time) error {
+
+	event, err := newCaptureEvent(deviceId, recordType, timestamp)
+	if err != nil {
+		return err
+	}
+	return handler.publisher.Send(event)
 }
 
 // ToTimeTrackingRecord try to convert passed request body to a time tracking record.
+// Supports native AWS IoT 1-Click and AWS IoT Core button events as well.
 func toTimeTrackingRecord(requestBody string) (TimeTrackingCapture, error) {
+
+	var payload map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(requestBody), &payload); err != nil {
+		return TimeTrackingCapture{}, err
+	}
+
+	if isIotOneClickEvent(payload) {
+		return fromIotOneClickEvent(requestBody)
+	}
+	if isIotCoreButtonEvent(payload) {
+		return fromIotCoreButtonEvent(requestBody)
+	}
+
 	var timeTrackingRecord TimeTrackingCapture
 	err := json.Unmarshal([]byte(requestBody), &timeTrackingRecord)
 	return timeTrackingRecord, err
@@ -2,6 +2,8 @@ package main
 
 import (
 	"encoding/json"
+	"net/http"
+	"os"
 	"testing"
 	"time"
 
@@ -35,11 +37,128 @@ func (suite *HandlerTestSuite) TestProcessRequests() {
 	suite.Equal(200, res2.StatusCode)
 }
 
-func (suite *HandlerTestSuite) TestConvertClickType() {
+func (suite *HandlerTestSuite) TestProcessUnknownClickType() {
 
-	suite.Equal(timetracker.WORKDAY, toTimeTrackingRecordType(SINGLE_CLICK))
-	suite.Equal(timetracker.ILLNESS, toTimeTrackingRecordType(DOUBLE_CLICK))
-	suite.Equal(timetracker.VACATION, toTimeTrackingRecordType(LONG_PRESS))
+	handler := handlerForTest()
+	record := TimeTrackingCapture{DeviceId: "Device01", ClickType: IotClickType("TRIPLE")}
+
+	res1, err1 := handler.Process(suite.requestForTest(record))
+	suite.NotNil(err1)
+	suite.Equal(http.StatusBadRequest, res1.StatusCode)
+}
+
+func (suite *HandlerTestSuite) TestProcessImplausibleTimestamp() {
+
+	handler := handlerForTest()
+	record := TimeTrackingCapture{DeviceId: "Device01", ClickType: SINGLE_CLICK, Timestamp: &APITime{Time: time.Unix(0, 0)}}
+
+	res1, err1 := handler.Process(suite.requestForTest(record))
+	suite.NotNil(err1)
+	suite.Equal(http.StatusBadRequest, res1.StatusCode)
+	suite.NotEqual("", res1.Headers[serverTimeHeader])
+}
+
+func (suite *HandlerTestSuite) TestProcessIotOneClickEvent() {
+
+	handler := handlerForTest()
+	content, err := os.ReadFile("fixtures/iot-1click-event.json")
+	suite.Nil(err)
+
+	res1, err1 := handler.Process(events.APIGatewayProxyRequest{Body: string(content)})
+	suite.Nil(err1)
+	suite.Equal(200, res1.StatusCode)
+
+	records, err2 := handler.timeTracker.ListRecords("Device01", time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC), time.Date(2022, 1, 3, 23, 59, 59, 0, time.UTC))
+	suite.Nil(err2)
+	suite.Len(records, 1)
+	suite.Equal(timetracker.WORKDAY, records[0].Type)
+}
+
+func (suite *HandlerTestSuite) TestCaptureResponse() {
+
+	handler := handlerForTest()
+	timestamp := time.Date(2022, 1, 3, 8, 12, 0, 0, time.UTC)
+	record := TimeTrackingCapture{DeviceId: "Device01", ClickType: SINGLE_CLICK, Timestamp: &APITime{Time: timestamp}}
+
+	res1, err1 := handler.Process(suite.requestForTest(record))
+	suite.Nil(err1)
+	suite.Equal(http.StatusOK, res1.StatusCode)
+
+	var response1 CaptureResponse
+	suite.Nil(json.Unmarshal([]byte(res1.Body), &response1))
+	suite.NotEqual("", response1.Key)
+	suite.Equal(timetracker.WORKDAY, response1.RecordType)
+	suite.NotNil(response1.ServerTime)
+	suite.NotNil(response1.State)
+	suite.Equal(WORKING, response1.State.Status)
+	suite.Equal("working since 08:12", response1.State.Message)
+	suite.Equal(948, response1.State.WorkingTime)
+
+	record.Timestamp = &APITime{Time: timestamp.Add(8*time.Hour + 3*time.Minute)}
+	res2, err2 := handler.Process(suite.requestForTest(record))
+	suite.Nil(err2)
+
+	var response2 CaptureResponse
+	suite.Nil(json.Unmarshal([]byte(res2.Body), &response2))
+	suite.NotEqual(response1.Key, response2.Key)
+	suite.Equal(DAY_CLOSED, response2.State.Status)
+	suite.Equal("day closed, 8h03m", response2.State.Message)
+}
+
+func (suite *HandlerTestSuite) TestCaptureResponseInDeviceTimezone() {
+
+	handler := handlerForTest()
+	record := TimeTrackingCapture{DeviceId: "Device03", ClickType: SINGLE_CLICK, Timestamp: &APITime{Time: time.Date(2022, 1, 3, 22, 30, 0, 0, time.UTC)}}
+	_, err1 := handler.Process(suite.requestForTest(record))
+	suite.Nil(err1)
+
+	record.Timestamp = &APITime{Time: time.Date(2022, 1, 3, 23, 30, 0, 0, time.UTC)}
+	res2, err2 := handler.Process(suite.requestForTest(record))
+	suite.Nil(err2)
+
+	var response2 CaptureResponse
+	suite.Nil(json.Unmarshal([]byte(res2.Body), &response2))
+	suite.NotEqual("", response2.Key)
+	suite.Equal(WORKING, response2.State.Status)
+	suite.Equal("working since 00:30", response2.State.Message)
+	suite.Equal(1410, response2.State.WorkingTime)
+}
+
+func (suite *HandlerTestSuite) TestAsyncCapture() {
+
+	publisher := newSqsMock()
+	clickTypeMapping, _ := newClickTypeMapping(configForTest())
+	handler := newCaptureRequestHandler(timetracker.NewLocaLRepository(), clickTypeMapping, newTimestampValidator(configForTest()), publisher, true, recordIdCodecForTest(), timezonesForTest(), loggerForTest())
+	record := TimeTrackingCapture{DeviceId: "Device01", ClickType: SINGLE_CLICK}
+
+	res1, err1 := handler.Process(suite.requestForTest(record))
+	suite.Nil(err1)
+	suite.Equal(http.StatusAccepted, res1.StatusCode)
+	suite.Equal(1, publisher.callCount)
+
+	publisher.shouldReturnError = true
+	res2, err2 := handler.Process(suite.requestForTest(record))
+	suite.Nil(err2)
+	suite.Equal(http.StatusOK, res2.StatusCode)
+	suite.Equal(2, publisher.callCount)
+}
+
+func (suite *HandlerTestSuite) TestStoreAndForward() {
+
+	publisher := newSqsMock()
+	clickTypeMapping, _ := newClickTypeMapping(configForTest())
+	handler := newCaptureRequestHandler(&timeTrackerErrorMock{}, clickTypeMapping, newTimestampValidator(configForTest()), publisher, false, recordIdCodecForTest(), timezonesForTest(), loggerForTest())
+	record := TimeTrackingCapture{DeviceId: "Device01", ClickType: SINGLE_CLICK}
+
+	res1, err1 := handler.Process(suite.requestForTest(record))
+	suite.Nil(err1)
+	suite.Equal(http.StatusAccepted, res1.StatusCode)
+	suite.Len(publisher.messages, 1)
+
+	publisher.shouldReturnError = true
+	res2, err2 := handler.Process(suite.requestForTest(record))
+	suite.NotNil(err2)
+	suite.Equal(http.StatusInternalServerError, res2.StatusCode)
 }
 
 func (suite *HandlerTestSuite) requestForTest(record TimeTrackingCapture) events.APIGatewayProxyRequest {
@@ -49,5 +168,6 @@ func (suite *HandlerTestSuite) requestForTest(record TimeTrackingCapture) events
 }
 
 func handlerForTest() *CaptureRequestHandler {
-	return newCaptureRequestHandler(timetracker.NewLocaLRepository(), loggerForTest())
+	clickTypeMapping, _ := newClickTypeMapping(configForTest())
+	return newCaptureRequestHandler(timetracker.NewLocaLRepository(), clickTypeMapping, newTimestampValidator(configForTest()), nil, false, recordIdCodecForTest(), timezonesForTest(), loggerForTest())
 }
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	config "github.com/tommzn/go-config"
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+// NewClickTypeMapping creates a click type mapping with default record types which can be
+// overwritten by config. Config entries without a device id will change the default mapping,
+// entries with a device id are applied for this device, only.
+func newClickTypeMapping(conf config.Config) (*ClickTypeMapping, error) {
+
+	mapping := &ClickTypeMapping{
+		defaults: map[IotClickType]timetracker.RecordType{
+			SINGLE_CLICK: timetracker.WORKDAY,
+			DOUBLE_CLICK: timetracker.ILLNESS,
+			LONG_PRESS:   timetracker.VACATION,
+		},
+		devices: make(map[string]map[IotClickType]timetracker.RecordType),
+	}
+	if conf == nil {
+		return mapping, nil
+	}
+
+	for _, entry := range conf.GetAsSliceOfMaps("hob.clicktypes") {
+
+		clickType := IotClickType(strings.ToUpper(entry["clicktype"]))
+		if clickType == "" {
+			return nil, fmt.Errorf("Missing click type in mapping: %+v", entry)
+		}
+		recordType, err := toRecordType(entry["recordtype"])
+		if err != nil {
+			return nil, err
+		}
+
+		deviceId, ok := entry["deviceid"]
+		if !ok || deviceId == "" {
+			mapping.defaults[clickType] = recordType
+			continue
+		}
+		if _, ok := mapping.devices[deviceId]; !ok {
+			mapping.devices[deviceId] = make(map[IotClickType]timetracker.RecordType)
+		}
+		mapping.devices[deviceId][clickType] = recordType
+	}
+	return mapping, nil
+}
+
+// RecordType returns the time tracking record type assigned to given click type. Device specific
+// mappings take precedence over default mappings. Returns with an error for unknown click types.
+func (mapping *ClickTypeMapping) recordType(deviceId string, clickType IotClickType) (timetracker.RecordType, error) {
+
+	if deviceMapping, ok := mapping.devices[deviceId]; ok {
+		if recordType, ok := deviceMapping[clickType]; ok {
+			return recordType, nil
+		}
+	}
+	if recordType, ok := mapping.defaults[clickType]; ok {
+		return recordType, nil
+	}
+	return "", fmt.Errorf("Unsupported click type: %s", clickType)
+}
+
+// RecordTypeForCapture returns the record type of a capture if it's explicitly set or
+// uses click type mapping to determine a record type.
+func (mapping *ClickTypeMapping) recordTypeForCapture(capture TimeTrackingCapture) (timetracker.RecordType, error) {
+	if capture.RecordType != "" {
+		return toRecordType(string(capture.RecordType))
+	}
+	return mapping.recordType(capture.DeviceId, capture.ClickType)
+}
+
+// ToRecordType converts passed value to a time tracking record type.
+// Returns with an error if there's no suitable record type.
+func toRecordType(value string) (timetracker.RecordType, error) {
+
+	switch recordType := timetracker.RecordType(strings.ToLower(value)); recordType {
+	case timetracker.WORKDAY, timetracker.ILLNESS, timetracker.VACATION:
+		return recordType, nil
+	default:
+		return "", fmt.Errorf("Invalid record type: %s", value)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	config "github.com/tommzn/go-config"
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+type ClickTypeMappingTestSuite struct {
+	suite.Suite
+}
+
+func TestClickTypeMappingTestSuite(t *testing.T) {
+	suite.Run(t, new(ClickTypeMappingTestSuite))
+}
+
+func (suite *ClickTypeMappingTestSuite) TestDefaultMapping() {
+
+	mapping, err := newClickTypeMapping(emptyConfigForTest())
+	suite.Nil(err)
+
+	suite.assertRecordType(mapping, "Device01", SINGLE_CLICK, timetracker.WORKDAY)
+	suite.assertRecordType(mapping, "Device01", DOUBLE_CLICK, timetracker.ILLNESS)
+	suite.assertRecordType(mapping, "Device01", LONG_PRESS, timetracker.VACATION)
+
+	_, err1 := mapping.recordType("Device01", IotClickType("TRIPLE"))
+	suite.NotNil(err1)
+}
+
+func (suite *ClickTypeMappingTestSuite) TestDeviceMapping() {
+
+	mapping, err := newClickTypeMapping(configForTest())
+	suite.Nil(err)
+
+	suite.assertRecordType(mapping, "Device01", DOUBLE_CLICK, timetracker.ILLNESS)
+	suite.assertRecordType(mapping, "Device02", DOUBLE_CLICK, timetracker.WORKDAY)
+	suite.assertRecordType(mapping, "Device02", LONG_PRESS, timetracker.VACATION)
+}
+
+func (suite *ClickTypeMappingTestSuite) TestInvalidMapping() {
+
+	conf, _ := config.NewStaticConfigSource("hob:\n  clicktypes:\n    - clicktype: SINGLE\n      recordtype: weekend\n").Load()
+	_, err := newClickTypeMapping(conf)
+	suite.NotNil(err)
+}
+
+func (suite *ClickTypeMappingTestSuite) assertRecordType(mapping *ClickTypeMapping, deviceId string, clickType IotClickType, expectedType timetracker.RecordType) {
+	recordType, err := mapping.recordType(deviceId, clickType)
+	suite.Nil(err)
+	suite.Equal(expectedType, recordType)
+}
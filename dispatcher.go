@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/aws/aws-lambda-go/events"
+	log "github.com/tommzn/go-log"
+)
+
+const (
+	sqsEventSource       = "aws:sqs"
+	scheduledEventSource = "aws.events"
+	scheduledEventType   = "Scheduled Event"
+)
+
+// NewEventDispatcher returns a dispatcher for API Gateway requests and, optional, SQS and scheduled events.
+// Context of each invocation is passed to all given context receivers before an event is dispatched.
+func newEventDispatcher(requestHandler Handler, queueConsumer QueueConsumer, scheduledJobs []ScheduledJob, contextReceivers []InvocationContextReceiver, logger log.Logger) *EventDispatcher {
+	return &EventDispatcher{
+		logger:           logger,
+		requestHandler:   requestHandler,
+		queueConsumer:    queueConsumer,
+		scheduledJobs:    scheduledJobs,
+		contextReceivers: contextReceivers,
+	}
+}
+
+// Dispatch inspects passed Lambda event and forwards it to a queue consumer, for SQS events,
+// to all scheduled jobs, for scheduled events, or to a request handler for all other events.
+func (dispatcher *EventDispatcher) Dispatch(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+
+	for _, receiver := range dispatcher.contextReceivers {
+		receiver.SetInvocationContext(ctx)
+	}
+
+	if isSqsEvent(payload) {
+		if dispatcher.queueConsumer == nil {
+			err := errors.New("No consumer for SQS events available.")
+			dispatcher.logger.Error(err)
+			dispatcher.logger.Flush()
+			return nil, err
+		}
+		var sqsEvent events.SQSEvent
+		if err := json.Unmarshal(payload, &sqsEvent); err != nil {
+			return nil, err
+		}
+		return dispatcher.queueConsumer.Consume(sqsEvent)
+	}
+
+	if isScheduledEvent(payload) {
+		var scheduledEvent events.CloudWatchEvent
+		if err := json.Unmarshal(payload, &scheduledEvent); err != nil {
+			return nil, err
+		}
+		return nil, dispatcher.runScheduledJobs(scheduledEvent)
+	}
+
+	var request events.APIGatewayProxyRequest
+	if err := json.Unmarshal(payload, &request); err != nil {
+		return nil, err
+	}
+	return dispatcher.requestHandler.Process(request)
+}
+
+// IsSqsEvent returns true if passed payload is an event from AWS SQS.
+func isSqsEvent(payload json.RawMessage) bool {
+	var event struct {
+		Records []struct {
+			EventSource string `json:"eventSource"`
+		} `json:"Records"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return false
+	}
+	return len(event.Records) > 0 && event.Records[0].EventSource == sqsEventSource
+}
+
+// IsScheduledEvent returns true if passed payload is a scheduled event from AWS EventBridge.
+func isScheduledEvent(payload json.RawMessage) bool {
+	var event struct {
+		Source     string `json:"source"`
+		DetailType string `json:"detail-type"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return false
+	}
+	return event.Source == scheduledEventSource && event.DetailType == scheduledEventType
+}
+
+// RunScheduledJobs executes all scheduled jobs for passed event. All jobs are executed, even if
+// a previous one fails. Returns with the first error.
+func (dispatcher *EventDispatcher) runScheduledJobs(event events.CloudWatchEvent) error {
+
+	defer dispatcher.logger.Flush()
+	dispatcher.logger.Debugf("Scheduled event received: %s", event.ID)
+
+	var firstErr error
+	for _, job := range dispatcher.scheduledJobs {
+		if err := job.Run(event); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/suite"
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+type DispatcherTestSuite struct {
+	suite.Suite
+}
+
+func TestDispatcherTestSuite(t *testing.T) {
+	suite.Run(t, new(DispatcherTestSuite))
+}
+
+func (suite *DispatcherTestSuite) TestDispatchEvents() {
+
+	dispatcher := newEventDispatcher(routerForTest(), newCaptureQueueConsumer(timetracker.NewLocaLRepository(), nil, loggerForTest()), []ScheduledJob{}, nil, loggerForTest())
+
+	request, _ := json.Marshal(events.APIGatewayProxyRequest{Resource: "/success", HTTPMethod: http.MethodPost})
+	res1, err1 := dispatcher.Dispatch(context.Background(), request)
+	suite.Nil(err1)
+	suite.Equal(http.StatusOK, res1.(events.APIGatewayProxyResponse).StatusCode)
+
+	sqsEvent, _ := json.Marshal(events.SQSEvent{Records: []events.SQSMessage{{MessageId: "Msg01", EventSource: sqsEventSource, Body: "xxx"}}})
+	res2, err2 := dispatcher.Dispatch(context.Background(), sqsEvent)
+	suite.Nil(err2)
+	suite.Len(res2.(events.SQSEventResponse).BatchItemFailures, 1)
+
+	recordHandler := timeTrackingRecordHandlerForTest()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dispatcher.contextReceivers = []InvocationContextReceiver{recordHandler}
+	dispatcher.Dispatch(ctx, request)
+	suite.Equal(ctx, recordHandler.ctx)
+
+	dispatcher.queueConsumer = nil
+	_, err3 := dispatcher.Dispatch(context.Background(), sqsEvent)
+	suite.NotNil(err3)
+}
+
+func (suite *DispatcherTestSuite) TestDispatchScheduledEvents() {
+
+	job1 := &scheduledJobMock{}
+	job2 := &scheduledJobMock{}
+	dispatcher := newEventDispatcher(routerForTest(), nil, []ScheduledJob{job1, job2}, nil, loggerForTest())
+
+	scheduledEvent := json.RawMessage(`{"id":"Event01","source":"aws.events","detail-type":"Scheduled Event","detail":{}}`)
+	res1, err1 := dispatcher.Dispatch(context.Background(), scheduledEvent)
+	suite.Nil(err1)
+	suite.Nil(res1)
+	suite.Equal(1, job1.callCount)
+	suite.Equal(1, job2.callCount)
+
+	job1.shouldReturnError = true
+	_, err2 := dispatcher.Dispatch(context.Background(), scheduledEvent)
+	suite.NotNil(err2)
+	suite.Equal(2, job2.callCount)
+
+	request, _ := json.Marshal(events.APIGatewayProxyRequest{Resource: "/success", HTTPMethod: http.MethodPost})
+	_, err3 := dispatcher.Dispatch(context.Background(), request)
+	suite.Nil(err3)
+	suite.Equal(2, job2.callCount)
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	config "github.com/tommzn/go-config"
+	secrets "github.com/tommzn/go-secrets"
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+// ErrRegionNotSupported is returned if a region is passed to a holiday provider without region support.
+var errRegionNotSupported = errors.New("Holiday provider doesn't support regions.")
+
+// NewHolidayCalendar creates a holiday calendar with a provider and a default country and region
+// from passed config. Supported providers are static, default, and calendarific.
+func newHolidayCalendar(conf config.Config, secretsManager secrets.SecretsManager) (*HolidayCalendar, error) {
+
+	provider, err := newHolidayProvider(conf, secretsManager)
+	if err != nil {
+		return nil, err
+	}
+	region := strings.ToUpper(*conf.Get("hob.holidays.region", config.AsStringPtr("")))
+	if region != "" && !provider.SupportsRegions() {
+		return nil, errRegionNotSupported
+	}
+	return &HolidayCalendar{
+		provider: provider,
+		country:  strings.ToUpper(*conf.Get("hob.holidays.country", config.AsStringPtr(""))),
+		region:   region,
+	}, nil
+}
+
+// NewHolidayProvider creates a holiday provider defined in passed config.
+func newHolidayProvider(conf config.Config, secretsManager secrets.SecretsManager) (HolidayProvider, error) {
+
+	switch provider := *conf.Get("hob.holidays.provider", config.AsStringPtr("static")); provider {
+	case "static":
+		file := conf.Get("hob.holidays.file", nil)
+		if file == nil {
+			return &StaticHolidayProvider{holidays: []Holiday{}}, nil
+		}
+		return newStaticHolidayProviderFromFile(*file)
+	case "calendarific":
+		apiKey, err := secretsManager.Obtain("CALENDARIFIC_API_KEY")
+		if err != nil {
+			return nil, err
+		}
+		return &CalendarificHolidayProvider{apiKey: *apiKey, cache: make(map[string][]Holiday)}, nil
+	default:
+		return nil, errors.New("Unsupported holiday provider: " + provider)
+	}
+}
+
+// NewStaticHolidayProviderFromFile reads a list of holidays, as JSON, from passed file.
+func newStaticHolidayProviderFromFile(file string) (*StaticHolidayProvider, error) {
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	holidays := []Holiday{}
+	if err := json.Unmarshal(content, &holidays); err != nil {
+		return nil, err
+	}
+	for idx, holiday := range holidays {
+		if _, err := time.Parse(dateLayout, holiday.Date); err != nil {
+			return nil, fmt.Errorf("Invalid holiday date in %s: %s", file, holiday.Date)
+		}
+		holidays[idx].Country = strings.ToUpper(holiday.Country)
+		holidays[idx].Region = strings.ToUpper(holiday.Region)
+	}
+	return &StaticHolidayProvider{holidays: holidays}, nil
+}
+
+// Holidays returns all holidays of passed year, country and region.
+func (provider *StaticHolidayProvider) Holidays(year int, country, region string) ([]Holiday, error) {
+
+	yearPrefix := fmt.Sprintf("%04d-", year)
+	holidays := []Holiday{}
+	for _, holiday := range provider.holidays {
+		if strings.HasPrefix(holiday.Date, yearPrefix) &&
+			strings.EqualFold(holiday.Country, country) &&
+			(holiday.Region == "" || strings.EqualFold(holiday.Region, region)) {
+			holidays = append(holidays, holiday)
+		}
+	}
+	sortHolidays(holidays)
+	return holidays, nil
+}
+
+// SupportsRegions returns true, because holidays of a static list can be defined for a region.
+func (provider *StaticHolidayProvider) SupportsRegions() bool {
+	return true
+}
+
+// Holidays fetches holidays of passed year and country from Calendarific. Returns with an error if a region
+// is passed, because regions are not supported. Holidays are cached for each year and country, the cache
+// is only locked to look up and to store holidays, not while they're fetched.
+func (provider *CalendarificHolidayProvider) Holidays(year int, country, region string) ([]Holiday, error) {
+
+	if region != "" {
+		return nil, errRegionNotSupported
+	}
+
+	cacheKey := fmt.Sprintf("%s/%04d", country, year)
+	provider.mutex.Lock()
+	holidays, ok := provider.cache[cacheKey]
+	provider.mutex.Unlock()
+	if ok {
+		return holidays, nil
+	}
+
+	holidays, err := provider.fetch(year, country)
+	if err != nil {
+		return holidays, err
+	}
+
+	provider.mutex.Lock()
+	provider.cache[cacheKey] = holidays
+	provider.mutex.Unlock()
+	return holidays, nil
+}
+
+// SupportsRegions returns false, Calendarific holidays are looked up by country only.
+func (provider *CalendarificHolidayProvider) SupportsRegions() bool {
+	return false
+}
+
+// Fetch requests holidays of each month of passed year and country from Calendarific.
+func (provider *CalendarificHolidayProvider) fetch(year int, country string) ([]Holiday, error) {
+
+	calendarApi := timetracker.NewCalendarApi(provider.apiKey, timetracker.Locale{Country: country})
+	holidays := []Holiday{}
+	for month := 1; month <= 12; month++ {
+		holidaysOfMonth, err := calendarApi.GetHolidays(year, month)
+		if err != nil {
+			return holidays, err
+		}
+		for _, holiday := range holidaysOfMonth {
+			holidays = append(holidays, Holiday{
+				Date:    fmt.Sprintf("%04d-%02d-%02d", holiday.Year, holiday.Month, holiday.Day),
+				Name:    holiday.Description,
+				Country: country,
+			})
+		}
+	}
+	sortHolidays(holidays)
+	return holidays, nil
+}
+
+// CountryAndRegion returns country and region passed as query parameters, or the defaults of this calendar.
+func (calendar *HolidayCalendar) countryAndRegion(queryParams map[string]string) (string, string) {
+	country, region := calendar.country, calendar.region
+	if value, ok := queryParams["country"]; ok {
+		country, region = strings.ToUpper(value), ""
+	}
+	if value, ok := queryParams["region"]; ok {
+		region = strings.ToUpper(value)
+	}
+	return country, region
+}
+
+// HolidaysBetween returns names of all holidays within passed range per date. There're no holidays
+// if neither a country has been passed as query parameter nor a default country is configured.
+func (calendar *HolidayCalendar) holidaysBetween(start, end time.Time, queryParams map[string]string) (map[string]string, error) {
+
+	holidays := make(map[string]string)
+	country, region := calendar.countryAndRegion(queryParams)
+	if country == "" || !end.After(start) {
+		return holidays, nil
+	}
+
+	firstDate, lastDate := start.Format(dateLayout), end.Add(-1*time.Nanosecond).Format(dateLayout)
+	// End of range is exclusive, so a range ending on the first day of a year doesn't include this year.
+	for year := start.Year(); year <= end.Add(-1*time.Nanosecond).Year(); year++ {
+		holidaysOfYear, err := calendar.provider.Holidays(year, country, region)
+		if err != nil {
+			return holidays, err
+		}
+		for _, holiday := range holidaysOfYear {
+			if holiday.Date >= firstDate && holiday.Date <= lastDate {
+				holidays[holiday.Date] = holiday.Name
+			}
+		}
+	}
+	return holidays, nil
+}
+
+// HolidayErrorStatus returns the HTTP status code for an error of a holiday lookup. Unsupported regions are
+// rejected as bad request, all other errors are caused by a holiday provider.
+func holidayErrorStatus(err error) int {
+	if errors.Is(err, errRegionNotSupported) {
+		return http.StatusBadRequest
+	}
+	return http.StatusBadGateway
+}
+
+// SortHolidays sorts passed holidays by date.
+func sortHolidays(holidays []Holiday) {
+	sort.SliceStable(holidays, func(i, j int) bool {
+		return holidays[i].Date < holidays[j].Date
+	})
+}
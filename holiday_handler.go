@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/events"
+	log "github.com/tommzn/go-log"
+)
+
+// NewHolidayRequestHandler returns a handler to list public holidays of passed calendar.
+func newHolidayRequestHandler(calendar *HolidayCalendar, logger log.Logger) *HolidayRequestHandler {
+	return &HolidayRequestHandler{
+		logger:   logger,
+		calendar: calendar,
+	}
+}
+
+// Process returns all public holidays for a year, a country and an optional region. Country and region
+// are optional if defaults are configured.
+func (handler *HolidayRequestHandler) Process(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	if request.HTTPMethod != http.MethodGet {
+		err := errors.New("Unsupported HTTP method.")
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusMethodNotAllowed), err
+	}
+
+	year, err := strconv.Atoi(request.QueryStringParameters["year"])
+	if err != nil || year < 1 || year > 9999 {
+		err := errors.New("Missing or invalid year.")
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusBadRequest), err
+	}
+
+	country, region := handler.calendar.countryAndRegion(request.QueryStringParameters)
+	if country == "" {
+		err := errors.New("Missing country.")
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusBadRequest), err
+	}
+	handler.logger.Debugf("Receive holiday request for %d, country: %s, region: %s", year, country, region)
+
+	holidays, err := handler.calendar.provider.Holidays(year, country, region)
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, holidayErrorStatus(err)), err
+	}
+
+	responseContent, err := json.Marshal(holidays)
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusInternalServerError), err
+	}
+	return responseWithContent(string(responseContent), http.StatusOK), nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/suite"
+	config "github.com/tommzn/go-config"
+	secrets "github.com/tommzn/go-secrets"
+)
+
+type HolidayTestSuite struct {
+	suite.Suite
+}
+
+func TestHolidayTestSuite(t *testing.T) {
+	suite.Run(t, new(HolidayTestSuite))
+}
+
+func (suite *HolidayTestSuite) TestStaticHolidayProvider() {
+
+	provider, err := newStaticHolidayProviderFromFile("fixtures/holidays.json")
+	suite.Nil(err)
+
+	holidays1, err1 := provider.Holidays(2022, "DE", "")
+	suite.Nil(err1)
+	suite.Len(holidays1, 2)
+	suite.Equal("2022-01-01", holidays1[0].Date)
+	suite.Equal("Good Friday", holidays1[1].Name)
+
+	holidays2, err2 := provider.Holidays(2022, "de", "by")
+	suite.Nil(err2)
+	suite.Len(holidays2, 3)
+	suite.Equal("Epiphany", holidays2[1].Name)
+	suite.Equal("BY", holidays2[1].Region)
+
+	holidays3, err3 := provider.Holidays(2022, "FR", "")
+	suite.Nil(err3)
+	suite.Len(holidays3, 0)
+
+	_, err4 := newStaticHolidayProviderFromFile("fixtures/xxx.json")
+	suite.NotNil(err4)
+
+	_, err5 := newStaticHolidayProviderFromFile("fixtures/testconfig.yml")
+	suite.NotNil(err5)
+}
+
+func (suite *HolidayTestSuite) TestHolidayProviderFromConfig() {
+
+	conf1, _ := config.NewStaticConfigSource("hob:\n  holidays:\n    country: DE\n").Load()
+	calendar1, err1 := newHolidayCalendar(conf1, nil)
+	suite.Nil(err1)
+	holidays1, err1 := calendar1.provider.Holidays(2022, "DE", "")
+	suite.Nil(err1)
+	suite.Len(holidays1, 0)
+
+	conf2, _ := config.NewStaticConfigSource("hob:\n  holidays:\n    provider: xxx\n").Load()
+	_, err2 := newHolidayCalendar(conf2, nil)
+	suite.NotNil(err2)
+
+	secretsManager := secrets.NewStaticSecretsManager(map[string]string{"CALENDARIFIC_API_KEY": "unittest"})
+	conf3, _ := config.NewStaticConfigSource("hob:\n  holidays:\n    provider: calendarific\n    country: DE\n").Load()
+	calendar3, err3 := newHolidayCalendar(conf3, secretsManager)
+	suite.Nil(err3)
+	suite.False(calendar3.provider.SupportsRegions())
+
+	conf4, _ := config.NewStaticConfigSource("hob:\n  holidays:\n    provider: calendarific\n    country: DE\n    region: BY\n").Load()
+	_, err4 := newHolidayCalendar(conf4, secretsManager)
+	suite.Equal(errRegionNotSupported, err4)
+}
+
+func (suite *HolidayTestSuite) TestCalendarificHolidayCache() {
+
+	provider := &CalendarificHolidayProvider{apiKey: "unittest", cache: map[string][]Holiday{
+		"DE/2022": {{Date: "2022-01-01", Name: "New Year's Day", Country: "DE"}},
+	}}
+
+	holidays1, err1 := provider.Holidays(2022, "DE", "")
+	suite.Nil(err1)
+	suite.Len(holidays1, 1)
+
+	_, err2 := provider.Holidays(2022, "DE", "BY")
+	suite.Equal(errRegionNotSupported, err2)
+}
+
+func (suite *HolidayTestSuite) TestHolidaysBetween() {
+
+	calendar := holidayCalendarForTest()
+	start := time.Date(2021, 12, 26, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2022, 1, 7, 0, 0, 0, 0, time.UTC)
+
+	holidays1, err1 := calendar.holidaysBetween(start, end, map[string]string{})
+	suite.Nil(err1)
+	suite.Equal(map[string]string{"2021-12-26": "Boxing Day", "2022-01-01": "New Year's Day"}, holidays1)
+
+	holidays2, err2 := calendar.holidaysBetween(start, end, map[string]string{"region": "BY"})
+	suite.Nil(err2)
+	suite.Len(holidays2, 3)
+
+	holidays3, err3 := calendar.holidaysBetween(start, time.Date(2022, 1, 6, 0, 0, 0, 0, time.UTC), map[string]string{"country": "AT"})
+	suite.Nil(err3)
+	suite.Equal(map[string]string{"2022-01-01": "New Year's Day"}, holidays3)
+
+	provider := &holidayProviderMock{HolidayProvider: calendar.provider}
+	calendar.provider = provider
+	holidays5, err5 := calendar.holidaysBetween(time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC), time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), map[string]string{})
+	suite.Nil(err5)
+	suite.Len(holidays5, 2)
+	suite.Equal([]int{2021}, provider.years)
+
+	calendar.country = ""
+	holidays4, err4 := calendar.holidaysBetween(start, end, map[string]string{})
+	suite.Nil(err4)
+	suite.Len(holidays4, 0)
+}
+
+func (suite *HolidayTestSuite) TestProcessHolidayRequest() {
+
+	handler := newHolidayRequestHandler(holidayCalendarForTest(), loggerForTest())
+
+	response1, err1 := handler.Process(suite.requestForTest(map[string]string{"year": "2022", "region": "by"}))
+	suite.Nil(err1)
+	suite.Equal(http.StatusOK, response1.StatusCode)
+	var holidays []Holiday
+	suite.Nil(json.Unmarshal([]byte(response1.Body), &holidays))
+	suite.Len(holidays, 3)
+
+	response2, err2 := handler.Process(suite.requestForTest(map[string]string{"year": "2022", "country": "AT"}))
+	suite.Nil(err2)
+	suite.Nil(json.Unmarshal([]byte(response2.Body), &holidays))
+	suite.Len(holidays, 2)
+
+	response3, err3 := handler.Process(suite.requestForTest(map[string]string{"year": "xxx"}))
+	suite.NotNil(err3)
+	suite.Equal(http.StatusBadRequest, response3.StatusCode)
+
+	handler.calendar.country = ""
+	response4, err4 := handler.Process(suite.requestForTest(map[string]string{"year": "2022"}))
+	suite.NotNil(err4)
+	suite.Equal(http.StatusBadRequest, response4.StatusCode)
+
+	handler.calendar = &HolidayCalendar{provider: &CalendarificHolidayProvider{cache: map[string][]Holiday{}}, country: "DE"}
+	response5, err5 := handler.Process(suite.requestForTest(map[string]string{"year": "2022", "region": "BY"}))
+	suite.NotNil(err5)
+	suite.Equal(http.StatusBadRequest, response5.StatusCode)
+}
+
+func (suite *HolidayTestSuite) requestForTest(queryParams map[string]string) events.APIGatewayProxyRequest {
+	request := timeTrackingRecordHandlerRequestForTest(http.MethodGet)
+	request.Resource = "/calendar/holidays"
+	request.QueryStringParameters = queryParams
+	return request
+}
+
+func holidayCalendarForTest() *HolidayCalendar {
+	calendar, _ := newHolidayCalendar(configForTest(), nil)
+	return calendar
+}
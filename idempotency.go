@@ -0,0 +1,111 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	log "github.com/tommzn/go-log"
+)
+
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotencyReservationTtl defines how long an idempotency key is reserved for a request in progress.
+// It exceeds the API Gateway timeout, so keys of crashed requests can be reused afterwards.
+const idempotencyReservationTtl = time.Minute
+
+// NewIdempotentHandler wraps passed handler to replay stored responses for requests with an idempotency key.
+func newIdempotentHandler(handler Handler, store IdempotencyStore, ttl time.Duration, logger log.Logger) *IdempotentHandler {
+	return &IdempotentHandler{
+		logger:  logger,
+		handler: handler,
+		store:   store,
+		ttl:     ttl,
+	}
+}
+
+// Process forwards POST requests with an idempotency key to the wrapped handler and stores its response.
+// Idempotency keys are reserved before a request is processed, so concurrent requests with same key are
+// processed only once. If there's already a response for an idempotency key it will be replayed, if request
+// bodies match. Reusing an idempotency key with a different request body, or while a request is still in
+// progress, results in status 409. Server errors are not stored, so requests can be retried.
+func (handler *IdempotentHandler) Process(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	idempotencyKey, ok := headerValue(request, idempotencyKeyHeader)
+	if !ok || idempotencyKey == "" || request.HTTPMethod != http.MethodPost {
+		return handler.handler.Process(request)
+	}
+
+	storeKey := string(resourceFromRequest(request)) + ":" + idempotencyKey
+	requestHash := hashRequestBody(request.Body)
+	reservation := IdempotencyRecord{RequestHash: requestHash, InProgress: true, Expires: time.Now().Add(idempotencyReservationTtl)}
+	reserved, err := handler.store.Reserve(storeKey, reservation)
+	if err != nil {
+		handler.logger.Error("Unable to reserve idempotency key, reason: ", err)
+		return errorResponse(err), err
+	}
+
+	if !reserved {
+		return handler.replay(storeKey, idempotencyKey, requestHash)
+	}
+
+	response, err := handler.handler.Process(request)
+	if response.StatusCode >= http.StatusInternalServerError {
+		if removeErr := handler.store.Remove(storeKey); removeErr != nil {
+			handler.logger.Error("Unable to release idempotency key, reason: ", removeErr)
+		}
+		return response, err
+	}
+
+	record := IdempotencyRecord{
+		RequestHash: requestHash,
+		StatusCode:  response.StatusCode,
+		Headers:     response.Headers,
+		Body:        response.Body,
+		Expires:     time.Now().Add(handler.ttl),
+	}
+	if storeErr := handler.store.Put(storeKey, record); storeErr != nil {
+		handler.logger.Error("Unable to store response, reason: ", storeErr)
+	}
+	return response, err
+}
+
+// Replay returns a stored response for an idempotency key which is already in use. Server time of a replayed
+// response is set to current time, so clients can still detect clock skew. Returns with status 409
+// if a request body doesn't match or if a request with same idempotency key is still in progress.
+func (handler *IdempotentHandler) replay(storeKey, idempotencyKey, requestHash string) (events.APIGatewayProxyResponse, error) {
+
+	storedRecord, err := handler.store.Get(storeKey)
+	if err != nil {
+		handler.logger.Error("Unable to get stored response, reason: ", err)
+		return errorResponse(err), err
+	}
+
+	if storedRecord != nil && storedRecord.RequestHash != requestHash {
+		err := errors.New("Idempotency key has already been used for a different request.")
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusConflict), err
+	}
+	if storedRecord == nil || storedRecord.InProgress {
+		err := errors.New("A request with same idempotency key is in progress.")
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusConflict), err
+	}
+
+	handler.logger.Infof("Replay response for idempotency key: %s", idempotencyKey)
+	response := responseWithContent(storedRecord.Body, storedRecord.StatusCode)
+	response.Headers = make(map[string]string)
+	for name, value := range storedRecord.Headers {
+		response.Headers[name] = value
+	}
+	return withServerTime(response, time.Now()), nil
+}
+
+// HashRequestBody returns a hex encoded SHA256 hash of passed request body.
+func hashRequestBody(body string) string {
+	hash := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(hash[:])
+}
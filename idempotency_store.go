@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	config "github.com/tommzn/go-config"
+)
+
+// NewIdempotencyStore creates a store for idempotency keys depending on config.
+// Supported stores are "s3", which is default and shared by all containers, and "memory".
+func newIdempotencyStore(conf config.Config) (IdempotencyStore, error) {
+
+	storeType := conf.Get("hob.idempotency.store", config.AsStringPtr("s3"))
+	switch strings.ToLower(*storeType) {
+	case "memory":
+		maxEntries := conf.GetAsInt("hob.idempotency.maxentries", config.AsIntPtr(10000))
+		return newInMemoryIdempotencyStore(*maxEntries), nil
+	case "s3":
+		awsConf, err := getAwsConfig(conf)
+		if err != nil {
+			return nil, err
+		}
+		basePath := conf.Get("hob.idempotency.basepath", config.AsStringPtr("idempotency"))
+		return newS3IdempotencyStore(newS3Client(awsConf.region), *awsConf.bucket, *basePath), nil
+	default:
+		return nil, errors.New("Unsupported idempotency store: " + *storeType)
+	}
+}
+
+// NewInMemoryIdempotencyStore returns a new, empty in memory store for at most max entries idempotency keys.
+func newInMemoryIdempotencyStore(maxEntries int) *InMemoryIdempotencyStore {
+	if maxEntries < 1 {
+		maxEntries = 1
+	}
+	return &InMemoryIdempotencyStore{records: make(map[string]IdempotencyRecord), maxEntries: maxEntries}
+}
+
+// Get returns a stored response for passed key. Expired responses will be removed.
+func (store *InMemoryIdempotencyStore) Get(key string) (*IdempotencyRecord, error) {
+
+	store.Lock()
+	defer store.Unlock()
+
+	record, ok := store.records[key]
+	if !ok {
+		return nil, nil
+	}
+	if record.Expires.Before(time.Now()) {
+		delete(store.records, key)
+		return nil, nil
+	}
+	return &record, nil
+}
+
+// Put stores passed response for given key.
+func (store *InMemoryIdempotencyStore) Put(key string, record IdempotencyRecord) error {
+
+	store.Lock()
+	defer store.Unlock()
+
+	store.store(key, record)
+	return nil
+}
+
+// Reserve stores passed record if there's no, or only an expired, record for given key.
+func (store *InMemoryIdempotencyStore) Reserve(key string, record IdempotencyRecord) (bool, error) {
+
+	store.Lock()
+	defer store.Unlock()
+
+	if storedRecord, ok := store.records[key]; ok && !storedRecord.Expires.Before(time.Now()) {
+		return false, nil
+	}
+	store.store(key, record)
+	return true, nil
+}
+
+// Remove deletes a stored record for passed key.
+func (store *InMemoryIdempotencyStore) Remove(key string) error {
+
+	store.Lock()
+	defer store.Unlock()
+
+	delete(store.records, key)
+	return nil
+}
+
+// Store adds passed record. If max entries are reached, all expired records are removed. If there're
+// still too many records, the record which expires first is removed. Caller has to hold the lock.
+func (store *InMemoryIdempotencyStore) store(key string, record IdempotencyRecord) {
+
+	if _, ok := store.records[key]; !ok && len(store.records) >= store.maxEntries {
+		now := time.Now()
+		for storedKey, storedRecord := range store.records {
+			if storedRecord.Expires.Before(now) {
+				delete(store.records, storedKey)
+			}
+		}
+		for len(store.records) >= store.maxEntries {
+			store.removeFirstExpiring()
+		}
+	}
+	store.records[key] = record
+}
+
+// RemoveFirstExpiring removes the record which expires first. Caller has to hold the lock.
+func (store *InMemoryIdempotencyStore) removeFirstExpiring() {
+
+	var firstKey string
+	var firstExpires time.Time
+	for key, record := range store.records {
+		if firstKey == "" || record.Expires.Before(firstExpires) {
+			firstKey, firstExpires = key, record.Expires
+		}
+	}
+	delete(store.records, firstKey)
+}
+
+// NewS3IdempotencyStore returns a store which persists responses for idempotency keys in given bucket.
+func newS3IdempotencyStore(s3Client s3iface.S3API, bucket, basePath string) *S3IdempotencyStore {
+	return &S3IdempotencyStore{
+		s3Client: s3Client,
+		bucket:   bucket,
+		basePath: basePath,
+	}
+}
+
+// Get downloads a stored response for passed key. Returns nil if there's no or an expired response.
+func (store *S3IdempotencyStore) Get(key string) (*IdempotencyRecord, error) {
+
+	record, _, err := store.download(key)
+	if err != nil || record == nil {
+		return nil, err
+	}
+	if record.Expires.Before(time.Now()) {
+		return nil, nil
+	}
+	return record, nil
+}
+
+// Put uploads passed response for given key.
+func (store *S3IdempotencyStore) Put(key string, record IdempotencyRecord) error {
+	_, err := store.upload(key, record, "", "")
+	return err
+}
+
+// Reserve uploads passed record with a conditional write, so only one request can reserve a key.
+// An expired record is replaced, if it hasn't been changed since it has been downloaded.
+func (store *S3IdempotencyStore) Reserve(key string, record IdempotencyRecord) (bool, error) {
+
+	reserved, err := store.upload(key, record, "If-None-Match", "*")
+	if err != nil || reserved {
+		return reserved, err
+	}
+
+	storedRecord, etag, err := store.download(key)
+	if err != nil {
+		return false, err
+	}
+	if storedRecord == nil {
+		return store.upload(key, record, "If-None-Match", "*")
+	}
+	if !storedRecord.Expires.Before(time.Now()) || etag == "" {
+		return false, nil
+	}
+	return store.upload(key, record, "If-Match", etag)
+}
+
+// Remove deletes a stored record for passed key.
+func (store *S3IdempotencyStore) Remove(key string) error {
+	_, err := store.s3Client.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(store.bucket),
+		Key:    store.objectKey(key),
+	})
+	return err
+}
+
+// Download fetches a stored record and its ETag for passed key. Returns nil if there's no record.
+func (store *S3IdempotencyStore) download(key string) (*IdempotencyRecord, string, error) {
+
+	output, err := store.s3Client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(store.bucket),
+		Key:    store.objectKey(key),
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == s3.ErrCodeNoSuchKey {
+			return nil, "", nil
+		}
+		return nil, "", err
+	}
+	defer output.Body.Close()
+
+	content, err := io.ReadAll(output.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var record IdempotencyRecord
+	if err := json.Unmarshal(content, &record); err != nil {
+		return nil, "", err
+	}
+	return &record, aws.StringValue(output.ETag), nil
+}
+
+// Upload writes passed record for given key. If a condition header is passed, the upload is a conditional
+// write and false is returned if this condition isn't met.
+func (store *S3IdempotencyStore) upload(key string, record IdempotencyRecord, conditionHeader, conditionValue string) (bool, error) {
+
+	content, err := json.Marshal(record)
+	if err != nil {
+		return false, err
+	}
+	request, _ := store.s3Client.PutObjectRequest(&s3.PutObjectInput{
+		Bucket:  aws.String(store.bucket),
+		Key:     store.objectKey(key),
+		Body:    bytes.NewReader(content),
+		Expires: aws.Time(record.Expires),
+	})
+	if conditionHeader != "" {
+		request.HTTPRequest.Header.Set(conditionHeader, conditionValue)
+	}
+	if err := request.Send(); err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && isConditionFailed(awsErr.Code()) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// IsConditionFailed returns true if passed S3 error code is returned for an unmet condition of a conditional write.
+func isConditionFailed(code string) bool {
+	return code == "PreconditionFailed" || code == "ConditionalRequestConflict"
+}
+
+// ObjectKey returns a S3 object key for passed idempotency key. Idempotency keys are hashed to
+// avoid invalid object keys.
+func (store *S3IdempotencyStore) objectKey(key string) *string {
+	objectKey := hashRequestBody(key) + ".json"
+	if store.basePath != "" {
+		objectKey = strings.TrimSuffix(store.basePath, "/") + "/" + objectKey
+	}
+	return aws.String(objectKey)
+}
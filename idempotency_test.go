@@ -0,0 +1,191 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/suite"
+	config "github.com/tommzn/go-config"
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+type IdempotencyTestSuite struct {
+	suite.Suite
+}
+
+func TestIdempotencyTestSuite(t *testing.T) {
+	suite.Run(t, new(IdempotencyTestSuite))
+}
+
+func (suite *IdempotencyTestSuite) TestReplayResponses() {
+
+	repo := timetracker.NewLocaLRepository()
+	handler := idempotentHandlerForTest(repo, newInMemoryIdempotencyStore(100), time.Hour)
+
+	request1 := idempotentRequestForTest("Key01", "{\"deviceid\":\"Device01\",\"clicktype\":\"SINGLE\",\"timestamp\":\"2022-01-03T08:00:00Z\"}")
+	res1, err1 := handler.Process(request1)
+	suite.Nil(err1)
+	suite.Equal(http.StatusOK, res1.StatusCode)
+
+	res2, err2 := handler.Process(request1)
+	suite.Nil(err2)
+	suite.Equal(res1.StatusCode, res2.StatusCode)
+	suite.Equal(res1.Body, res2.Body)
+	suite.Len(suite.recordsForTest(repo), 1)
+
+	request3 := idempotentRequestForTest("Key01", "{\"deviceid\":\"Device01\",\"clicktype\":\"SINGLE\",\"timestamp\":\"2022-01-03T09:00:00Z\"}")
+	res3, err3 := handler.Process(request3)
+	suite.NotNil(err3)
+	suite.Equal(http.StatusConflict, res3.StatusCode)
+
+	request4 := idempotentRequestForTest("", request3.Body)
+	res4, err4 := handler.Process(request4)
+	suite.Nil(err4)
+	suite.Equal(http.StatusOK, res4.StatusCode)
+	suite.Len(suite.recordsForTest(repo), 2)
+}
+
+func (suite *IdempotencyTestSuite) TestReplayServerTime() {
+
+	store := newInMemoryIdempotencyStore(100)
+	handler := idempotentHandlerForTest(timetracker.NewLocaLRepository(), store, time.Hour)
+	request := idempotentRequestForTest("Key01", "{\"deviceid\":\"Device01\",\"clicktype\":\"SINGLE\",\"timestamp\":\"2022-01-03T08:00:00Z\"}")
+
+	_, err1 := handler.Process(request)
+	suite.Nil(err1)
+	storedRecord, _ := store.Get("/capture:Key01")
+	storedRecord.Headers[serverTimeHeader] = "2022-01-03T08:00:00Z"
+	suite.Nil(store.Put("/capture:Key01", *storedRecord))
+
+	res2, err2 := handler.Process(request)
+	suite.Nil(err2)
+	serverTime, err := time.Parse(time.RFC3339, res2.Headers[serverTimeHeader])
+	suite.Nil(err)
+	suite.WithinDuration(time.Now(), serverTime, time.Minute)
+	storedRecord, _ = store.Get("/capture:Key01")
+	suite.Equal("2022-01-03T08:00:00Z", storedRecord.Headers[serverTimeHeader])
+}
+
+func (suite *IdempotencyTestSuite) TestExpiredResponses() {
+
+	repo := timetracker.NewLocaLRepository()
+	handler := idempotentHandlerForTest(repo, newInMemoryIdempotencyStore(100), -1*time.Second)
+
+	request1 := idempotentRequestForTest("Key01", "{\"deviceid\":\"Device01\",\"clicktype\":\"SINGLE\",\"timestamp\":\"2022-01-03T08:00:00Z\"}")
+	_, err1 := handler.Process(request1)
+	suite.Nil(err1)
+	_, err2 := handler.Process(request1)
+	suite.Nil(err2)
+	suite.Len(suite.recordsForTest(repo), 2)
+}
+
+func (suite *IdempotencyTestSuite) TestRequestInProgress() {
+
+	store := newInMemoryIdempotencyStore(100)
+	handler := idempotentHandlerForTest(timetracker.NewLocaLRepository(), store, time.Hour)
+	request := idempotentRequestForTest("Key01", "{\"deviceid\":\"Device01\",\"clicktype\":\"SINGLE\",\"timestamp\":\"2022-01-03T08:00:00Z\"}")
+
+	reserved, err := store.Reserve("/capture:Key01", IdempotencyRecord{RequestHash: hashRequestBody(request.Body), InProgress: true, Expires: time.Now().Add(time.Minute)})
+	suite.Nil(err)
+	suite.True(reserved)
+
+	res1, err1 := handler.Process(request)
+	suite.NotNil(err1)
+	suite.Equal(http.StatusConflict, res1.StatusCode)
+
+	suite.Nil(store.Remove("/capture:Key01"))
+	res2, err2 := handler.Process(request)
+	suite.Nil(err2)
+	suite.Equal(http.StatusOK, res2.StatusCode)
+}
+
+func (suite *IdempotencyTestSuite) TestReleaseKeyOnServerError() {
+
+	store := newInMemoryIdempotencyStore(100)
+	handler := idempotentHandlerForTest(&timeTrackerErrorMock{}, store, time.Hour)
+	request := idempotentRequestForTest("Key01", "{\"deviceid\":\"Device01\",\"clicktype\":\"SINGLE\",\"timestamp\":\"2022-01-03T08:00:00Z\"}")
+
+	res1, err1 := handler.Process(request)
+	suite.NotNil(err1)
+	suite.Equal(http.StatusInternalServerError, res1.StatusCode)
+
+	storedRecord, err := store.Get("/capture:Key01")
+	suite.Nil(err)
+	suite.Nil(storedRecord)
+}
+
+func (suite *IdempotencyTestSuite) TestInMemoryStoreSize() {
+
+	store := newInMemoryIdempotencyStore(2)
+	suite.Nil(store.Put("Key01", IdempotencyRecord{Expires: time.Now().Add(-1 * time.Minute)}))
+	suite.Nil(store.Put("Key02", IdempotencyRecord{Expires: time.Now().Add(2 * time.Hour)}))
+	suite.Nil(store.Put("Key03", IdempotencyRecord{Expires: time.Now().Add(time.Hour)}))
+	suite.Len(store.records, 2)
+
+	suite.Nil(store.Put("Key04", IdempotencyRecord{Expires: time.Now().Add(time.Hour)}))
+	suite.Len(store.records, 2)
+	_, ok := store.records["Key03"]
+	suite.False(ok)
+	_, ok = store.records["Key02"]
+	suite.True(ok)
+}
+
+func (suite *IdempotencyTestSuite) TestNewIdempotencyStore() {
+
+	store1, err1 := newIdempotencyStore(configForTest())
+	suite.Nil(err1)
+	suite.IsType(&S3IdempotencyStore{}, store1)
+
+	conf, _ := config.NewStaticConfigSource("hob:\n  idempotency:\n    store: memory\n    maxentries: 10\n").Load()
+	store2, err2 := newIdempotencyStore(conf)
+	suite.Nil(err2)
+	suite.Equal(10, store2.(*InMemoryIdempotencyStore).maxEntries)
+
+	_, err3 := newIdempotencyStore(emptyConfigForTest())
+	suite.NotNil(err3)
+}
+
+func (suite *IdempotencyTestSuite) TestS3IdempotencyStore() {
+
+	skipCI(suite.T())
+
+	awsConf, err := getAwsConfig(configForTest())
+	suite.Nil(err)
+	store := newS3IdempotencyStore(newS3Client(awsConf.region), *awsConf.bucket, "idempotency-test")
+	record := IdempotencyRecord{RequestHash: hashRequestBody("xxx"), StatusCode: http.StatusOK, Expires: time.Now().Add(time.Minute)}
+	suite.Nil(store.Put("Key01", record))
+
+	storedRecord, err := store.Get("Key01")
+	suite.Nil(err)
+	suite.NotNil(storedRecord)
+	suite.Equal(record.RequestHash, storedRecord.RequestHash)
+
+	reserved, err := store.Reserve("Key01", record)
+	suite.Nil(err)
+	suite.False(reserved)
+	suite.Nil(store.Remove("Key01"))
+	reserved, err = store.Reserve("Key01", record)
+	suite.Nil(err)
+	suite.True(reserved)
+}
+
+func (suite *IdempotencyTestSuite) recordsForTest(repo timetracker.TimeTracker) []timetracker.TimeTrackingRecord {
+	records, err := repo.ListRecords("Device01", time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC), time.Date(2022, 1, 3, 23, 59, 59, 0, time.UTC))
+	suite.Nil(err)
+	return records
+}
+
+func idempotentRequestForTest(idempotencyKey, body string) events.APIGatewayProxyRequest {
+	request := events.APIGatewayProxyRequest{Resource: "/capture", HTTPMethod: http.MethodPost, Body: body}
+	if idempotencyKey != "" {
+		request.Headers = map[string]string{"idempotency-key": idempotencyKey}
+	}
+	return request
+}
+
+func idempotentHandlerForTest(repo timetracker.TimeTracker, store IdempotencyStore, ttl time.Duration) *IdempotentHandler {
+	clickTypeMapping, _ := newClickTypeMapping(configForTest())
+	return newIdempotentHandler(newCaptureRequestHandler(repo, clickTypeMapping, newTimestampValidator(configForTest()), nil, false, recordIdCodecForTest(), timezonesForTest(), loggerForTest()), store, ttl, loggerForTest())
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	config "github.com/tommzn/go-config"
+	log "github.com/tommzn/go-log"
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+// Formats of timestamps without timezone, they're parsed in location of an import.
+var importTimestampFormats = []string{"2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02 15:04"}
+
+// NewTimeTrackingImportHandler returns a handler to import records from CSV. Records are validated and
+// persisted by passed record handler. Max number of rows is read from passed config, default is 200 to
+// import all rows within the API Gateway timeout. Imported timestamps can be up to 10 years in the past
+// by default, other plausibility windows are same as for new records.
+func newTimeTrackingImportHandler(recordHandler *TimeTrackingRecordHandler, conf config.Config, logger log.Logger) *TimeTrackingImportHandler {
+
+	timestampValidator := newTimestampValidator(conf)
+	timestampValidator.maxPast = *conf.GetAsDuration("hob.records.import.maxpast", config.AsDurationPtr(10*365*24*time.Hour))
+	return &TimeTrackingImportHandler{
+		logger:             logger,
+		recordHandler:      recordHandler,
+		maxRows:            *conf.GetAsInt("hob.records.import.maxrows", config.AsIntPtr(200)),
+		timestampValidator: timestampValidator,
+	}
+}
+
+// Process imports time tracking records from CSV passed as request body. Each row is validated, and checked for
+// conflicts with existing and previously imported records, with same rules as new records. Rows for already existing
+// records are skipped, conflicting rows fail. Managers can skip conflict checks with force=true. With dryRun=true
+// records are validated, only. Response contains a result for each row.
+func (handler *TimeTrackingImportHandler) Process(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	if request.HTTPMethod != http.MethodPost {
+		err := errors.New("Unsupported HTTP method.")
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusMethodNotAllowed), err
+	}
+
+	location, err := handler.recordHandler.locationFor(request.QueryStringParameters)
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusBadRequest), err
+	}
+
+	rows, err := readCsv(request.Body, request.QueryStringParameters["delimiter"])
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusBadRequest), err
+	}
+
+	firstRow := 0
+	var header []string
+	if strings.ToLower(request.QueryStringParameters["header"]) != "false" && len(rows) > 0 {
+		header = rows[0]
+		firstRow = 1
+	}
+	columns, err := importColumns(header, request.QueryStringParameters)
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusBadRequest), err
+	}
+
+	if len(rows)-firstRow > handler.maxRows {
+		err := fmt.Errorf("Max number of %d rows exceeded.", handler.maxRows)
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusRequestEntityTooLarge), err
+	}
+
+	forced := isForced(request)
+	if forced && !handler.recordHandler.conflictRules.isManager(request) {
+		err := errors.New("Only managers are allowed to force adding conflicting records.")
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusForbidden), err
+	}
+
+	dryRun := strings.ToLower(request.QueryStringParameters["dryRun"]) == "true"
+	report := ImportReport{DryRun: dryRun, Rows: []ImportRowResult{}}
+	records := make(map[int]timetracker.TimeTrackingRecord)
+	for idx := firstRow; idx < len(rows); idx++ {
+		record, err := handler.toRecord(rows[idx], columns, location)
+		if err != nil {
+			report.Rows = append(report.Rows, ImportRowResult{Row: idx + 1, Status: IMPORT_FAILED, Error: err.Error()})
+			continue
+		}
+		records[idx] = record
+		report.Rows = append(report.Rows, ImportRowResult{Row: idx + 1})
+	}
+
+	existingRecords, err := handler.existingRecords(records)
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusInternalServerError), err
+	}
+
+	importedRecords := make(map[string]bool)
+	for idx, result := range report.Rows {
+
+		record, ok := records[result.Row-1]
+		if !ok {
+			continue
+		}
+
+		recordId := fmt.Sprintf("%s|%s|%d", record.DeviceId, record.Type, record.Timestamp.Unix())
+		if importedRecords[recordId] || findRecord(existingRecords[record.DeviceId], record.Type, record.Timestamp, nil) != nil {
+			report.Rows[idx].Status = IMPORT_SKIPPED
+			report.Rows[idx].Error = "Duplicate time tracking record."
+			continue
+		}
+
+		if !forced {
+			if conflicts := handler.checkConflicts(record, existingRecords[record.DeviceId]); len(conflicts) > 0 {
+				report.Rows[idx].Status = IMPORT_FAILED
+				report.Rows[idx].Error = conflictMessages(conflicts)
+				continue
+			}
+		}
+		importedRecords[recordId] = true
+
+		if dryRun {
+			report.Rows[idx].Status = IMPORT_CREATED
+			existingRecords[record.DeviceId] = append(existingRecords[record.DeviceId], record)
+			continue
+		}
+		newRecord, err := handler.recordHandler.timeTrackingManager.Add(record)
+		if err != nil {
+			handler.logger.Error("Unable to import time tracking record, reason: ", err)
+			report.Rows[idx].Status = IMPORT_FAILED
+			report.Rows[idx].Error = err.Error()
+			continue
+		}
+		report.Rows[idx].Status = IMPORT_CREATED
+		report.Rows[idx].Key = handler.recordHandler.recordIds.encode(newRecord.Key)
+		existingRecords[record.DeviceId] = append(existingRecords[record.DeviceId], newRecord)
+	}
+
+	for _, result := range report.Rows {
+		switch result.Status {
+		case IMPORT_CREATED:
+			report.Created++
+		case IMPORT_SKIPPED:
+			report.Skipped++
+		default:
+			report.Failed++
+		}
+	}
+	handler.logger.Infof("Import finished, created: %d, skipped: %d, failed: %d, dry run: %t", report.Created, report.Skipped, report.Failed, dryRun)
+
+	responseContent, err := json.Marshal(report)
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusInternalServerError), err
+	}
+	return responseWithContent(string(responseContent), http.StatusOK), nil
+}
+
+// ToRecord converts passed CSV row to a time tracking record and validates it.
+func (handler *TimeTrackingImportHandler) toRecord(row []string, columns ImportColumns, location *time.Location) (timetracker.TimeTrackingRecord, error) {
+
+	record := timetracker.TimeTrackingRecord{}
+	if len(row) <= columns.deviceId || len(row) <= columns.recordType || len(row) <= columns.timestamp {
+		return record, errors.New("Missing columns.")
+	}
+
+	recordType, err := toRecordType(strings.TrimSpace(row[columns.recordType]))
+	if err != nil {
+		return record, err
+	}
+	timestamp, err := parseImportTimestamp(strings.TrimSpace(row[columns.timestamp]), location)
+	if err != nil {
+		return record, err
+	}
+
+	record.DeviceId = strings.TrimSpace(row[columns.deviceId])
+	record.Type = recordType
+	record.Timestamp = timestamp
+	return validateRecordWith(record, handler.timestampValidator)
+}
+
+// CheckConflicts returns all conflicts of passed record with given records of its day, in timezone of its device.
+func (handler *TimeTrackingImportHandler) checkConflicts(record timetracker.TimeTrackingRecord, records []timetracker.TimeTrackingRecord) []RecordConflict {
+
+	start := startOfDay(record.Timestamp, handler.recordHandler.locationOfDevice(record.DeviceId))
+	end := start.AddDate(0, 0, 1)
+	recordsOfDay := []timetracker.TimeTrackingRecord{}
+	for _, existingRecord := range records {
+		if !existingRecord.Timestamp.Before(start) && existingRecord.Timestamp.Before(end) {
+			recordsOfDay = append(recordsOfDay, existingRecord)
+		}
+	}
+	return handler.recordHandler.conflictRules.check(record, recordsOfDay)
+}
+
+// ExistingRecords lists all persisted records of devices of passed records, within all days, in timezone of
+// a device, of these records. Only days of imported records are listed, consecutive days with a single query.
+func (handler *TimeTrackingImportHandler) existingRecords(records map[int]timetracker.TimeTrackingRecord) (map[string][]timetracker.TimeTrackingRecord, error) {
+
+	daysOfDevices := make(map[string]map[string]time.Time)
+	for _, record := range records {
+		if _, ok := daysOfDevices[record.DeviceId]; !ok {
+			daysOfDevices[record.DeviceId] = make(map[string]time.Time)
+		}
+		day := startOfDay(record.Timestamp, handler.recordHandler.locationOfDevice(record.DeviceId))
+		daysOfDevices[record.DeviceId][day.Format(dateLayout)] = day
+	}
+
+	existingRecords := make(map[string][]timetracker.TimeTrackingRecord)
+	for deviceId, days := range daysOfDevices {
+		existingRecords[deviceId] = []timetracker.TimeTrackingRecord{}
+		for _, timeRange := range consecutiveDays(days) {
+			recordsOfDays, err := handler.recordHandler.timeTracker.ListRecords(deviceId, timeRange[0], timeRange[1])
+			if err != nil {
+				return existingRecords, err
+			}
+			existingRecords[deviceId] = append(existingRecords[deviceId], recordsOfDays...)
+		}
+	}
+	return existingRecords, nil
+}
+
+// ConsecutiveDays merges passed days, by start of a day, into time ranges of consecutive days. Each range
+// starts at the beginning of its first day and ends at the beginning of the day after its last day.
+func consecutiveDays(days map[string]time.Time) [][]time.Time {
+
+	dates := []string{}
+	for date := range days {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+
+	timeRanges := [][]time.Time{}
+	for _, date := range dates {
+		day := days[date]
+		if len(timeRanges) > 0 {
+			lastRange := timeRanges[len(timeRanges)-1]
+			if lastRange[1].Format(dateLayout) == date {
+				lastRange[1] = day.AddDate(0, 0, 1)
+				continue
+			}
+		}
+		timeRanges = append(timeRanges, []time.Time{day, day.AddDate(0, 0, 1)})
+	}
+	return timeRanges
+}
+
+// ConflictMessages joins messages of all passed conflicts.
+func conflictMessages(conflicts []RecordConflict) string {
+	messages := []string{}
+	for _, conflict := range conflicts {
+		messages = append(messages, conflict.Message)
+	}
+	return strings.Join(messages, " ")
+}
+
+// ReadCsv reads all rows of passed CSV content. Default delimiter is a comma.
+func readCsv(content, delimiter string) ([][]string, error) {
+
+	reader := csv.NewReader(strings.NewReader(content))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	if delimiter != "" {
+		if delimiter == "tab" {
+			delimiter = "\t"
+		}
+		if len([]rune(delimiter)) != 1 {
+			return nil, errors.New("Invalid delimiter: " + delimiter)
+		}
+		reader.Comma = []rune(delimiter)[0]
+	}
+
+	rows := [][]string{}
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+}
+
+// ImportColumns determines columns of record values. Columns can be passed as query parameters devicecolumn,
+// typecolumn and timestampcolumn, by name if CSV has a header or by a 1-based index. By default columns named
+// device, type and timestamp are used or the first three columns if there's no header.
+func importColumns(header []string, queryParams map[string]string) (ImportColumns, error) {
+
+	deviceId, err := importColumn(header, queryParams["devicecolumn"], []string{"device", "deviceid"}, 0)
+	if err != nil {
+		return ImportColumns{}, err
+	}
+	recordType, err := importColumn(header, queryParams["typecolumn"], []string{"type", "recordtype"}, 1)
+	if err != nil {
+		return ImportColumns{}, err
+	}
+	timestamp, err := importColumn(header, queryParams["timestampcolumn"], []string{"timestamp", "time"}, 2)
+	if err != nil {
+		return ImportColumns{}, err
+	}
+	return ImportColumns{deviceId: deviceId, recordType: recordType, timestamp: timestamp}, nil
+}
+
+// ImportColumn returns the index of a column passed by name or 1-based index, or the index of a column
+// with one of passed default names.
+func importColumn(header []string, column string, defaultNames []string, defaultIndex int) (int, error) {
+
+	if column == "" {
+		if header == nil {
+			return defaultIndex, nil
+		}
+		for _, name := range defaultNames {
+			if idx := indexOfColumn(header, name); idx >= 0 {
+				return idx, nil
+			}
+		}
+		return 0, fmt.Errorf("Missing column: %s", defaultNames[0])
+	}
+
+	if idx := indexOfColumn(header, column); idx >= 0 {
+		return idx, nil
+	}
+	if idx, err := strconv.Atoi(column); err == nil && idx > 0 {
+		return idx - 1, nil
+	}
+	return 0, fmt.Errorf("Invalid column: %s", column)
+}
+
+// IndexOfColumn returns the index of a column with passed name, case insensitive, or -1.
+func indexOfColumn(header []string, name string) int {
+	for idx, value := range header {
+		if strings.EqualFold(strings.TrimSpace(value), name) {
+			return idx
+		}
+	}
+	return -1
+}
+
+// ParseImportTimestamp parses passed value as RFC3339 timestamp or as a timestamp without timezone in given location.
+func parseImportTimestamp(value string, location *time.Location) (time.Time, error) {
+
+	for _, format := range dateFormatList {
+		if timestamp, err := time.Parse(format, value); err == nil {
+			return timestamp, nil
+		}
+	}
+	for _, format := range importTimestampFormats {
+		if timestamp, err := time.ParseInLocation(format, value, location); err == nil {
+			return timestamp, nil
+		}
+	}
+	return time.Time{}, errors.New("Invalid timestamp: " + value)
+}
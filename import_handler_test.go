@@ -0,0 +1,237 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/suite"
+	config "github.com/tommzn/go-config"
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+type TimeTrackingImportTestSuite struct {
+	suite.Suite
+}
+
+func TestTimeTrackingImportTestSuite(t *testing.T) {
+	suite.Run(t, new(TimeTrackingImportTestSuite))
+}
+
+func (suite *TimeTrackingImportTestSuite) TestImportRecords() {
+
+	handler := suite.handlerForTest()
+	prepareForTest(handler.recordHandler.timeTrackingManager)
+	csv := "device,type,timestamp\n" +
+		"Device01,workday,2022-01-03T08:00:00Z\n" +
+		"Device01,WORKDAY,2022-01-03 17:00:00\n" +
+		"Device01,workday,2022-01-01T09:00:00Z\n" +
+		"Device01,workday,2022-01-03T08:00:00Z\n" +
+		"Device01,weekend,2022-01-04T08:00:00Z\n" +
+		"Device01,vacation,xxx\n" +
+		",vacation,2022-01-04T08:00:00Z\n" +
+		"Device01,vacation\n" +
+		"Device01,vacation,1970-01-01T08:00:00Z\n" +
+		"Device02,illness,2022-01-04T08:00:00Z\n"
+
+	report := suite.importRecords(handler, csv, map[string]string{}, http.StatusOK)
+	suite.False(report.DryRun)
+	suite.Equal(3, report.Created)
+	suite.Equal(2, report.Skipped)
+	suite.Equal(5, report.Failed)
+	suite.Len(report.Rows, 10)
+	suite.Equal(ImportRowResult{Row: 2, Status: IMPORT_CREATED, Key: recordIdCodecForTest().encode("Device01/2022-01-03/0")}, report.Rows[0])
+	suite.Equal(IMPORT_CREATED, report.Rows[1].Status)
+	suite.Equal(IMPORT_SKIPPED, report.Rows[2].Status)
+	suite.Equal(IMPORT_SKIPPED, report.Rows[3].Status)
+	suite.Equal(IMPORT_FAILED, report.Rows[4].Status)
+	suite.NotEqual("", report.Rows[4].Error)
+	suite.Equal(11, report.Rows[9].Row)
+	suite.Equal(IMPORT_CREATED, report.Rows[9].Status)
+
+	start, end := dayRange(time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC))
+	records, err := handler.recordHandler.timeTracker.ListRecords("Device01", start, end)
+	suite.Nil(err)
+	suite.Len(records, 2)
+	suite.Equal(17, records[1].Timestamp.Hour())
+}
+
+func (suite *TimeTrackingImportTestSuite) TestImportRecordsDryRun() {
+
+	handler := suite.handlerForTest()
+	csv := "Device01;workday;2022-01-03 08:00\nDevice01;workday;2022-01-03 17:00\nDevice01;workday;2022-01-03 17:00\n"
+
+	report := suite.importRecords(handler, csv, map[string]string{"dryRun": "true", "header": "false", "delimiter": ";", "tz": "Europe/Berlin"}, http.StatusOK)
+	suite.True(report.DryRun)
+	suite.Equal(2, report.Created)
+	suite.Equal(1, report.Skipped)
+	suite.Equal("", report.Rows[0].Key)
+
+	start, end := dayRange(time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC))
+	records, err := handler.recordHandler.timeTracker.ListRecords("Device01", start, end)
+	suite.Nil(err)
+	suite.Len(records, 0)
+
+	report2 := suite.importRecords(handler, csv, map[string]string{"header": "false", "delimiter": ";", "tz": "Europe/Berlin"}, http.StatusOK)
+	suite.Equal(2, report2.Created)
+	records2, err2 := handler.recordHandler.timeTracker.ListRecords("Device01", start, end)
+	suite.Nil(err2)
+	suite.Len(records2, 2)
+	suite.Equal(7, records2[0].Timestamp.UTC().Hour())
+}
+
+func (suite *TimeTrackingImportTestSuite) TestImportConflictingRecords() {
+
+	handler := suite.handlerForTest()
+	prepareForTest(handler.recordHandler.timeTrackingManager)
+	csv := "device,type,timestamp\n" +
+		"Device01,vacation,2022-01-02T12:00:00Z\n" +
+		"Device01,vacation,2022-01-03T00:00:00Z\n" +
+		"Device01,workday,2022-01-03T08:00:00Z\n"
+
+	report1 := suite.importRecords(handler, csv, map[string]string{"dryRun": "true"}, http.StatusOK)
+	suite.Equal(1, report1.Created)
+	suite.Equal(2, report1.Failed)
+	suite.Equal(IMPORT_FAILED, report1.Rows[0].Status)
+	suite.Equal("Absences can't be mixed with work on same day.", report1.Rows[0].Error)
+	suite.Equal(IMPORT_CREATED, report1.Rows[1].Status)
+	suite.Equal(IMPORT_FAILED, report1.Rows[2].Status)
+
+	request := suite.requestForTest(csv, map[string]string{"force": "true"})
+	response2, err2 := handler.Process(request)
+	suite.NotNil(err2)
+	suite.Equal(http.StatusForbidden, response2.StatusCode)
+
+	request.RequestContext.Authorizer = map[string]interface{}{"groups": "Managers"}
+	response3, err3 := handler.Process(request)
+	suite.Nil(err3)
+	var report3 ImportReport
+	suite.Nil(json.Unmarshal([]byte(response3.Body), &report3))
+	suite.Equal(3, report3.Created)
+}
+
+func (suite *TimeTrackingImportTestSuite) TestListOnlyDaysOfImportedRecords() {
+
+	handler := suite.handlerForTest()
+	listMock := &timeTrackerListMock{TimeTracker: handler.recordHandler.timeTracker}
+	handler.recordHandler.timeTracker = listMock
+	csv := "device,type,timestamp\n" +
+		"Device01,workday,2022-01-03T08:00:00Z\n" +
+		"Device01,workday,2022-01-04T08:00:00Z\n" +
+		"Device01,workday,2022-01-03T17:00:00Z\n" +
+		"Device01,vacation,2022-06-01T08:00:00Z\n" +
+		"Device02,illness,2022-01-04T08:00:00Z\n"
+
+	report := suite.importRecords(handler, csv, map[string]string{}, http.StatusOK)
+	suite.Equal(5, report.Created)
+	suite.Equal(3, listMock.calls)
+}
+
+func (suite *TimeTrackingImportTestSuite) TestConsecutiveDays() {
+
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	days := make(map[string]time.Time)
+	for _, day := range []time.Time{
+		time.Date(2022, 3, 28, 0, 0, 0, 0, berlin),
+		time.Date(2022, 3, 26, 0, 0, 0, 0, berlin),
+		time.Date(2022, 3, 27, 0, 0, 0, 0, berlin),
+		time.Date(2022, 4, 1, 0, 0, 0, 0, berlin),
+	} {
+		days[day.Format(dateLayout)] = day
+	}
+
+	timeRanges := consecutiveDays(days)
+	suite.Len(timeRanges, 2)
+	suite.Equal([]time.Time{time.Date(2022, 3, 26, 0, 0, 0, 0, berlin), time.Date(2022, 3, 29, 0, 0, 0, 0, berlin)}, timeRanges[0])
+	suite.Equal([]time.Time{time.Date(2022, 4, 1, 0, 0, 0, 0, berlin), time.Date(2022, 4, 2, 0, 0, 0, 0, berlin)}, timeRanges[1])
+}
+
+func (suite *TimeTrackingImportTestSuite) TestImportWithColumnMapping() {
+
+	handler := suite.handlerForTest()
+	csv := "Time,Comment,Kind,Button\n2022-01-03T08:00:00Z,Start,vacation,Device01\n"
+
+	report1 := suite.importRecords(handler, csv, map[string]string{"timestampcolumn": "time", "typecolumn": "kind", "devicecolumn": "4"}, http.StatusOK)
+	suite.Equal(1, report1.Created)
+
+	report2 := suite.importRecords(handler, "Device01,2022-01-04T08:00:00Z,illness\n", map[string]string{"header": "false", "typecolumn": "3", "timestampcolumn": "2"}, http.StatusOK)
+	suite.Equal(1, report2.Created)
+
+	suite.importRecords(handler, csv, map[string]string{}, http.StatusBadRequest)
+	suite.importRecords(handler, csv, map[string]string{"timestampcolumn": "xxx"}, http.StatusBadRequest)
+	suite.importRecords(handler, csv, map[string]string{"delimiter": "xx"}, http.StatusBadRequest)
+	suite.importRecords(handler, "a,\"b\n", map[string]string{}, http.StatusBadRequest)
+}
+
+func (suite *TimeTrackingImportTestSuite) TestImportTooManyRows() {
+
+	handler := suite.handlerForTest()
+	handler.maxRows = 1
+	csv := "device,type,timestamp\nDevice01,workday,2022-01-03T08:00:00Z\nDevice01,workday,2022-01-03T09:00:00Z\n"
+	suite.importRecords(handler, csv, map[string]string{}, http.StatusRequestEntityTooLarge)
+
+	request := suite.requestForTest("", map[string]string{})
+	request.HTTPMethod = http.MethodGet
+	response, err := handler.Process(request)
+	suite.NotNil(err)
+	suite.Equal(http.StatusMethodNotAllowed, response.StatusCode)
+}
+
+func (suite *TimeTrackingImportTestSuite) TestImportTimestampWindow() {
+
+	conf, _ := config.NewStaticConfigSource("hob:\n  timestamps:\n    maxpast: 24h\n").Load()
+	handler := newTimeTrackingImportHandler(timeTrackingRecordHandlerForTest(), conf, loggerForTest())
+	suite.Equal(200, handler.maxRows)
+	timestamp := time.Now().AddDate(-5, 0, 0)
+	record := timetracker.TimeTrackingRecord{DeviceId: "Device01", Type: timetracker.WORKDAY, Timestamp: timestamp}
+	_, err := validateRecordWith(record, newTimestampValidator(conf))
+	suite.NotNil(err)
+
+	csv := "Device01,workday," + timestamp.UTC().Format(time.RFC3339) + "\nDevice01,workday,1970-01-01T08:00:00Z\n"
+	report := suite.importRecords(handler, csv, map[string]string{"header": "false"}, http.StatusOK)
+	suite.Equal(1, report.Created)
+	suite.Equal(1, report.Failed)
+}
+
+func (suite *TimeTrackingImportTestSuite) TestParseImportTimestamp() {
+
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	timestamp1, err1 := parseImportTimestamp("2022-01-03T08:00:00+01:00", time.UTC)
+	suite.Nil(err1)
+	suite.Equal(7, timestamp1.UTC().Hour())
+
+	timestamp2, err2 := parseImportTimestamp("2022-07-03 08:00", berlin)
+	suite.Nil(err2)
+	suite.Equal(6, timestamp2.UTC().Hour())
+
+	_, err3 := parseImportTimestamp("03.01.2022", berlin)
+	suite.NotNil(err3)
+}
+
+func (suite *TimeTrackingImportTestSuite) importRecords(handler *TimeTrackingImportHandler, csv string, queryParams map[string]string, expectedStatus int) ImportReport {
+
+	response, err := handler.Process(suite.requestForTest(csv, queryParams))
+	suite.Equal(expectedStatus, response.StatusCode)
+	var report ImportReport
+	if expectedStatus == http.StatusOK {
+		suite.Nil(err)
+		suite.Nil(json.Unmarshal([]byte(response.Body), &report))
+	} else {
+		suite.NotNil(err)
+	}
+	return report
+}
+
+func (suite *TimeTrackingImportTestSuite) handlerForTest() *TimeTrackingImportHandler {
+	return newTimeTrackingImportHandler(timeTrackingRecordHandlerForTest(), configForTest(), loggerForTest())
+}
+
+func (suite *TimeTrackingImportTestSuite) requestForTest(csv string, queryParams map[string]string) events.APIGatewayProxyRequest {
+	request := timeTrackingRecordHandlerRequestForTest(http.MethodPost)
+	request.Resource = "/timetrackingrecords/import"
+	request.QueryStringParameters = queryParams
+	request.Body = csv
+	return request
+}
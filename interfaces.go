@@ -1,8 +1,12 @@
 package main
 
 import (
+	"context"
+	"time"
+
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/golang/protobuf/proto"
+	timetracker "github.com/tommzn/hob-timetracker"
 )
 
 // Handler is used to process request forwarded by AWS API Gateway.
@@ -12,9 +16,101 @@ type Handler interface {
 	Process(events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)
 }
 
+// QueueConsumer is used to process messages received from AWS SQS.
+type QueueConsumer interface {
+
+	// Consume will process all messages of passed SQS event and reports failed messages.
+	Consume(events.SQSEvent) (events.SQSEventResponse, error)
+}
+
 // Publisher is used to send messages to one or multiple queues.
 type Publisher interface {
 
 	// Send will publish passed message to given queues.
 	Send(message proto.Message) error
 }
+
+// IdempotencyStore is used to persist responses of requests with an idempotency key.
+type IdempotencyStore interface {
+
+	// Get returns a stored response for passed key or nil if there's no, or no longer a, response for it.
+	Get(key string) (*IdempotencyRecord, error)
+
+	// Put stores passed response for given key.
+	Put(key string, record IdempotencyRecord) error
+
+	// Reserve stores passed record for given key, only if there's no, or no longer a, record for it.
+	// Returns false if key is already in use.
+	Reserve(key string, record IdempotencyRecord) (bool, error)
+
+	// Remove deletes a stored record for passed key.
+	Remove(key string) error
+}
+
+// TimeTrackingRepository is used to capture, list and maintain time tracking records.
+type TimeTrackingRepository interface {
+	timetracker.TimeTracker
+	timetracker.TimeTrackingRecordManager
+}
+
+// Notifier is used to send notifications about time tracking events.
+type Notifier interface {
+
+	// Notify sends passed event asynchronously to all matching subscribers.
+	Notify(event WebhookEvent)
+}
+
+// WebhookDeliveryLog keeps deliveries of webhook events.
+type WebhookDeliveryLog interface {
+
+	// Put appends passed delivery to the log.
+	Put(delivery WebhookDelivery) error
+
+	// List returns at most limit deliveries of given day, in UTC, oldest first.
+	List(date time.Time, limit int) ([]WebhookDelivery, error)
+}
+
+// HolidayProvider is used to get public holidays.
+type HolidayProvider interface {
+
+	// Holidays returns all public holidays of a year for given country, ISO 3166-1 code,
+	// and an optional region. Holidays of a country are included if a region is passed.
+	Holidays(year int, country, region string) ([]Holiday, error)
+
+	// SupportsRegions returns true if holidays can be looked up for a region of a country.
+	SupportsRegions() bool
+}
+
+// RecordTrash is used to keep deleted time tracking records until their retention expires.
+type RecordTrash interface {
+
+	// Put moves passed entry to trash.
+	Put(entry TrashEntry) error
+
+	// Get returns an entry from trash or nil if there's no entry for passed id.
+	Get(id string) (*TrashEntry, error)
+
+	// List returns at most limit entries of a device, or of all devices if device id is empty, which are not
+	// expired. Entries are ordered by device id, most recent deleted first, and start after the entry of passed id.
+	List(deviceId, after string, limit int, now time.Time) ([]TrashEntry, error)
+
+	// Expired returns ids of all expired entries.
+	Expired(now time.Time) ([]string, error)
+
+	// Remove deletes an entry from trash.
+	Remove(id string) error
+}
+
+// InvocationContextReceiver is used by handlers which depend on the context of a Lambda invocation, e.g. its deadline.
+type InvocationContextReceiver interface {
+
+	// SetInvocationContext passes the context of current invocation.
+	SetInvocationContext(ctx context.Context)
+}
+
+// ScheduledJob is executed for scheduled events, e.g. to run maintenance tasks.
+type ScheduledJob interface {
+
+	// Run executes this job for passed event.
+	Run(event events.CloudWatchEvent) error
+}
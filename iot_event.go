@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+const (
+	placementAttributeDeviceId   = "deviceid"
+	placementAttributeRecordType = "recordtype"
+)
+
+// IsIotOneClickEvent returns true if passed payload contains a device event send by AWS IoT 1-Click.
+func isIotOneClickEvent(payload map[string]json.RawMessage) bool {
+	_, hasDeviceEvent := payload["deviceEvent"]
+	_, hasDeviceInfo := payload["deviceInfo"]
+	return hasDeviceEvent && hasDeviceInfo
+}
+
+// IsIotCoreButtonEvent returns true if passed payload has been published by an AWS IoT button via IoT Core.
+func isIotCoreButtonEvent(payload map[string]json.RawMessage) bool {
+	_, hasSerialNumber := payload["serialNumber"]
+	_, hasClickType := payload["clickType"]
+	return hasSerialNumber && hasClickType
+}
+
+// FromIotOneClickEvent converts an AWS IoT 1-Click event to a time tracking capture.
+// Placement attributes "deviceid" and "recordtype" will override values from device event.
+func fromIotOneClickEvent(requestBody string) (TimeTrackingCapture, error) {
+
+	var event IotOneClickEvent
+	if err := json.Unmarshal([]byte(requestBody), &event); err != nil {
+		return TimeTrackingCapture{}, err
+	}
+
+	capture := TimeTrackingCapture{
+		DeviceId:      event.DeviceInfo.DeviceId,
+		ClickType:     event.DeviceEvent.ButtonClicked.ClickType,
+		Timestamp:     event.DeviceEvent.ButtonClicked.ReportedTime,
+		RemainingLife: event.DeviceInfo.RemainingLife,
+	}
+	if event.PlacementInfo == nil {
+		return capture, nil
+	}
+
+	if deviceId, ok := event.PlacementInfo.Attributes[placementAttributeDeviceId]; ok && deviceId != "" {
+		capture.DeviceId = deviceId
+	}
+	if recordType, ok := event.PlacementInfo.Attributes[placementAttributeRecordType]; ok && recordType != "" {
+		capture.RecordType = timetracker.RecordType(strings.ToLower(recordType))
+	}
+	return capture, nil
+}
+
+// FromIotCoreButtonEvent converts an event published by an AWS IoT button to a time tracking capture.
+func fromIotCoreButtonEvent(requestBody string) (TimeTrackingCapture, error) {
+
+	var event IotCoreButtonEvent
+	if err := json.Unmarshal([]byte(requestBody), &event); err != nil {
+		return TimeTrackingCapture{}, err
+	}
+	return TimeTrackingCapture{
+		DeviceId:  event.SerialNumber,
+		ClickType: event.ClickType,
+	}, nil
+}
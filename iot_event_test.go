@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+type IotEventTestSuite struct {
+	suite.Suite
+}
+
+func TestIotEventTestSuite(t *testing.T) {
+	suite.Run(t, new(IotEventTestSuite))
+}
+
+func (suite *IotEventTestSuite) TestConvertIotOneClickEvent() {
+
+	capture, err := toTimeTrackingRecord(suite.fixture("fixtures/iot-1click-event.json"))
+	suite.Nil(err)
+	suite.Equal("Device01", capture.DeviceId)
+	suite.Equal(DOUBLE_CLICK, capture.ClickType)
+	suite.Equal(timetracker.WORKDAY, capture.RecordType)
+	suite.NotNil(capture.RemainingLife)
+	suite.NotNil(capture.Timestamp)
+	suite.Equal(time.Date(2022, 1, 3, 8, 12, 33, 747000000, time.UTC), capture.Timestamp.UTC())
+}
+
+func (suite *IotEventTestSuite) TestConvertIotCoreButtonEvent() {
+
+	capture, err := toTimeTrackingRecord(suite.fixture("fixtures/iot-core-event.json"))
+	suite.Nil(err)
+	suite.Equal("G030JF0123456789", capture.DeviceId)
+	suite.Equal(LONG_PRESS, capture.ClickType)
+	suite.Equal(timetracker.RecordType(""), capture.RecordType)
+	suite.Nil(capture.Timestamp)
+}
+
+func (suite *IotEventTestSuite) TestConvertTimeTrackingCapture() {
+
+	capture, err := toTimeTrackingRecord("{\"deviceid\":\"Device01\",\"clicktype\":\"SINGLE\"}")
+	suite.Nil(err)
+	suite.Equal("Device01", capture.DeviceId)
+	suite.Equal(SINGLE_CLICK, capture.ClickType)
+
+	_, err1 := toTimeTrackingRecord("xxx")
+	suite.NotNil(err1)
+}
+
+func (suite *IotEventTestSuite) fixture(file string) string {
+	content, err := os.ReadFile(file)
+	suite.Nil(err)
+	return string(content)
+}
@@ -3,6 +3,9 @@ package main
 import (
 	"errors"
 	"os"
+	"strings"
+	"time"
+	_ "time/tzdata"
 
 	"github.com/aws/aws-lambda-go/lambda"
 
@@ -14,15 +17,15 @@ import (
 
 func main() {
 
-	handler, err := bootstrap()
+	dispatcher, err := bootstrap()
 	if err != nil {
 		panic(err)
 	}
-	lambda.Start(handler.Process)
+	lambda.Start(dispatcher.Dispatch)
 }
 
-// bootstrap loads config and creates a new request handler.
-func bootstrap() (Handler, error) {
+// bootstrap loads config and creates a new dispatcher for requests and queue events.
+func bootstrap() (*EventDispatcher, error) {
 
 	conf, err := loadConfig()
 	if err != nil {
@@ -35,13 +38,109 @@ func bootstrap() (Handler, error) {
 	if err != nil {
 		return nil, err
 	}
+	recordIds, err := newRecordIdCodecFromSecrets(secretsManager)
+	if err != nil {
+		return nil, err
+	}
+	webhookDeliveryLog, err := newWebhookDeliveryLog(conf)
+	if err != nil {
+		return nil, err
+	}
+	webhookNotifier, err := newWebhookNotifier(conf, newWebhookPublisher(conf, logger), webhookDeliveryLog, logger)
+	if err != nil {
+		return nil, err
+	}
+	repository, recordCache := newRecordCache(conf, newNotifyingRepository(timeTracker.(TimeTrackingRepository), webhookNotifier, recordIds, conf.Get("aws.s3.basepath", nil)))
+
+	clickTypeMapping, err := newClickTypeMapping(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	idempotencyStore, err := newIdempotencyStore(conf)
+	if err != nil {
+		return nil, err
+	}
+	idempotencyTtl := conf.GetAsDuration("hob.idempotency.ttl", config.AsDurationPtr(24*time.Hour))
+
+	capturePublisher, asyncCapture, err := newCapturePublisher(conf, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	timestampValidator := newTimestampValidator(conf)
+	timezones, err := newTimezoneSettings(conf)
+	if err != nil {
+		return nil, err
+	}
+	recordTrash, err := newRecordTrash(conf)
+	if err != nil {
+		return nil, err
+	}
+	timeTrackingRecordHandler, err := newTimeTrackingRecordHandler(repository, repository, timestampValidator, recordIds, recordTrash, conf, logger)
+	if err != nil {
+		return nil, err
+	}
+	holidayCalendar, err := newHolidayCalendar(conf, secretsManager)
+	if err != nil {
+		return nil, err
+	}
+	timeTrackingBalanceHandler, err := newTimeTrackingBalanceHandler(timeTrackingRecordHandler, holidayCalendar, conf, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	calendarFeedHandler, err := newCalendarFeedHandler(timeTrackingRecordHandler, conf, secretsManager, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	trashHandler := newTimeTrackingTrashHandler(timeTrackingRecordHandler, logger)
 
-	timeTrackingRecordHandler := newTimeTrackingRecordHandler(timeTracker.(timetracker.TimeTrackingRecordManager), timeTracker, logger)
 	routes := make(map[RequestedResource]Handler)
-	routes["/capture"] = newCaptureRequestHandler(timeTracker, logger)
+	routes["/capture"] = newIdempotentHandler(newCaptureRequestHandler(repository, clickTypeMapping, timestampValidator, capturePublisher, asyncCapture, recordIds, timezones, logger), idempotencyStore, *idempotencyTtl, logger)
+	routes["/capture/batch"] = newCaptureBatchRequestHandler(repository, clickTypeMapping, timestampValidator, recordIds, *conf.GetAsInt("hob.capture.batch.maxsize", config.AsIntPtr(100)), logger)
 	routes["/generatereport"] = newReportGenerateRequestHandler(logger, newSqsPublisher(conf, logger))
-	routes["/timetrackingrecords"] = timeTrackingRecordHandler
-	return newRequestRouter(routes, logger), nil
+	routes["/timetrackingrecords"] = newIdempotentHandler(timeTrackingRecordHandler, idempotencyStore, *idempotencyTtl, logger)
+	routes["/timetrackingrecords/{id}"] = timeTrackingRecordHandler
+	routes["/timetrackingrecords/import"] = newIdempotentHandler(newTimeTrackingImportHandler(timeTrackingRecordHandler, conf, logger), idempotencyStore, *idempotencyTtl, logger)
+	routes["/timetrackingrecords/summary"] = newTimeTrackingSummaryHandler(timeTrackingRecordHandler, holidayCalendar, logger)
+	routes["/timetrackingrecords/balance"] = timeTrackingBalanceHandler
+	routes["/calendar/holidays"] = newHolidayRequestHandler(holidayCalendar, logger)
+	routes["/calendar/{feed}"] = calendarFeedHandler
+	routes["/timetrackingrecords/trash"] = trashHandler
+	routes["/timetrackingrecords/{id}/restore"] = trashHandler
+	routes["/webhooks/deliveries"] = newWebhookDeliveryHandler(webhookDeliveryLog, logger)
+	routes["/metrics/cache"] = newCacheStatsHandler(recordCache, logger)
+
+	scheduledJobs := []ScheduledJob{newTrashPurgeJob(recordTrash, logger)}
+	contextReceivers := []InvocationContextReceiver{timeTrackingRecordHandler}
+	return newEventDispatcher(newRequestRouter(routes, logger), newCaptureQueueConsumer(repository, webhookNotifier, logger), scheduledJobs, contextReceivers, logger), nil
+}
+
+// NewWebhookPublisher creates a publisher for webhook deliveries if a webhook queue, or a capture queue, is defined.
+func newWebhookPublisher(conf config.Config, logger log.Logger) Publisher {
+
+	queue := conf.Get("hob.webhooks.queue", conf.Get("hob.capture.queue", nil))
+	if queue == nil || *queue == "" {
+		return nil
+	}
+	return newSqsPublisherForQueue(conf, *queue, logger)
+}
+
+// newCapturePublisher creates a publisher for capture events if a capture queue is defined.
+// Returns with an error if async capture mode is enabled, but there's no capture queue.
+func newCapturePublisher(conf config.Config, logger log.Logger) (Publisher, bool, error) {
+
+	asyncCapture := strings.ToLower(*conf.Get("hob.capture.mode", config.AsStringPtr("sync"))) == "async"
+	queue := conf.Get("hob.capture.queue", nil)
+	if queue == nil || *queue == "" {
+		if asyncCapture {
+			return nil, false, errors.New("Async capture mode requires a capture queue!")
+		}
+		return nil, false, nil
+	}
+	return newSqsPublisherForQueue(conf, *queue, logger), asyncCapture, nil
 }
 
 // loadConfig from config file.
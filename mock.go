@@ -1,20 +1,249 @@
 package main
 
 import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
 	"github.com/golang/protobuf/proto"
+	timetracker "github.com/tommzn/hob-timetracker"
 )
 
 // sqsMock mocks access to AWS SQS for testing.
 type sqsMock struct {
-	callCount int
+	callCount         int
+	messages          []proto.Message
+	shouldReturnError bool
 }
 
 // newSqsMock creates a new mock for AWS SQS.
 func newSqsMock() *sqsMock {
-	return &sqsMock{callCount: 0}
+	return &sqsMock{callCount: 0, messages: []proto.Message{}}
 }
 
 func (mock *sqsMock) Send(message proto.Message) error {
 	mock.callCount++
+	if mock.shouldReturnError {
+		return errors.New("Unable to send message.")
+	}
+	mock.messages = append(mock.messages, message)
+	return nil
+}
+
+// timeTrackerErrorMock is a time tracker which fails to persist records.
+type timeTrackerErrorMock struct {
+}
+
+func (mock *timeTrackerErrorMock) Capture(deviceId string, recordType timetracker.RecordType) error {
+	return errors.New("Unable to capture record.")
+}
+
+func (mock *timeTrackerErrorMock) Captured(deviceId string, recordType timetracker.RecordType, timestamp time.Time) error {
+	return errors.New("Unable to capture record.")
+}
+
+func (mock *timeTrackerErrorMock) ListRecords(deviceId string, start time.Time, end time.Time) ([]timetracker.TimeTrackingRecord, error) {
+	return nil, errors.New("Unable to list records.")
+}
+
+// notifierMock collects all events passed for notification.
+type notifierMock struct {
+	events []WebhookEvent
+}
+
+func (mock *notifierMock) Notify(event WebhookEvent) {
+	mock.events = append(mock.events, event)
+}
+
+// recordManagerErrorMock delegates all calls to a wrapped repository, but fails to add new records.
+type recordManagerErrorMock struct {
+	TimeTrackingRepository
+}
+
+// Add will always return an error.
+func (mock *recordManagerErrorMock) Add(record timetracker.TimeTrackingRecord) (timetracker.TimeTrackingRecord, error) {
+	return record, errors.New("Unable to add time tracking record.")
+}
+
+// recordManagerFailOnceMock delegates all calls to a wrapped repository, but fails to add the first record.
+type recordManagerFailOnceMock struct {
+	TimeTrackingRepository
+	failed bool
+}
+
+// Add returns an error for the first call.
+func (mock *recordManagerFailOnceMock) Add(record timetracker.TimeTrackingRecord) (timetracker.TimeTrackingRecord, error) {
+	if !mock.failed {
+		mock.failed = true
+		return record, errors.New("Unable to add time tracking record.")
+	}
+	return mock.TimeTrackingRepository.Add(record)
+}
+
+// recordManagerDeleteErrorMock delegates all calls to a wrapped repository, but fails to delete records
+// after given number of deletes.
+type recordManagerDeleteErrorMock struct {
+	TimeTrackingRepository
+	allowedDeletes int
+}
+
+// Delete returns an error if allowed deletes are exceeded.
+func (mock *recordManagerDeleteErrorMock) Delete(key string) error {
+	if mock.allowedDeletes <= 0 {
+		return errors.New("Unable to delete time tracking record.")
+	}
+	mock.allowedDeletes--
+	return mock.TimeTrackingRepository.Delete(key)
+}
+
+// scheduledJobMock counts executions and fails if requested.
+type scheduledJobMock struct {
+	callCount         int
+	shouldReturnError bool
+}
+
+func (mock *scheduledJobMock) Run(event events.CloudWatchEvent) error {
+	mock.callCount++
+	if mock.shouldReturnError {
+		return errors.New("Unable to run scheduled job.")
+	}
 	return nil
 }
+
+// timeTrackerListMock delegates listing to a wrapped time tracker, but delays or fails listing of single devices.
+// It tracks the max number of concurrent list calls and the number of all list calls.
+type timeTrackerListMock struct {
+	timetracker.TimeTracker
+	sync.Mutex
+	delays        map[string]time.Duration
+	failedDevices map[string]bool
+	running       int
+	maxRunning    int
+	calls         int
+}
+
+func (mock *timeTrackerListMock) ListRecords(deviceId string, start time.Time, end time.Time) ([]timetracker.TimeTrackingRecord, error) {
+
+	mock.Lock()
+	mock.calls++
+	mock.running++
+	if mock.running > mock.maxRunning {
+		mock.maxRunning = mock.running
+	}
+	mock.Unlock()
+	defer func() {
+		mock.Lock()
+		mock.running--
+		mock.Unlock()
+	}()
+
+	time.Sleep(mock.delays[deviceId])
+	if mock.failedDevices[deviceId] {
+		return nil, errors.New("AccessDenied: access to bucket test denied.")
+	}
+	return mock.TimeTracker.ListRecords(deviceId, start, end)
+}
+
+// recordManagerListMock delegates all calls to a wrapped repository, but lists records with a separate time tracker.
+type recordManagerListMock struct {
+	TimeTrackingRepository
+	timeTracker timetracker.TimeTracker
+}
+
+func (mock *recordManagerListMock) ListRecords(deviceId string, start time.Time, end time.Time) ([]timetracker.TimeTrackingRecord, error) {
+	return mock.timeTracker.ListRecords(deviceId, start, end)
+}
+
+// recordTrashMock keeps deleted time tracking records in memory.
+type recordTrashMock struct {
+	sync.Mutex
+	entries map[string]TrashEntry
+}
+
+// newRecordTrashMock returns a new, empty in memory trash for testing.
+func newRecordTrashMock() *recordTrashMock {
+	return &recordTrashMock{entries: make(map[string]TrashEntry)}
+}
+
+// Put adds passed entry to trash.
+func (trash *recordTrashMock) Put(entry TrashEntry) error {
+
+	trash.Lock()
+	defer trash.Unlock()
+
+	trash.entries[entry.Id] = entry
+	return nil
+}
+
+// Get returns an entry for passed id or nil if there's no such entry.
+func (trash *recordTrashMock) Get(id string) (*TrashEntry, error) {
+
+	trash.Lock()
+	defer trash.Unlock()
+
+	entry, ok := trash.entries[id]
+	if !ok {
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+// List returns at most limit entries, which are not expired, ordered by id.
+func (trash *recordTrashMock) List(deviceId, after string, limit int, now time.Time) ([]TrashEntry, error) {
+
+	trash.Lock()
+	defer trash.Unlock()
+
+	ids := []string{}
+	for id, entry := range trash.entries {
+		if (deviceId == "" || entry.Record.DeviceId == deviceId) && id > after && !isTrashEntryExpired(id, now) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	entries := []TrashEntry{}
+	for _, id := range ids {
+		if len(entries) < limit {
+			entries = append(entries, trash.entries[id])
+		}
+	}
+	return entries, nil
+}
+
+// Expired returns ids of all expired entries.
+func (trash *recordTrashMock) Expired(now time.Time) ([]string, error) {
+
+	trash.Lock()
+	defer trash.Unlock()
+
+	ids := []string{}
+	for id := range trash.entries {
+		if isTrashEntryExpired(id, now) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// Remove deletes an entry from trash.
+func (trash *recordTrashMock) Remove(id string) error {
+
+	trash.Lock()
+	defer trash.Unlock()
+
+	delete(trash.entries, id)
+	return nil
+}
+
+// holidayProviderMock delegates to a wrapped provider and records requested years.
+type holidayProviderMock struct {
+	HolidayProvider
+	years []int
+}
+
+func (mock *holidayProviderMock) Holidays(year int, country, region string) ([]Holiday, error) {
+	mock.years = append(mock.years, year)
+	return mock.HolidayProvider.Holidays(year, country, region)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"time"
+
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+// NewNotifyingRepository wraps passed repository to send notifications for created and deleted records.
+// Keys of records are passed as opaque record ids. Base path is used to resolve records which should be deleted.
+func newNotifyingRepository(repository TimeTrackingRepository, notifier Notifier, recordIds *RecordIdCodec, basePath *string) *NotifyingRepository {
+	return &NotifyingRepository{
+		repository: repository,
+		notifier:   notifier,
+		recordIds:  recordIds,
+		basePath:   basePath,
+	}
+}
+
+// Capture will create a time tracking record with passed type at time this method has been called.
+func (repo *NotifyingRepository) Capture(deviceId string, recordType timetracker.RecordType) error {
+	return repo.Captured(deviceId, recordType, time.Now())
+}
+
+// Captured creates a time tracking record for passed point in time and sends a notification for it.
+func (repo *NotifyingRepository) Captured(deviceId string, recordType timetracker.RecordType, timestamp time.Time) error {
+	if err := repo.repository.Captured(deviceId, recordType, timestamp); err != nil {
+		return err
+	}
+	repo.notifier.Notify(WebhookEvent{Event: RECORD_CREATED, DeviceId: deviceId, RecordType: recordType, Timestamp: &APITime{Time: timestamp}})
+	return nil
+}
+
+// ListRecords returns available time tracking records for given range.
+func (repo *NotifyingRepository) ListRecords(deviceId string, start time.Time, end time.Time) ([]timetracker.TimeTrackingRecord, error) {
+	return repo.repository.ListRecords(deviceId, start, end)
+}
+
+// Add creates a new time tracking record and sends a notification for it.
+func (repo *NotifyingRepository) Add(record timetracker.TimeTrackingRecord) (timetracker.TimeTrackingRecord, error) {
+	newRecord, err := repo.repository.Add(record)
+	if err != nil {
+		return newRecord, err
+	}
+	repo.notifier.Notify(WebhookEvent{Event: RECORD_CREATED, Key: repo.recordIds.encode(newRecord.Key), DeviceId: newRecord.DeviceId, RecordType: newRecord.Type, Timestamp: &APITime{Time: newRecord.Timestamp}})
+	return newRecord, nil
+}
+
+// Delete will remove time tracking record by passed key and sends a notification for it.
+// Record is resolved before it's deleted, so notifications contain device id, record type and timestamp.
+func (repo *NotifyingRepository) Delete(key string) error {
+
+	event := WebhookEvent{Event: RECORD_DELETED, Key: repo.recordIds.encode(key)}
+	if record, err := findRecordByKey(repo.repository, key, repo.basePath); err == nil && record != nil {
+		event.DeviceId = record.DeviceId
+		event.RecordType = record.Type
+		event.Timestamp = &APITime{Time: record.Timestamp}
+	} else if deviceId, _, err := parseRecordKey(key, repo.basePath); err == nil {
+		event.DeviceId = deviceId
+	}
+
+	if err := repo.repository.Delete(key); err != nil {
+		return err
+	}
+	repo.notifier.Notify(event)
+	return nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+type NotifyingRepositoryTestSuite struct {
+	suite.Suite
+}
+
+func TestNotifyingRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(NotifyingRepositoryTestSuite))
+}
+
+func (suite *NotifyingRepositoryTestSuite) TestNotifyForChanges() {
+
+	notifier := &notifierMock{events: []WebhookEvent{}}
+	repo := newNotifyingRepository(timetracker.NewLocaLRepository(), notifier, recordIdCodecForTest(), nil)
+
+	suite.Nil(repo.Captured("Device01", timetracker.WORKDAY, time.Now()))
+	record, err := repo.Add(timeTrackingRecordForTest())
+	suite.Nil(err)
+	suite.Nil(repo.Delete(record.Key))
+	suite.NotNil(repo.Delete("xxx"))
+
+	suite.Len(notifier.events, 3)
+	suite.Equal(RECORD_CREATED, notifier.events[0].Event)
+	suite.Equal(RECORD_CREATED, notifier.events[1].Event)
+	suite.Equal(recordIdCodecForTest().encode(record.Key), notifier.events[1].Key)
+	suite.Equal(RECORD_DELETED, notifier.events[2].Event)
+	suite.Equal(record.DeviceId, notifier.events[2].DeviceId)
+	suite.Equal(record.Type, notifier.events[2].RecordType)
+	suite.True(WebhookSubscription{DeviceIds: []string{record.DeviceId}}.matches(notifier.events[2]))
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+const (
+	SORT_BY_TIMESTAMP      = "timestamp"
+	SORT_BY_TIMESTAMP_DESC = "-timestamp"
+	SORT_BY_DEVICE         = "device"
+)
+
+// PageFromRequest extracts limit, sort order and cursor for a record listing from passed request.
+func (handler *TimeTrackingRecordHandler) pageFromRequest(request events.APIGatewayProxyRequest) (RecordPage, error) {
+
+	page := RecordPage{Limit: handler.defaultPageSize, Sort: SORT_BY_TIMESTAMP, Query: queryFingerprint(request.QueryStringParameters)}
+	if limitStr, ok := request.QueryStringParameters["limit"]; ok {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > handler.maxPageSize {
+			return page, fmt.Errorf("Invalid limit, has to be between 1 and %d.", handler.maxPageSize)
+		}
+		page.Limit = limit
+	}
+
+	if sortOrder, ok := request.QueryStringParameters["sort"]; ok {
+		switch sortOrder {
+		case SORT_BY_TIMESTAMP, SORT_BY_TIMESTAMP_DESC, SORT_BY_DEVICE:
+			page.Sort = sortOrder
+		default:
+			return page, errors.New("Invalid sort order: " + sortOrder)
+		}
+	}
+
+	if cursorStr, ok := request.QueryStringParameters["cursor"]; ok {
+		cursor, err := decodeCursor(cursorStr)
+		if err != nil || cursor.Query != page.Query || cursor.Timestamp.IsZero() {
+			return page, errors.New("Invalid cursor.")
+		}
+		key, err := handler.recordIds.decode(cursor.Key)
+		if err != nil {
+			return page, errors.New("Invalid cursor.")
+		}
+		page.After = &TimeTrackingRecord{Key: key, DeviceId: cursor.DeviceId, Timestamp: &APITime{Time: cursor.Timestamp}}
+	}
+	return page, nil
+}
+
+// TimeRange narrows passed time range to records which can be on current page. Records before the last record
+// of previous page don't have to be listed if records are sorted by timestamp.
+func (page RecordPage) timeRange(start, end time.Time) (time.Time, time.Time) {
+
+	if page.After == nil {
+		return start, end
+	}
+	switch page.Sort {
+	case SORT_BY_TIMESTAMP:
+		if page.After.Timestamp.After(start) {
+			start = page.After.Timestamp.Time
+		}
+	case SORT_BY_TIMESTAMP_DESC:
+		if lastEnd := page.After.Timestamp.Add(time.Nanosecond); lastEnd.Before(end) {
+			end = lastEnd
+		}
+	}
+	return start, end
+}
+
+// Devices narrows passed device ids to devices which can have records on current page. If records are sorted
+// by device, devices before the device of the last record of previous page are finished and don't have to be listed.
+func (page RecordPage) devices(deviceIds []string) []string {
+
+	if page.After == nil || page.Sort != SORT_BY_DEVICE {
+		return deviceIds
+	}
+	pendingDeviceIds := []string{}
+	for _, deviceId := range deviceIds {
+		if deviceId >= page.After.DeviceId {
+			pendingDeviceIds = append(pendingDeviceIds, deviceId)
+		}
+	}
+	return pendingDeviceIds
+}
+
+// Apply sorts passed records and returns records for current page, which are all records after the last record
+// of previous page. Returns a cursor for next page or nil if there're no more records. Keys of records and of
+// a cursor are storage keys.
+func (page RecordPage) apply(records []TimeTrackingRecord) ([]TimeTrackingRecord, *RecordCursor) {
+
+	sortRecords(records, page.Sort)
+	first := 0
+	if page.After != nil {
+		first = sort.Search(len(records), func(idx int) bool {
+			return isRecordBefore(*page.After, records[idx], page.Sort)
+		})
+	}
+	records = records[first:]
+	if len(records) <= page.Limit {
+		return records, nil
+	}
+
+	records = records[:page.Limit]
+	last := records[len(records)-1]
+	return records, &RecordCursor{Timestamp: last.Timestamp.Time, DeviceId: last.DeviceId, Key: last.Key, Query: page.Query}
+}
+
+// SortRecords sorts given records by passed sort order. Keys are used as tie breaker to get a stable order.
+func sortRecords(records []TimeTrackingRecord, sortOrder string) {
+	sort.SliceStable(records, func(i, j int) bool {
+		return isRecordBefore(records[i], records[j], sortOrder)
+	})
+}
+
+// IsRecordBefore returns true if record a is listed before record b in passed sort order.
+func isRecordBefore(a, b TimeTrackingRecord, sortOrder string) bool {
+	if sortOrder == SORT_BY_DEVICE && a.DeviceId != b.DeviceId {
+		return a.DeviceId < b.DeviceId
+	}
+	if !a.Timestamp.Equal(b.Timestamp.Time) {
+		if sortOrder == SORT_BY_TIMESTAMP_DESC {
+			return a.Timestamp.After(b.Timestamp.Time)
+		}
+		return a.Timestamp.Before(b.Timestamp.Time)
+	}
+	return a.Key < b.Key
+}
+
+// Encode returns an opaque, URL safe representation of a cursor.
+func (cursor RecordCursor) encode() string {
+	content, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(content)
+}
+
+// DecodeCursor decodes passed opaque cursor.
+func decodeCursor(value string) (RecordCursor, error) {
+	var cursor RecordCursor
+	content, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, err
+	}
+	err = json.Unmarshal(content, &cursor)
+	return cursor, err
+}
+
+// QueryFingerprint returns a hash of all query parameters, except pagination parameters.
+// It's used to ensure a cursor is used for the query it has been created for.
+func queryFingerprint(queryParams map[string]string) string {
+	keys := []string{}
+	for key := range queryParams {
+		if key != "cursor" && key != "limit" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	values := []string{}
+	for _, key := range keys {
+		values = append(values, key+"="+queryParams[key])
+	}
+	return hashRequestBody(strings.Join(values, "&"))[:16]
+}
+
+// NextLink returns a link header value for next page of passed request.
+func nextLink(request events.APIGatewayProxyRequest, cursor RecordCursor) string {
+	return nextLinkWithCursor(request, cursor.encode())
+}
+
+// NextLinkWithCursor returns a link header value for next page of passed request, which starts at given opaque cursor.
+func nextLinkWithCursor(request events.APIGatewayProxyRequest, cursor string) string {
+	queryValues := url.Values{}
+	for key, value := range request.QueryStringParameters {
+		queryValues.Set(key, value)
+	}
+	queryValues.Set("cursor", cursor)
+
+	path := request.Path
+	if path == "" {
+		path = request.Resource
+	}
+	return fmt.Sprintf("<%s?%s>; rel=\"next\"", path, queryValues.Encode())
+}
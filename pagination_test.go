@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+type PaginationTestSuite struct {
+	suite.Suite
+}
+
+func TestPaginationTestSuite(t *testing.T) {
+	suite.Run(t, new(PaginationTestSuite))
+}
+
+func (suite *PaginationTestSuite) TestPaginateRecords() {
+
+	handler := timeTrackingRecordHandlerForTest()
+	prepareForTest(handler.timeTrackingManager)
+
+	queryParams := map[string]string{"deviceid": "Device01", "from": "2021-12-31", "to": "2022-01-02", "limit": "3", "sort": "-timestamp"}
+	records1, link1 := suite.listRecords(handler, queryParams)
+	suite.Len(records1, 3)
+	suite.NotEqual("", link1)
+	suite.True(records1[0].Timestamp.After(records1[1].Timestamp.Time))
+	suite.True(records1[1].Timestamp.After(records1[2].Timestamp.Time))
+
+	queryParams["cursor"] = suite.cursorFromLink(link1)
+	records2, link2 := suite.listRecords(handler, queryParams)
+	suite.Len(records2, 1)
+	suite.Equal("", link2)
+	suite.True(records1[2].Timestamp.After(records2[0].Timestamp.Time))
+
+	queryParams["sort"] = "timestamp"
+	request := timeTrackingRecordHandlerRequestForTest(http.MethodGet)
+	request.QueryStringParameters = queryParams
+	res, err := handler.Process(request)
+	suite.NotNil(err)
+	suite.Equal(http.StatusBadRequest, res.StatusCode)
+}
+
+func (suite *PaginationTestSuite) TestPaginateChangingRecords() {
+
+	handler := timeTrackingRecordHandlerForTest()
+	prepareForTest(handler.timeTrackingManager)
+
+	queryParams := map[string]string{"deviceid": "Device01", "from": "2021-12-31", "to": "2022-01-02", "limit": "2"}
+	records1, link1 := suite.listRecords(handler, queryParams)
+	suite.Len(records1, 2)
+	suite.Equal(9, records1[1].Timestamp.Hour())
+
+	_, err := handler.timeTrackingManager.Add(recordForTest(timetracker.WORKDAY, time.Date(2021, 12, 31, 10, 0, 0, 0, time.UTC)))
+	suite.Nil(err)
+
+	queryParams["cursor"] = suite.cursorFromLink(link1)
+	records2, link2 := suite.listRecords(handler, queryParams)
+	suite.Len(records2, 2)
+	suite.Equal("", link2)
+	suite.Equal(time.Date(2022, 1, 1, 17, 0, 0, 0, time.UTC), records2[0].Timestamp.UTC())
+	suite.Equal(time.Date(2022, 1, 2, 9, 0, 0, 0, time.UTC), records2[1].Timestamp.UTC())
+}
+
+func (suite *PaginationTestSuite) TestPaginateByDeviceSkipsFinishedDevices() {
+
+	handler := timeTrackingRecordHandlerForTest()
+	for _, deviceId := range []string{"Device01", "Device02", "Device03"} {
+		for _, hour := range []int{8, 17} {
+			record := recordForTest(timetracker.WORKDAY, time.Date(2022, 1, 3, hour, 0, 0, 0, time.UTC))
+			record.DeviceId = deviceId
+			_, err := handler.timeTrackingManager.Add(record)
+			suite.Nil(err)
+		}
+	}
+	listMock := &timeTrackerListMock{TimeTracker: handler.timeTracker}
+	handler.timeTracker = listMock
+
+	queryParams := map[string]string{"deviceids": "Device03,Device01,Device02", "date": "2022-01-03", "limit": "3", "sort": "device"}
+	records1, link1 := suite.listRecords(handler, queryParams)
+	suite.Len(records1, 3)
+	suite.Equal("Device02", records1[2].DeviceId)
+	suite.Equal(3, listMock.calls)
+
+	queryParams["cursor"] = suite.cursorFromLink(link1)
+	records2, link2 := suite.listRecords(handler, queryParams)
+	suite.Len(records2, 3)
+	suite.Equal("", link2)
+	suite.Equal("Device02", records2[0].DeviceId)
+	suite.Equal(17, records2[0].Timestamp.Hour())
+	suite.Equal(5, listMock.calls)
+
+	page := RecordPage{Sort: SORT_BY_DEVICE, After: &TimeTrackingRecord{DeviceId: "Device02"}}
+	suite.Equal([]string{"Device03", "Device02"}, page.devices([]string{"Device03", "Device01", "Device02"}))
+	page.Sort = SORT_BY_TIMESTAMP
+	suite.Len(page.devices([]string{"Device03", "Device01", "Device02"}), 3)
+}
+
+func (suite *PaginationTestSuite) TestApplyPage() {
+
+	timestamp := time.Date(2022, 1, 3, 8, 0, 0, 0, time.UTC)
+	records := []TimeTrackingRecord{
+		{Key: "3", DeviceId: "Device02", Timestamp: &APITime{Time: timestamp}},
+		{Key: "2", DeviceId: "Device01", Timestamp: &APITime{Time: timestamp.Add(time.Hour)}},
+		{Key: "1", DeviceId: "Device01", Timestamp: &APITime{Time: timestamp}},
+	}
+
+	page := RecordPage{Limit: 1, Sort: SORT_BY_DEVICE}
+	records1, cursor1 := page.apply(records)
+	suite.Equal([]string{"1"}, suite.keys(records1))
+	suite.NotNil(cursor1)
+
+	page.After = &TimeTrackingRecord{Key: cursor1.Key, DeviceId: cursor1.DeviceId, Timestamp: &APITime{Time: cursor1.Timestamp}}
+	records2, cursor2 := page.apply(records)
+	suite.Equal([]string{"2"}, suite.keys(records2))
+	suite.NotNil(cursor2)
+
+	page.After = &TimeTrackingRecord{Key: "2", DeviceId: "Device01", Timestamp: &APITime{Time: timestamp.Add(time.Hour)}}
+	page.Limit = 2
+	records3, cursor3 := page.apply(records)
+	suite.Equal([]string{"3"}, suite.keys(records3))
+	suite.Nil(cursor3)
+
+	start, end := timestamp.Add(-24*time.Hour), timestamp.Add(24*time.Hour)
+	start1, end1 := page.timeRange(start, end)
+	suite.Equal(start, start1)
+	suite.Equal(end, end1)
+
+	page.Sort = SORT_BY_TIMESTAMP
+	start2, _ := page.timeRange(start, end)
+	suite.Equal(timestamp.Add(time.Hour), start2)
+
+	page.Sort = SORT_BY_TIMESTAMP_DESC
+	_, end3 := page.timeRange(start, end)
+	suite.Equal(timestamp.Add(time.Hour+time.Nanosecond), end3)
+}
+
+func (suite *PaginationTestSuite) TestInvalidPageParameters() {
+
+	handler := timeTrackingRecordHandlerForTest()
+	for _, queryParams := range []map[string]string{
+		{"limit": "0"},
+		{"limit": "xxx"},
+		{"limit": "100000"},
+		{"sort": "type"},
+		{"cursor": "xxx"},
+	} {
+		request := timeTrackingRecordHandlerRequestForTest(http.MethodGet)
+		request.QueryStringParameters = queryParams
+		_, err := handler.pageFromRequest(request)
+		suite.NotNil(err, "Expected error for %+v", queryParams)
+	}
+}
+
+func (suite *PaginationTestSuite) TestSortRecords() {
+
+	timestamp := time.Date(2022, 1, 3, 8, 0, 0, 0, time.UTC)
+	records := []TimeTrackingRecord{
+		{Key: "3", DeviceId: "Device02", Timestamp: &APITime{Time: timestamp}},
+		{Key: "2", DeviceId: "Device01", Timestamp: &APITime{Time: timestamp.Add(time.Hour)}},
+		{Key: "1", DeviceId: "Device01", Timestamp: &APITime{Time: timestamp}},
+	}
+
+	sortRecords(records, SORT_BY_TIMESTAMP)
+	suite.Equal([]string{"1", "3", "2"}, suite.keys(records))
+
+	sortRecords(records, SORT_BY_TIMESTAMP_DESC)
+	suite.Equal([]string{"2", "1", "3"}, suite.keys(records))
+
+	sortRecords(records, SORT_BY_DEVICE)
+	suite.Equal([]string{"1", "2", "3"}, suite.keys(records))
+}
+
+func (suite *PaginationTestSuite) listRecords(handler *TimeTrackingRecordHandler, queryParams map[string]string) ([]TimeTrackingRecord, string) {
+	request := timeTrackingRecordHandlerRequestForTest(http.MethodGet)
+	request.QueryStringParameters = queryParams
+	res, err := handler.Process(request)
+	suite.Nil(err)
+	suite.Equal(http.StatusOK, res.StatusCode)
+
+	var records []TimeTrackingRecord
+	suite.Nil(json.Unmarshal([]byte(res.Body), &records))
+	return records, res.Headers["Link"]
+}
+
+func (suite *PaginationTestSuite) cursorFromLink(link string) string {
+	rawUrl := strings.TrimPrefix(strings.Split(link, ">")[0], "<")
+	parsedUrl, err := url.Parse(rawUrl)
+	suite.Nil(err)
+	return parsedUrl.Query().Get("cursor")
+}
+
+func (suite *PaginationTestSuite) keys(records []TimeTrackingRecord) []string {
+	keys := []string{}
+	for _, record := range records {
+		keys = append(keys, record.Key)
+	}
+	return keys
+}
@@ -11,10 +11,15 @@ import (
 // newSqsPublisher creates a new SQS message publisher with given queue and archive queue.
 func newSqsPublisher(conf config.Config, logger log.Logger) *SqsPublisher {
 	queue := conf.Get("hob.queue", config.AsStringPtr("de.tsl.hob.unknown"))
+	return newSqsPublisherForQueue(conf, *queue, logger)
+}
+
+// newSqsPublisherForQueue creates a new SQS message publisher for passed queue.
+func newSqsPublisherForQueue(conf config.Config, queue string, logger log.Logger) *SqsPublisher {
 	return &SqsPublisher{
 		logger:    logger,
 		sqsClient: sqs.NewPublisher(conf),
-		queue:     *queue,
+		queue:     queue,
 	}
 }
 
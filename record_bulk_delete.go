@@ -0,0 +1,142 @@
+package main
+
+import (
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+// DeleteRecords moves all records of passed devices within a time range, optional filtered by record type, to trash.
+// A preview of affected records has to be requested with dryRun=true at first. Its confirmation token
+// is required to delete records afterwards. Tokens are signed and bound to query parameters and affected
+// records of a preview. If affected records have changed since the preview or if a token has expired,
+// deletion is rejected with status 409. If deletion fails, ids of already deleted records are returned.
+func (handler *TimeTrackingRecordHandler) deleteRecords(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	deviceIds := deviceIdsFromRequest(request)
+	timeRangeStart, timeRangeEnd, err := handler.timeRangeFromRequest(request)
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusBadRequest), err
+	}
+
+	filter, err := handler.filterFromRequest(request)
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusBadRequest), err
+	}
+
+	dryRun := strings.ToLower(request.QueryStringParameters["dryRun"]) == "true"
+	token, hasToken := request.QueryStringParameters["token"]
+	if !dryRun && !hasToken {
+		err := errors.New("Missing confirmation token, request a preview with dryRun=true.")
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusBadRequest), err
+	}
+
+	records, err := handler.listRecords(deviceIds, timeRangeStart, timeRangeEnd, filter)
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusInternalServerError), err
+	}
+
+	result := BulkDeleteResult{DryRun: dryRun, Count: len(records), Keys: []string{}}
+	for _, record := range records {
+		result.Keys = append(result.Keys, handler.recordIds.encode(record.Key))
+	}
+
+	if dryRun {
+		result.Token = handler.bulkDeleteToken(request.QueryStringParameters, records, time.Now().Add(handler.bulkDeleteTokenTtl))
+		return handler.bulkDeleteResponse(result, http.StatusOK)
+	}
+
+	if err := handler.verifyBulkDeleteToken(token, request.QueryStringParameters, records, time.Now()); err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusConflict), err
+	}
+
+	handler.logger.Infof("Delete %d time tracking record(s) of %s", len(records), strings.Join(deviceIds, ","))
+	// Records are deleted in reverse order, because keys of some repositories depend on the position of a record.
+	deleted := []string{}
+	for idx := len(records) - 1; idx >= 0; idx-- {
+		if _, err := handler.moveToTrash(records[idx]); err != nil {
+			handler.logger.Errorf("Bulk delete failed after %d of %d record(s), reason: %s", len(deleted), len(records), err)
+			result.Deleted = deleted
+			result.Error = err.Error()
+			response, _ := handler.bulkDeleteResponse(result, http.StatusInternalServerError)
+			return response, err
+		}
+		deleted = append(deleted, handler.recordIds.encode(records[idx].Key))
+	}
+	return handler.bulkDeleteResponse(result, http.StatusOK)
+}
+
+// BulkDeleteResponse returns passed result as JSON with given status code.
+func (handler *TimeTrackingRecordHandler) bulkDeleteResponse(result BulkDeleteResult, statusCode int) (events.APIGatewayProxyResponse, error) {
+
+	responseContent, err := json.Marshal(result)
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusInternalServerError), err
+	}
+	return responseWithContent(string(responseContent), statusCode), nil
+}
+
+// BulkDeleteToken generates a confirmation token for passed query parameters and affected records, which
+// expires at given time. Tokens consist of their expiration time and a signature.
+func (handler *TimeTrackingRecordHandler) bulkDeleteToken(queryParams map[string]string, records []timetracker.TimeTrackingRecord, expires time.Time) string {
+	expiresAt := strconv.FormatInt(expires.Unix(), 10)
+	return expiresAt + "." + handler.recordIds.sign(bulkDeleteTokenPayload(expiresAt, queryParams, records))
+}
+
+// VerifyBulkDeleteToken returns with an error if passed token hasn't been generated for given query parameters
+// and affected records or if it has expired.
+func (handler *TimeTrackingRecordHandler) verifyBulkDeleteToken(token string, queryParams map[string]string, records []timetracker.TimeTrackingRecord, now time.Time) error {
+
+	tokenParts := strings.SplitN(token, ".", 2)
+	if len(tokenParts) != 2 {
+		return errors.New("Invalid confirmation token.")
+	}
+	expires, err := strconv.ParseInt(tokenParts[0], 10, 64)
+	if err != nil {
+		return errors.New("Invalid confirmation token.")
+	}
+	signature := handler.recordIds.sign(bulkDeleteTokenPayload(tokenParts[0], queryParams, records))
+	if !hmac.Equal([]byte(signature), []byte(tokenParts[1])) {
+		return errors.New("Invalid confirmation token, affected records have changed.")
+	}
+	if now.After(time.Unix(expires, 0)) {
+		return errors.New("Confirmation token has expired, request a new preview.")
+	}
+	return nil
+}
+
+// BulkDeleteTokenPayload returns the signed content of a confirmation token, expiration time, all query
+// parameters, except dryRun and token, and keys of all affected records.
+func bulkDeleteTokenPayload(expiresAt string, queryParams map[string]string, records []timetracker.TimeTrackingRecord) string {
+
+	names := []string{}
+	for name := range queryParams {
+		if name != "dryRun" && name != "token" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	lines := []string{expiresAt}
+	for _, name := range names {
+		lines = append(lines, name+"="+queryParams[name])
+	}
+	for _, record := range records {
+		lines = append(lines, record.Key)
+	}
+	return strings.Join(lines, "\n")
+}
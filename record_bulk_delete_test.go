@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+type RecordBulkDeleteTestSuite struct {
+	suite.Suite
+}
+
+func TestRecordBulkDeleteTestSuite(t *testing.T) {
+	suite.Run(t, new(RecordBulkDeleteTestSuite))
+}
+
+func (suite *RecordBulkDeleteTestSuite) TestDeleteRecords() {
+
+	handler := timeTrackingRecordHandlerForTest()
+	prepareForTest(handler.timeTrackingManager)
+	queryParams := map[string]string{"deviceid": "Device01", "from": "2022-01-01", "to": "2022-01-02"}
+
+	preview := suite.deleteRecords(handler, queryParams, map[string]string{"dryRun": "true"}, http.StatusOK)
+	suite.True(preview.DryRun)
+	suite.Equal(3, preview.Count)
+	suite.Len(preview.Keys, 3)
+	suite.NotEqual("", preview.Token)
+	suite.Len(suite.listRecords(handler), 4)
+
+	result := suite.deleteRecords(handler, queryParams, map[string]string{"token": preview.Token}, http.StatusOK)
+	suite.False(result.DryRun)
+	suite.Equal(3, result.Count)
+	suite.Equal(preview.Keys, result.Keys)
+	suite.Equal("", result.Token)
+
+	records := suite.listRecords(handler)
+	suite.Len(records, 1)
+	suite.Equal(2021, records[0].Timestamp.Year())
+}
+
+func (suite *RecordBulkDeleteTestSuite) TestDeleteRecordsByType() {
+
+	handler := timeTrackingRecordHandlerForTest()
+	prepareForTest(handler.timeTrackingManager)
+	handler.timeTrackingManager.Add(timetracker.TimeTrackingRecord{DeviceId: "Device01", Type: timetracker.ILLNESS, Timestamp: time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)})
+	queryParams := map[string]string{"deviceid": "Device01", "date": "2022-01-01", "type": "illness"}
+
+	preview := suite.deleteRecords(handler, queryParams, map[string]string{"dryRun": "true"}, http.StatusOK)
+	suite.Equal(1, preview.Count)
+
+	suite.deleteRecords(handler, queryParams, map[string]string{"token": preview.Token}, http.StatusOK)
+	records := suite.listRecords(handler)
+	suite.Len(records, 4)
+	for _, record := range records {
+		suite.Equal(timetracker.WORKDAY, record.Type)
+	}
+}
+
+func (suite *RecordBulkDeleteTestSuite) TestDeleteRecordsWithoutConfirmation() {
+
+	handler := timeTrackingRecordHandlerForTest()
+	prepareForTest(handler.timeTrackingManager)
+	queryParams := map[string]string{"deviceid": "Device01", "date": "2022-01-01"}
+
+	suite.deleteRecords(handler, queryParams, map[string]string{}, http.StatusBadRequest)
+	suite.deleteRecords(handler, queryParams, map[string]string{"token": "xxx"}, http.StatusConflict)
+	suite.deleteRecords(handler, map[string]string{"deviceid": "Device01"}, map[string]string{"dryRun": "true"}, http.StatusBadRequest)
+
+	preview := suite.deleteRecords(handler, queryParams, map[string]string{"dryRun": "true"}, http.StatusOK)
+	handler.timeTrackingManager.Add(timeTrackingRecordForTest())
+	prepareForTest(handler.timeTrackingManager)
+	suite.deleteRecords(handler, queryParams, map[string]string{"token": preview.Token}, http.StatusConflict)
+	suite.Len(suite.listRecords(handler), 8)
+}
+
+func (suite *RecordBulkDeleteTestSuite) TestConfirmationToken() {
+
+	handler := timeTrackingRecordHandlerForTest()
+	prepareForTest(handler.timeTrackingManager)
+	queryParams := map[string]string{"deviceid": "Device01", "date": "2022-01-01"}
+	preview := suite.deleteRecords(handler, queryParams, map[string]string{"dryRun": "true"}, http.StatusOK)
+
+	suite.deleteRecords(handler, map[string]string{"deviceid": "Device01", "from": "2022-01-01", "to": "2022-01-01"}, map[string]string{"token": preview.Token}, http.StatusConflict)
+	suite.deleteRecords(handler, queryParams, map[string]string{"token": "9999999999." + strings.Split(preview.Token, ".")[1]}, http.StatusConflict)
+	suite.deleteRecords(handler, queryParams, map[string]string{"token": hashRequestBody("Device01/2022-01-01/0\nDevice01/2022-01-01/1")[:32]}, http.StatusConflict)
+
+	records, err := handler.listRecords([]string{"Device01"}, time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC), RecordFilter{location: time.UTC})
+	suite.Nil(err)
+	expiredToken := handler.bulkDeleteToken(queryParams, records, time.Now().Add(-1*time.Second))
+	suite.deleteRecords(handler, queryParams, map[string]string{"token": expiredToken}, http.StatusConflict)
+	suite.Len(suite.listRecords(handler), 4)
+
+	suite.deleteRecords(handler, queryParams, map[string]string{"token": preview.Token}, http.StatusOK)
+	suite.Len(suite.listRecords(handler), 2)
+}
+
+func (suite *RecordBulkDeleteTestSuite) TestDeleteRecordsPartially() {
+
+	handler := timeTrackingRecordHandlerForTest()
+	prepareForTest(handler.timeTrackingManager)
+	handler.timeTrackingManager = &recordManagerDeleteErrorMock{TimeTrackingRepository: handler.timeTracker.(TimeTrackingRepository), allowedDeletes: 1}
+	queryParams := map[string]string{"deviceid": "Device01", "date": "2022-01-01"}
+	preview := suite.deleteRecords(handler, queryParams, map[string]string{"dryRun": "true"}, http.StatusOK)
+
+	request := timeTrackingRecordHandlerRequestForTest(http.MethodDelete)
+	request.QueryStringParameters = map[string]string{"deviceid": "Device01", "date": "2022-01-01", "token": preview.Token}
+	response, err := handler.Process(request)
+	suite.NotNil(err)
+	suite.Equal(http.StatusInternalServerError, response.StatusCode)
+
+	var result BulkDeleteResult
+	suite.Nil(json.Unmarshal([]byte(response.Body), &result))
+	suite.Equal([]string{preview.Keys[1]}, result.Deleted)
+	suite.NotEqual("", result.Error)
+	suite.Len(suite.listRecords(handler), 3)
+}
+
+func (suite *RecordBulkDeleteTestSuite) deleteRecords(handler *TimeTrackingRecordHandler, queryParams, additionalParams map[string]string, expectedStatus int) BulkDeleteResult {
+
+	request := timeTrackingRecordHandlerRequestForTest(http.MethodDelete)
+	request.QueryStringParameters = map[string]string{}
+	for key, value := range queryParams {
+		request.QueryStringParameters[key] = value
+	}
+	for key, value := range additionalParams {
+		request.QueryStringParameters[key] = value
+	}
+
+	response, err := handler.Process(request)
+	suite.Equal(expectedStatus, response.StatusCode)
+	var result BulkDeleteResult
+	if expectedStatus == http.StatusOK {
+		suite.Nil(err)
+		suite.Nil(json.Unmarshal([]byte(response.Body), &result))
+	} else {
+		suite.NotNil(err)
+	}
+	return result
+}
+
+func (suite *RecordBulkDeleteTestSuite) listRecords(handler *TimeTrackingRecordHandler) []timetracker.TimeTrackingRecord {
+	start, end, err := handler.parseTimeRange(map[string]string{"from": "2021-12-31", "to": "2022-01-02"})
+	suite.Nil(err)
+	records, err := handler.timeTracker.ListRecords("Device01", start, end)
+	suite.Nil(err)
+	return records
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	config "github.com/tommzn/go-config"
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+// NewConflictRules reads conflict rules from passed config. Duplicates and absences mixed with work
+// are rejected by default, there's no max number of clicks.
+func newConflictRules(conf config.Config) *ConflictRules {
+	return &ConflictRules{
+		duplicates:    *conf.GetAsBool("hob.records.conflicts.duplicates", config.AsBoolPtr(true)),
+		mixedAbsence:  *conf.GetAsBool("hob.records.conflicts.mixedabsence", config.AsBoolPtr(true)),
+		maxClicks:     *conf.GetAsInt("hob.records.conflicts.maxclicks", config.AsIntPtr(0)),
+		managerGroups: splitList(*conf.Get("hob.records.conflicts.managers", config.AsStringPtr(""))),
+	}
+}
+
+// Check returns all conflicts of passed record with existing records of its day.
+func (rules *ConflictRules) check(record timetracker.TimeTrackingRecord, existingRecords []timetracker.TimeTrackingRecord) []RecordConflict {
+
+	conflicts := []RecordConflict{}
+	if rules.duplicates {
+		if duplicate := findRecord(existingRecords, record.Type, record.Timestamp, nil); duplicate != nil {
+			conflicts = append(conflicts, RecordConflict{
+				Rule:    CONFLICT_DUPLICATE,
+				Message: fmt.Sprintf("There's already a %s record at %s.", record.Type, duplicate.Timestamp.UTC().Format("2006-01-02T15:04:05Z")),
+				Keys:    []string{duplicate.Key},
+			})
+		}
+	}
+
+	if rules.mixedAbsence {
+		keys := []string{}
+		for _, existingRecord := range existingRecords {
+			if isAbsence(record.Type) != isAbsence(existingRecord.Type) {
+				keys = append(keys, existingRecord.Key)
+			}
+		}
+		if len(keys) > 0 {
+			conflicts = append(conflicts, RecordConflict{
+				Rule:    CONFLICT_MIXED_ABSENCE,
+				Message: "Absences can't be mixed with work on same day.",
+				Keys:    keys,
+			})
+		}
+	}
+
+	if rules.maxClicks > 0 && record.Type == timetracker.WORKDAY {
+		keys := []string{}
+		for _, existingRecord := range existingRecords {
+			if existingRecord.Type == timetracker.WORKDAY {
+				keys = append(keys, existingRecord.Key)
+			}
+		}
+		if len(keys) >= rules.maxClicks {
+			conflicts = append(conflicts, RecordConflict{
+				Rule:    CONFLICT_MAX_CLICKS,
+				Message: fmt.Sprintf("Max number of %d workday records per day exceeded.", rules.maxClicks),
+				Keys:    keys,
+			})
+		}
+	}
+	return conflicts
+}
+
+// IsManager returns true if an authorized user of passed request is member of a manager group. Groups are
+// taken from Cognito claims, cognito:groups, or from a groups value of a custom authorizer.
+func (rules *ConflictRules) isManager(request events.APIGatewayProxyRequest) bool {
+
+	for _, group := range authorizedGroups(request) {
+		if containsString(rules.managerGroups, group) {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthorizedGroups extracts groups of an authorized user from passed request.
+func authorizedGroups(request events.APIGatewayProxyRequest) []string {
+
+	var groups interface{}
+	if claims, ok := request.RequestContext.Authorizer["claims"].(map[string]interface{}); ok {
+		groups = claims["cognito:groups"]
+	}
+	if groups == nil {
+		groups = request.RequestContext.Authorizer["groups"]
+	}
+
+	switch value := groups.(type) {
+	case string:
+		return strings.FieldsFunc(strings.Trim(value, "[]"), func(r rune) bool {
+			return r == ',' || r == ' '
+		})
+	case []interface{}:
+		values := []string{}
+		for _, group := range value {
+			values = append(values, fmt.Sprintf("%v", group))
+		}
+		return values
+	default:
+		return []string{}
+	}
+}
+
+// IsAbsence returns true for vacation and illness records.
+func isAbsence(recordType timetracker.RecordType) bool {
+	return recordType == timetracker.VACATION || recordType == timetracker.ILLNESS
+}
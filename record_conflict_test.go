@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/suite"
+	config "github.com/tommzn/go-config"
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+type RecordConflictTestSuite struct {
+	suite.Suite
+}
+
+func TestRecordConflictTestSuite(t *testing.T) {
+	suite.Run(t, new(RecordConflictTestSuite))
+}
+
+func (suite *RecordConflictTestSuite) TestCheckConflicts() {
+
+	conf, _ := config.NewStaticConfigSource("hob:\n  records:\n    conflicts:\n      maxclicks: 2\n").Load()
+	rules := newConflictRules(conf)
+	timestamp := time.Date(2022, 1, 3, 8, 0, 0, 0, time.UTC)
+	existingRecords := []timetracker.TimeTrackingRecord{
+		recordForTest(timetracker.WORKDAY, timestamp),
+		recordForTest(timetracker.WORKDAY, timestamp.Add(8*time.Hour)),
+	}
+
+	conflicts1 := rules.check(recordForTest(timetracker.WORKDAY, timestamp), existingRecords)
+	suite.Len(conflicts1, 2)
+	suite.Equal(CONFLICT_DUPLICATE, conflicts1[0].Rule)
+	suite.Equal(CONFLICT_MAX_CLICKS, conflicts1[1].Rule)
+
+	conflicts2 := rules.check(recordForTest(timetracker.VACATION, timestamp), existingRecords)
+	suite.Len(conflicts2, 1)
+	suite.Equal(CONFLICT_MIXED_ABSENCE, conflicts2[0].Rule)
+	suite.Len(conflicts2[0].Keys, 2)
+
+	suite.Len(rules.check(recordForTest(timetracker.WORKDAY, timestamp), []timetracker.TimeTrackingRecord{}), 0)
+
+	conf2, _ := config.NewStaticConfigSource("hob:\n  records:\n    conflicts:\n      duplicates: false\n      mixedabsence: false\n").Load()
+	suite.Len(newConflictRules(conf2).check(recordForTest(timetracker.VACATION, timestamp), existingRecords), 0)
+}
+
+func (suite *RecordConflictTestSuite) TestAuthorizedGroups() {
+
+	request1 := events.APIGatewayProxyRequest{}
+	request1.RequestContext.Authorizer = map[string]interface{}{"claims": map[string]interface{}{"cognito:groups": "[Users Managers]"}}
+	suite.Equal([]string{"Users", "Managers"}, authorizedGroups(request1))
+
+	request2 := events.APIGatewayProxyRequest{}
+	request2.RequestContext.Authorizer = map[string]interface{}{"groups": []interface{}{"Admins"}}
+	suite.Equal([]string{"Admins"}, authorizedGroups(request2))
+
+	suite.Len(authorizedGroups(events.APIGatewayProxyRequest{}), 0)
+
+	rules := newConflictRules(configForTest())
+	suite.True(rules.isManager(request1))
+	suite.True(rules.isManager(request2))
+	suite.False(rules.isManager(events.APIGatewayProxyRequest{}))
+}
+
+func (suite *RecordConflictTestSuite) TestAddConflictingRecord() {
+
+	handler := timeTrackingRecordHandlerForTest()
+	record := recordForTest(timetracker.WORKDAY, time.Date(2022, 1, 3, 8, 0, 0, 0, time.UTC))
+	handler.timeTrackingManager.Add(record)
+
+	response1, err1 := handler.Process(suite.requestForTest(record, nil, nil))
+	suite.NotNil(err1)
+	suite.Equal(http.StatusConflict, response1.StatusCode)
+	var conflictResponse ConflictResponse
+	suite.Nil(json.Unmarshal([]byte(response1.Body), &conflictResponse))
+	suite.Len(conflictResponse.Conflicts, 1)
+	suite.Equal(CONFLICT_DUPLICATE, conflictResponse.Conflicts[0].Rule)
+
+	response2, err2 := handler.Process(suite.requestForTest(record, map[string]string{"force": "true"}, nil))
+	suite.NotNil(err2)
+	suite.Equal(http.StatusForbidden, response2.StatusCode)
+
+	response3, err3 := handler.Process(suite.requestForTest(record, map[string]string{"force": "true"}, map[string]interface{}{"groups": "Managers"}))
+	suite.Nil(err3)
+	suite.Equal(http.StatusCreated, response3.StatusCode)
+
+	response4, err4 := handler.Process(suite.requestForTest(recordForTest(timetracker.WORKDAY, record.Timestamp.Add(time.Hour)), nil, nil))
+	suite.Nil(err4)
+	suite.Equal(http.StatusCreated, response4.StatusCode)
+}
+
+func (suite *RecordConflictTestSuite) requestForTest(record timetracker.TimeTrackingRecord, queryParams map[string]string, authorizer map[string]interface{}) events.APIGatewayProxyRequest {
+	request := timeTrackingRecordHandlerRequestForTest(http.MethodPost)
+	content, err := json.Marshal(record)
+	suite.Nil(err)
+	request.Body = string(content)
+	request.QueryStringParameters = queryParams
+	request.RequestContext.Authorizer = authorizer
+	return request
+}
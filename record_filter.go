@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+// FilterFromRequest extracts record filters from passed request. Supported filters are a comma separated
+// list of record types, e.g. type=VACATION,ILLNESS, and a time of day range, e.g. between=06:00-10:00.
+func (handler *TimeTrackingRecordHandler) filterFromRequest(request events.APIGatewayProxyRequest) (RecordFilter, error) {
+
+	location, err := handler.locationFor(request.QueryStringParameters)
+	if err != nil {
+		return RecordFilter{}, err
+	}
+
+	filter := RecordFilter{Types: []timetracker.RecordType{}, location: location}
+	if typeStr, ok := request.QueryStringParameters["type"]; ok {
+		for _, value := range splitList(typeStr) {
+			recordType, err := toRecordType(value)
+			if err != nil {
+				return filter, err
+			}
+			filter.Types = append(filter.Types, recordType)
+		}
+	}
+
+	if betweenStr, ok := request.QueryStringParameters["between"]; ok {
+		timesOfDay := strings.Split(betweenStr, "-")
+		if len(timesOfDay) != 2 {
+			return filter, errors.New("Invalid time of day range: " + betweenStr)
+		}
+		from, err := parseTimeOfDay(timesOfDay[0])
+		if err != nil {
+			return filter, err
+		}
+		to, err := parseTimeOfDay(timesOfDay[1])
+		if err != nil {
+			return filter, err
+		}
+		filter.Between = &TimeOfDayRange{From: from, To: to}
+	}
+	return filter, nil
+}
+
+// Apply returns all records which match this filter.
+func (filter RecordFilter) apply(records []timetracker.TimeTrackingRecord) []timetracker.TimeTrackingRecord {
+
+	filteredRecords := []timetracker.TimeTrackingRecord{}
+	for _, record := range records {
+		if filter.matches(record) {
+			filteredRecords = append(filteredRecords, record)
+		}
+	}
+	return filteredRecords
+}
+
+// Matches returns true if passed record has one of filtered types and has been captured within filtered time of day range.
+func (filter RecordFilter) matches(record timetracker.TimeTrackingRecord) bool {
+
+	if len(filter.Types) > 0 && !containsRecordType(filter.Types, record.Type) {
+		return false
+	}
+	if filter.Between != nil {
+		timestamp := record.Timestamp.In(filter.location)
+		return filter.Between.contains(time.Duration(timestamp.Hour())*time.Hour + time.Duration(timestamp.Minute())*time.Minute + time.Duration(timestamp.Second())*time.Second)
+	}
+	return true
+}
+
+// Contains returns true if passed time of day is within this range, including its start and excluding its end.
+// Ranges across midnight, e.g. 22:00-02:00, are supported.
+func (timeOfDayRange TimeOfDayRange) contains(timeOfDay time.Duration) bool {
+	if timeOfDayRange.From <= timeOfDayRange.To {
+		return timeOfDay >= timeOfDayRange.From && timeOfDay < timeOfDayRange.To
+	}
+	return timeOfDay >= timeOfDayRange.From || timeOfDay < timeOfDayRange.To
+}
+
+// ParseTimeOfDay parses passed value, e.g. 06:00, to a duration since midnight.
+func parseTimeOfDay(value string) (time.Duration, error) {
+	timeOfDay, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, errors.New("Invalid time of day: " + value)
+	}
+	return time.Duration(timeOfDay.Hour())*time.Hour + time.Duration(timeOfDay.Minute())*time.Minute, nil
+}
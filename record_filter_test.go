@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+type RecordFilterTestSuite struct {
+	suite.Suite
+}
+
+func TestRecordFilterTestSuite(t *testing.T) {
+	suite.Run(t, new(RecordFilterTestSuite))
+}
+
+func (suite *RecordFilterTestSuite) TestFilterRecords() {
+
+	handler := timeTrackingRecordHandlerForTest()
+	prepareForTest(handler.timeTrackingManager)
+	location := handler.defaultLocation
+	handler.timeTrackingManager.Add(timetracker.TimeTrackingRecord{DeviceId: "Device01", Type: timetracker.ILLNESS, Timestamp: time.Date(2022, 1, 2, 7, 0, 0, 0, location)})
+
+	records1 := suite.listRecords(handler, map[string]string{"deviceid": "Device01", "from": "2021-12-31", "to": "2022-01-02", "type": "ILLNESS,VACATION"})
+	suite.Len(records1, 1)
+	suite.Equal(timetracker.ILLNESS, records1[0].Type)
+
+	records2 := suite.listRecords(handler, map[string]string{"deviceid": "Device01", "from": "2021-12-31", "to": "2022-01-02", "between": "06:00-10:00"})
+	suite.Len(records2, 3)
+
+	records3 := suite.listRecords(handler, map[string]string{"deviceid": "Device01", "from": "2021-12-31", "to": "2022-01-02", "type": "workday", "between": "06:00-10:00"})
+	suite.Len(records3, 2)
+
+	request := timeTrackingRecordHandlerRequestForTest(http.MethodGet)
+	request.QueryStringParameters = map[string]string{"deviceid": "Device01", "date": "2022-01-01", "type": "vacation"}
+	res, err := handler.Process(request)
+	suite.Nil(err)
+	suite.Equal(http.StatusNotFound, res.StatusCode)
+}
+
+func (suite *RecordFilterTestSuite) TestInvalidFilters() {
+
+	handler := timeTrackingRecordHandlerForTest()
+	for _, queryParams := range []map[string]string{
+		{"type": "weekend"},
+		{"type": "xxx"},
+		{"between": "06:00"},
+		{"between": "06:00-25:00"},
+		{"between": "xxx-10:00"},
+	} {
+		request := timeTrackingRecordHandlerRequestForTest(http.MethodGet)
+		request.QueryStringParameters = queryParams
+		_, err := handler.filterFromRequest(request)
+		suite.NotNil(err, "Expected error for %+v", queryParams)
+	}
+}
+
+func (suite *RecordFilterTestSuite) TestTimeOfDayRange() {
+
+	morning := TimeOfDayRange{From: 6 * time.Hour, To: 10 * time.Hour}
+	suite.True(morning.contains(6 * time.Hour))
+	suite.True(morning.contains(9*time.Hour + 59*time.Minute))
+	suite.False(morning.contains(10 * time.Hour))
+
+	night := TimeOfDayRange{From: 22 * time.Hour, To: 2 * time.Hour}
+	suite.True(night.contains(23 * time.Hour))
+	suite.True(night.contains(1 * time.Hour))
+	suite.False(night.contains(12 * time.Hour))
+}
+
+func (suite *RecordFilterTestSuite) listRecords(handler *TimeTrackingRecordHandler, queryParams map[string]string) []TimeTrackingRecord {
+	request := timeTrackingRecordHandlerRequestForTest(http.MethodGet)
+	request.QueryStringParameters = queryParams
+	res, err := handler.Process(request)
+	suite.Nil(err)
+	suite.Equal(http.StatusOK, res.StatusCode)
+
+	var records []TimeTrackingRecord
+	suite.Nil(json.Unmarshal([]byte(res.Body), &records))
+	return records
+}
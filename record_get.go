@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// GetRecord returns a single time tracking record together with an ETag. If the ETag passed in
+// If-None-Match header still matches, status 304 is returned without content.
+func (handler *TimeTrackingRecordHandler) getRecord(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	key, ok, err := handler.recordKeyFromRequest(request)
+	if !ok {
+		err := errors.New("Missing time tracking record id.")
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusBadRequest), err
+	}
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusBadRequest), err
+	}
+	handler.logger.Debug("Receive GET for time tracking record: ", key)
+	if _, _, err := parseRecordKey(key, handler.recordKeyPrefix); err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusBadRequest), err
+	}
+
+	repositoryRecord, err := findRecordByKey(handler.timeTracker, key, handler.recordKeyPrefix)
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusInternalServerError), err
+	}
+	if repositoryRecord == nil {
+		handler.logger.Errorf("Time tracking record not found: %s", key)
+		return responseWithStatus(http.StatusNotFound), nil
+	}
+
+	record := TimeTrackingRecord{
+		Key:       handler.recordIds.encode(repositoryRecord.Key),
+		DeviceId:  repositoryRecord.DeviceId,
+		Type:      repositoryRecord.Type,
+		Timestamp: &APITime{Time: repositoryRecord.Timestamp},
+	}
+	responseContent, err := json.Marshal(record)
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusInternalServerError), err
+	}
+
+	etag := recordETag(responseContent)
+	if ifNoneMatch, ok := headerValue(request, "If-None-Match"); ok && ifNoneMatch == etag {
+		response := responseWithStatus(http.StatusNotModified)
+		response.Headers = map[string]string{"ETag": etag}
+		return response, nil
+	}
+
+	response := responseWithContent(string(responseContent), http.StatusOK)
+	response.Headers = map[string]string{"ETag": etag}
+	return response, nil
+}
+
+// RecordETag generates an ETag for passed record content.
+func recordETag(content []byte) string {
+	return "\"" + hashRequestBody(string(content))[:32] + "\""
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+type RecordGetTestSuite struct {
+	suite.Suite
+}
+
+func TestRecordGetTestSuite(t *testing.T) {
+	suite.Run(t, new(RecordGetTestSuite))
+}
+
+func (suite *RecordGetTestSuite) TestGetRecord() {
+
+	handler := timeTrackingRecordHandlerForTest()
+	prepareForTest(handler.timeTrackingManager)
+
+	request1 := timeTrackingRecordHandlerRequestForTest(http.MethodGet)
+	request1.PathParameters = map[string]string{"id": recordIdCodecForTest().encode("Device01/2022-01-01/1")}
+	response1, err1 := handler.Process(request1)
+	suite.Nil(err1)
+	suite.Equal(http.StatusOK, response1.StatusCode)
+	suite.NotEqual("", response1.Headers["ETag"])
+
+	var record TimeTrackingRecord
+	suite.Nil(json.Unmarshal([]byte(response1.Body), &record))
+	suite.Equal(recordIdCodecForTest().encode("Device01/2022-01-01/1"), record.Key)
+	suite.Equal("Device01", record.DeviceId)
+	suite.Equal(timetracker.WORKDAY, record.Type)
+	suite.Equal(17, record.Timestamp.AsTime().Hour())
+
+	request2 := timeTrackingRecordHandlerRequestForTest(http.MethodGet)
+	request2.PathParameters = request1.PathParameters
+	request2.Headers = map[string]string{"if-none-match": response1.Headers["ETag"]}
+	response2, err2 := handler.Process(request2)
+	suite.Nil(err2)
+	suite.Equal(http.StatusNotModified, response2.StatusCode)
+	suite.Equal("", response2.Body)
+
+	request3 := timeTrackingRecordHandlerRequestForTest(http.MethodGet)
+	request3.PathParameters = map[string]string{"id": recordIdCodecForTest().encode("Device01/2022-01-01/0")}
+	response3, err3 := handler.Process(request3)
+	suite.Nil(err3)
+	suite.Equal(http.StatusOK, response3.StatusCode)
+	suite.NotEqual(response1.Headers["ETag"], response3.Headers["ETag"])
+}
+
+func (suite *RecordGetTestSuite) TestGetNotExistingRecord() {
+
+	handler := timeTrackingRecordHandlerForTest()
+	prepareForTest(handler.timeTrackingManager)
+
+	request1 := timeTrackingRecordHandlerRequestForTest(http.MethodGet)
+	request1.PathParameters = map[string]string{"id": recordIdCodecForTest().encode("Device01/2022-01-01/5")}
+	response1, err1 := handler.Process(request1)
+	suite.Nil(err1)
+	suite.Equal(http.StatusNotFound, response1.StatusCode)
+
+	request2 := timeTrackingRecordHandlerRequestForTest(http.MethodGet)
+	request2.PathParameters = map[string]string{"id": recordIdCodecForTest().encode("reports/2022/01/report.xlsx")}
+	response2, err2 := handler.Process(request2)
+	suite.NotNil(err2)
+	suite.Equal(http.StatusBadRequest, response2.StatusCode)
+
+	request3 := timeTrackingRecordHandlerRequestForTest(http.MethodGet)
+	request3.PathParameters = map[string]string{"id": ""}
+	response3, err3 := handler.Process(request3)
+	suite.NotNil(err3)
+	suite.Equal(http.StatusBadRequest, response3.StatusCode)
+
+	handler.timeTracker = &timeTrackerErrorMock{}
+	response4, err4 := handler.Process(request1)
+	suite.NotNil(err4)
+	suite.Equal(http.StatusInternalServerError, response4.StatusCode)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+
+	"github.com/aws/aws-lambda-go/events"
+	secrets "github.com/tommzn/go-secrets"
+)
+
+const recordIdSecretName = "RECORD_ID_SECRET"
+
+// ErrInvalidRecordId is returned for record ids which can't be decoded.
+var errInvalidRecordId = errors.New("Invalid time tracking record id.")
+
+// NewRecordIdCodecFromSecrets creates a codec for record ids with a secret obtained from passed secrets manager.
+func newRecordIdCodecFromSecrets(secretsManager secrets.SecretsManager) (*RecordIdCodec, error) {
+
+	secret, err := secretsManager.Obtain(recordIdSecretName)
+	if err != nil || secret == nil || *secret == "" {
+		return nil, errors.New("Missing secret for time tracking record ids: " + recordIdSecretName)
+	}
+	return newRecordIdCodec(*secret)
+}
+
+// NewRecordIdCodec creates a codec for record ids. Keys for encryption and for synthetic IVs are derived
+// from passed secret, so same secret has to be used in all environments which share records.
+func newRecordIdCodec(secret string) (*RecordIdCodec, error) {
+
+	block, err := aes.NewCipher(deriveKey(secret, "hob-record-id-encryption"))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &RecordIdCodec{
+		aead:       aead,
+		ivKey:      deriveKey(secret, "hob-record-id-iv"),
+		signingKey: deriveKey(secret, "hob-signature"),
+	}, nil
+}
+
+// Encode returns an opaque, URL safe id for passed storage key. Ids are stable, same key results in same id.
+// An empty key results in an empty id.
+func (codec *RecordIdCodec) encode(key string) string {
+
+	if key == "" {
+		return ""
+	}
+	nonce := codec.syntheticIv(key)
+	sealed := codec.aead.Seal(nonce, nonce, []byte(key), nil)
+	return base64.RawURLEncoding.EncodeToString(sealed)
+}
+
+// Decode returns the storage key of passed id. Returns with an error if an id hasn't been created
+// by this codec or has been changed.
+func (codec *RecordIdCodec) decode(id string) (string, error) {
+
+	sealed, err := base64.RawURLEncoding.DecodeString(id)
+	if err != nil || len(sealed) < codec.aead.NonceSize()+codec.aead.Overhead() {
+		return "", errInvalidRecordId
+	}
+	nonce := sealed[:codec.aead.NonceSize()]
+	key, err := codec.aead.Open(nil, nonce, sealed[codec.aead.NonceSize():], nil)
+	if err != nil || !hmac.Equal(nonce, codec.syntheticIv(string(key))) {
+		return "", errInvalidRecordId
+	}
+	return string(key), nil
+}
+
+// SyntheticIv derives a nonce from passed key, which makes encryption deterministic.
+func (codec *RecordIdCodec) syntheticIv(key string) []byte {
+	mac := hmac.New(sha256.New, codec.ivKey)
+	mac.Write([]byte(key))
+	return mac.Sum(nil)[:codec.aead.NonceSize()]
+}
+
+// Sign returns a URL safe HMAC SHA256 signature of passed value.
+func (codec *RecordIdCodec) sign(value string) string {
+	mac := hmac.New(sha256.New, codec.signingKey)
+	mac.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// DeriveKey derives a 256 bit key for given purpose from passed secret.
+func deriveKey(secret, purpose string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// RecordKeyFromRequest decodes the id of a time tracking record passed as path or as query parameter.
+// Returns false if there's no id and an error if passed id is invalid.
+func (handler *TimeTrackingRecordHandler) recordKeyFromRequest(request events.APIGatewayProxyRequest) (string, bool, error) {
+
+	id, ok := request.PathParameters["id"]
+	if !ok || id == "" {
+		id, ok = request.QueryStringParameters["id"]
+	}
+	if !ok || id == "" {
+		return "", false, nil
+	}
+	key, err := handler.recordIds.decode(id)
+	return key, true, err
+}
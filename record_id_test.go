@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/base64"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/suite"
+	secrets "github.com/tommzn/go-secrets"
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+type RecordIdTestSuite struct {
+	suite.Suite
+}
+
+func TestRecordIdTestSuite(t *testing.T) {
+	suite.Run(t, new(RecordIdTestSuite))
+}
+
+func (suite *RecordIdTestSuite) TestEncodeAndDecode() {
+
+	codec := recordIdCodecForTest()
+	key := "timetracker/Device01/2022/01/03/faa5260b-01d3-41ed-bde9-8eb7bbfe9c0a"
+
+	id := codec.encode(key)
+	suite.Equal(id, codec.encode(key))
+	suite.NotEqual(id, codec.encode("timetracker/Device01/2022/01/03/faa5260b-01d3-41ed-bde9-8eb7bbfe9c0b"))
+	suite.NotContains(id, "Device01")
+	suite.NotContains(id, "/")
+	suite.NotContains(id, "=")
+	suite.Equal("", codec.encode(""))
+
+	decodedKey, err := codec.decode(id)
+	suite.Nil(err)
+	suite.Equal(key, decodedKey)
+
+	otherCodec, _ := newRecordIdCodec("another-secret")
+	_, err1 := otherCodec.decode(id)
+	suite.Equal(errInvalidRecordId, err1)
+
+	sealed, _ := base64.RawURLEncoding.DecodeString(id)
+	sealed[len(sealed)-1] ^= 0x01
+	_, err2 := codec.decode(base64.RawURLEncoding.EncodeToString(sealed))
+	suite.Equal(errInvalidRecordId, err2)
+
+	for _, invalidId := range []string{"", "xxx", "Device01%2F2022-01-03%2F0", base64.RawURLEncoding.EncodeToString([]byte(key))} {
+		_, err := codec.decode(invalidId)
+		suite.Equal(errInvalidRecordId, err, invalidId)
+	}
+}
+
+func (suite *RecordIdTestSuite) TestCodecFromSecrets() {
+
+	codec, err := newRecordIdCodecFromSecrets(secrets.NewStaticSecretsManager(map[string]string{recordIdSecretName: "unittest-secret"}))
+	suite.Nil(err)
+	suite.Equal(recordIdCodecForTest().encode("Device01/2022-01-03/0"), codec.encode("Device01/2022-01-03/0"))
+
+	_, err2 := newRecordIdCodecFromSecrets(secrets.NewStaticSecretsManager(map[string]string{}))
+	suite.NotNil(err2)
+}
+
+func (suite *RecordIdTestSuite) TestRecordIdsInPathAndQuery() {
+
+	handler := timeTrackingRecordHandlerForTest()
+	record, _ := handler.timeTrackingManager.Add(recordForTest(timetracker.WORKDAY, time.Date(2022, 1, 3, 8, 0, 0, 0, time.UTC)))
+	id := handler.recordIds.encode(record.Key)
+
+	response1, err1 := handler.Process(events.APIGatewayProxyRequest{Resource: "/timetrackingrecords/{id}", HTTPMethod: http.MethodGet, PathParameters: map[string]string{"id": id}})
+	suite.Nil(err1)
+	suite.Equal(http.StatusOK, response1.StatusCode)
+	suite.Contains(response1.Body, id)
+
+	response2, err2 := handler.Process(events.APIGatewayProxyRequest{Resource: "/timetrackingrecords/{id}", HTTPMethod: http.MethodGet, PathParameters: map[string]string{"id": record.Key}})
+	suite.NotNil(err2)
+	suite.Equal(http.StatusBadRequest, response2.StatusCode)
+
+	response3, err3 := handler.Process(events.APIGatewayProxyRequest{Resource: "/timetrackingrecords", HTTPMethod: http.MethodDelete, QueryStringParameters: map[string]string{"id": "xxx"}})
+	suite.NotNil(err3)
+	suite.Equal(http.StatusBadRequest, response3.StatusCode)
+
+	response4, err4 := handler.Process(events.APIGatewayProxyRequest{Resource: "/timetrackingrecords/{id}", HTTPMethod: http.MethodDelete, PathParameters: map[string]string{"id": id}})
+	suite.Nil(err4)
+	suite.Equal(http.StatusOK, response4.StatusCode)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+// ParseRecordKey validates passed record key and extracts its device id and day. Supported are keys of
+// records persisted in S3, <basepath>/<deviceid>/<yyyy>/<mm>/<dd>/<id>, and keys of local repositories,
+// <deviceid>/<yyyy-mm-dd>/<index>. S3 keys have to be located in passed base path of the time tracker.
+func parseRecordKey(key string, basePath *string) (string, time.Time, error) {
+
+	prefix := "/"
+	if basePath != nil {
+		prefix = *basePath + "/"
+	}
+	if strings.HasPrefix(key, prefix) {
+		keyParts := strings.Split(strings.TrimPrefix(key, prefix), "/")
+		if len(keyParts) == 5 && isValidKeyPart(keyParts[0]) && isValidKeyPart(keyParts[4]) {
+			if day, err := time.Parse("2006/01/02", strings.Join(keyParts[1:4], "/")); err == nil {
+				return keyParts[0], day, nil
+			}
+		}
+	}
+
+	keyParts := strings.Split(key, "/")
+	if len(keyParts) == 3 && isValidKeyPart(keyParts[0]) && isValidKeyPart(keyParts[2]) {
+		if day, err := time.Parse(dateLayout, keyParts[1]); err == nil {
+			return keyParts[0], day, nil
+		}
+	}
+	return "", time.Time{}, errors.New("Invalid time tracking record key.")
+}
+
+// IsValidKeyPart returns true if passed value can be used as a segment of a record key.
+func isValidKeyPart(value string) bool {
+	return value != "" && value != "." && value != ".."
+}
+
+// FindRecordByKey looks up a time tracking record by its key. Returns nil if there's no such record.
+func findRecordByKey(timeTracker timetracker.TimeTracker, key string, basePath *string) (*timetracker.TimeTrackingRecord, error) {
+
+	deviceId, day, err := parseRecordKey(key, basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := dayRange(day)
+	records, err := timeTracker.ListRecords(deviceId, start, end)
+	if err != nil {
+		return nil, err
+	}
+	for idx, record := range records {
+		if record.Key == key {
+			return &records[idx], nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	config "github.com/tommzn/go-config"
+)
+
+type RecordKeyTestSuite struct {
+	suite.Suite
+}
+
+func TestRecordKeyTestSuite(t *testing.T) {
+	suite.Run(t, new(RecordKeyTestSuite))
+}
+
+func (suite *RecordKeyTestSuite) TestParseRecordKey() {
+
+	basePath := config.AsStringPtr("timetracking")
+
+	deviceId1, day1, err1 := parseRecordKey("Device01/2022-01-03/0", basePath)
+	suite.Nil(err1)
+	suite.Equal("Device01", deviceId1)
+	suite.Equal(time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC), day1)
+
+	deviceId2, day2, err2 := parseRecordKey("timetracking/Device01/2022/01/03/8b5a7e4c-7c5c-4b5e-9d1a-6d0b2c7e5f10", basePath)
+	suite.Nil(err2)
+	suite.Equal("Device01", deviceId2)
+	suite.Equal(time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC), day2)
+
+	deviceId3, _, err3 := parseRecordKey("/Device01/2022/01/03/8b5a7e4c-7c5c-4b5e-9d1a-6d0b2c7e5f10", nil)
+	suite.Nil(err3)
+	suite.Equal("Device01", deviceId3)
+}
+
+func (suite *RecordKeyTestSuite) TestParseInvalidRecordKey() {
+
+	basePath := config.AsStringPtr("timetracking")
+	for _, key := range []string{
+		"",
+		"Device01",
+		"Device01/xxx/0",
+		"Device01/2022-01-03/",
+		"reports/Device01/2022/01/03/8b5a7e4c",
+		"timetracking/../Device01/2022/01/03/8b5a7e4c",
+		"timetracking/Device01/2022/01/03/8b5a7e4c/xxx",
+		"timetracking/Device01/2022/13/03/8b5a7e4c",
+		"timetracking/Device01/2022/01/03/..",
+		"/Device01/2022/01/03/8b5a7e4c",
+	} {
+		_, _, err := parseRecordKey(key, basePath)
+		suite.NotNil(err, "Expected error for %s", key)
+	}
+}
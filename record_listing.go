@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+const (
+	failedDevicesHeader = "X-Failed-Devices"
+	deviceErrorsHeader  = "X-Device-Errors"
+)
+
+// ErrListTimeout is reported for devices whose records haven't been listed before the list timeout.
+var errListTimeout = errors.New("Listing records timed out.")
+
+// ErrListFailed is passed to clients for devices whose records can't be listed.
+var errListFailed = errors.New("Unable to list records.")
+
+// ListDeadlineReserve is the duration kept from the deadline of an invocation to send a response.
+const listDeadlineReserve = time.Second
+
+// SetInvocationContext passes the context of current Lambda invocation. Record listings are cancelled
+// when it's done, and they're limited to its deadline.
+func (handler *TimeTrackingRecordHandler) SetInvocationContext(ctx context.Context) {
+	handler.ctx = ctx
+}
+
+// ListRecordsOfDevices lists records of all passed devices in parallel. At most as much devices as configured
+// workers are listed at same time. Results are returned in same order as passed device ids. Devices which
+// haven't been listed within the list timeout, or before the deadline of current invocation, are reported with
+// an error. Listing of pending devices is cancelled and running listings stop with their next chunk.
+func (handler *TimeTrackingRecordHandler) listRecordsOfDevices(deviceIds []string, start, end time.Time, filter RecordFilter) []DeviceRecords {
+
+	results := make([]DeviceRecords, len(deviceIds))
+	for idx, deviceId := range deviceIds {
+		results[idx] = DeviceRecords{DeviceId: deviceId, Records: []timetracker.TimeTrackingRecord{}, Err: errListTimeout}
+	}
+	if len(deviceIds) == 0 {
+		return results
+	}
+
+	timeout := handler.listTimeoutOf(handler.ctx)
+	ctx, cancel := context.WithTimeout(handler.invocationContext(), timeout)
+	defer cancel()
+
+	type indexedResult struct {
+		idx    int
+		result DeviceRecords
+	}
+	// Channel is buffered, so workers finishing after the list timeout don't block.
+	done := make(chan indexedResult, len(deviceIds))
+
+	workers := handler.listWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	go func() {
+		semaphore := make(chan struct{}, workers)
+		for idx, deviceId := range deviceIds {
+			select {
+			case <-ctx.Done():
+				return
+			case semaphore <- struct{}{}:
+			}
+			go func(idx int, deviceId string) {
+				defer func() { <-semaphore }()
+				records, err := handler.listRecordsOfDevice(ctx, deviceId, start, end, filter)
+				done <- indexedResult{idx: idx, result: DeviceRecords{DeviceId: deviceId, Records: records, Err: err}}
+			}(idx, deviceId)
+		}
+	}()
+
+	for received := 0; received < len(deviceIds); received++ {
+		select {
+		case indexed := <-done:
+			results[indexed.idx] = indexed.result
+		case <-ctx.Done():
+			handler.logger.Errorf("Listing records timed out after %s, %d of %d device(s) listed", timeout, received, len(deviceIds))
+			return results
+		}
+	}
+	return results
+}
+
+// InvocationContext returns the context of current invocation or a background context if there's no invocation context.
+func (handler *TimeTrackingRecordHandler) invocationContext() context.Context {
+	if handler.ctx == nil {
+		return context.Background()
+	}
+	return handler.ctx
+}
+
+// ListTimeoutOf returns the configured list timeout, shortened to the deadline of passed context,
+// without a reserve to send a response.
+func (handler *TimeTrackingRecordHandler) listTimeoutOf(ctx context.Context) time.Duration {
+
+	timeout := handler.listTimeout
+	if ctx == nil {
+		return timeout
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline) - listDeadlineReserve; remaining < timeout {
+			timeout = remaining
+		}
+	}
+	return timeout
+}
+
+// ListRecordsOfDevice returns all records of a single device within given half-open time range, [start, end),
+// which match passed filter. Records are listed in chunks, listing stops with errListTimeout if passed context is done.
+func (handler *TimeTrackingRecordHandler) listRecordsOfDevice(ctx context.Context, deviceId string, start, end time.Time, filter RecordFilter) ([]timetracker.TimeTrackingRecord, error) {
+
+	records := []timetracker.TimeTrackingRecord{}
+	handler.logger.Debugf("Looking for records: deviceid: %s, range %s/%s", deviceId, start.Format(time.RFC3339), end.Format(time.RFC3339))
+	for chunkStart := start; chunkStart.Before(end); {
+		if ctx.Err() != nil {
+			return records, errListTimeout
+		}
+		chunkEnd := end
+		if handler.listChunk > 0 && chunkStart.Add(handler.listChunk).Before(end) {
+			chunkEnd = chunkStart.Add(handler.listChunk)
+		}
+		recordsForDevice, err := handler.timeTracker.ListRecords(deviceId, chunkStart, chunkEnd)
+		if err != nil {
+			return records, err
+		}
+		for _, record := range filter.apply(recordsForDevice) {
+			if !record.Timestamp.Before(chunkStart) && record.Timestamp.Before(chunkEnd) {
+				records = append(records, record)
+			}
+		}
+		chunkStart = chunkEnd
+	}
+	handler.logger.Debugf("Found %d record(s) fordeviceid: %s", len(records), deviceId)
+	return records, nil
+}
+
+// DeviceErrors returns error messages of all failed devices of passed results.
+func deviceErrors(results []DeviceRecords) map[string]string {
+	errorMessages := make(map[string]string)
+	for _, result := range results {
+		if result.Err != nil {
+			errorMessages[result.DeviceId] = result.Err.Error()
+		}
+	}
+	return errorMessages
+}
+
+// AllTimedOut returns true if listing of all devices of passed results timed out.
+func allTimedOut(results []DeviceRecords) bool {
+	for _, result := range results {
+		if result.Err != errListTimeout {
+			return false
+		}
+	}
+	return true
+}
+
+// PublicDeviceErrors returns generic error messages of all failed devices of passed results by device id.
+// Internal error messages are not passed to clients, they're logged, only.
+func publicDeviceErrors(results []DeviceRecords) map[string]string {
+	errorMessages := make(map[string]string)
+	for _, result := range results {
+		switch {
+		case result.Err == errListTimeout:
+			errorMessages[result.DeviceId] = errListTimeout.Error()
+		case result.Err != nil:
+			errorMessages[result.DeviceId] = errListFailed.Error()
+		}
+	}
+	return errorMessages
+}
+
+// WithDeviceErrors adds ids and error messages of failed devices as headers to passed response.
+func withDeviceErrors(response events.APIGatewayProxyResponse, results []DeviceRecords) events.APIGatewayProxyResponse {
+
+	failedDevices := []string{}
+	for _, result := range results {
+		if result.Err != nil {
+			failedDevices = append(failedDevices, result.DeviceId)
+		}
+	}
+	if len(failedDevices) == 0 {
+		return response
+	}
+
+	if response.Headers == nil {
+		response.Headers = make(map[string]string)
+	}
+	response.Headers[failedDevicesHeader] = strings.Join(failedDevices, ",")
+	if content, err := json.Marshal(publicDeviceErrors(results)); err == nil {
+		response.Headers[deviceErrorsHeader] = string(content)
+	}
+	return response
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+type RecordListingTestSuite struct {
+	suite.Suite
+}
+
+func TestRecordListingTestSuite(t *testing.T) {
+	suite.Run(t, new(RecordListingTestSuite))
+}
+
+func (suite *RecordListingTestSuite) TestListRecordsInParallel() {
+
+	handler, mock, deviceIds := suite.handlerForTest(10)
+	handler.listWorkers = 3
+	for idx, deviceId := range deviceIds {
+		mock.delays[deviceId] = time.Duration(10-idx) * 5 * time.Millisecond
+	}
+
+	results := handler.listRecordsOfDevices(deviceIds, suite.start(), suite.end(), RecordFilter{location: time.UTC})
+	suite.Len(results, 10)
+	for idx, result := range results {
+		suite.Nil(result.Err)
+		suite.Equal(deviceIds[idx], result.DeviceId)
+		suite.Len(result.Records, 1)
+		suite.Equal(deviceIds[idx], result.Records[0].DeviceId)
+	}
+	suite.True(mock.maxRunning <= 3)
+	suite.True(mock.maxRunning > 1)
+}
+
+func (suite *RecordListingTestSuite) TestListRecordsWithErrors() {
+
+	handler, mock, deviceIds := suite.handlerForTest(3)
+	mock.failedDevices[deviceIds[1]] = true
+
+	results := handler.listRecordsOfDevices(deviceIds, suite.start(), suite.end(), RecordFilter{location: time.UTC})
+	suite.Nil(results[0].Err)
+	suite.NotNil(results[1].Err)
+	suite.Nil(results[2].Err)
+
+	_, err := handler.listRecords(deviceIds, suite.start(), suite.end(), RecordFilter{location: time.UTC})
+	suite.NotNil(err)
+
+	request := timeTrackingRecordHandlerRequestForTest(http.MethodGet)
+	request.QueryStringParameters = map[string]string{"deviceids": "Device00,Device01,Device02", "date": "2022-01-03"}
+	response1, err1 := handler.Process(request)
+	suite.Nil(err1)
+	suite.Equal(http.StatusOK, response1.StatusCode)
+	suite.Equal("Device01", response1.Headers[failedDevicesHeader])
+	var errorMessages map[string]string
+	suite.Nil(json.Unmarshal([]byte(response1.Headers[deviceErrorsHeader]), &errorMessages))
+	suite.Equal("Unable to list records.", errorMessages["Device01"])
+	var records []TimeTrackingRecord
+	suite.Nil(json.Unmarshal([]byte(response1.Body), &records))
+	suite.Len(records, 2)
+
+	request.QueryStringParameters["limit"] = "1"
+	response1_1, err1_1 := handler.Process(request)
+	suite.Nil(err1_1)
+	suite.Nil(json.Unmarshal([]byte(response1_1.Body), &records))
+	suite.Len(records, 1)
+	suite.Equal("", response1_1.Headers["Link"])
+	mock.failedDevices[deviceIds[1]] = false
+	response1_2, err1_2 := handler.Process(request)
+	suite.Nil(err1_2)
+	suite.NotEqual("", response1_2.Headers["Link"])
+	mock.failedDevices[deviceIds[1]] = true
+
+	request.QueryStringParameters = map[string]string{"deviceid": "Device01", "date": "2022-01-03"}
+	response2, err2 := handler.Process(request)
+	suite.NotNil(err2)
+	suite.Equal(http.StatusInternalServerError, response2.StatusCode)
+	suite.Equal(errListFailed, err2)
+	suite.NotContains(response2.Body, "AccessDenied")
+	suite.Equal("Device01", response2.Headers[failedDevicesHeader])
+}
+
+func (suite *RecordListingTestSuite) TestListRecordsTimeout() {
+
+	handler, mock, deviceIds := suite.handlerForTest(4)
+	handler.listWorkers = 1
+	handler.listTimeout = 50 * time.Millisecond
+	mock.delays[deviceIds[1]] = 200 * time.Millisecond
+
+	results := handler.listRecordsOfDevices(deviceIds, suite.start(), suite.end(), RecordFilter{location: time.UTC})
+	suite.Nil(results[0].Err)
+	for _, result := range results[1:] {
+		suite.Equal(errListTimeout, result.Err)
+	}
+
+	request := timeTrackingRecordHandlerRequestForTest(http.MethodGet)
+	request.QueryStringParameters = map[string]string{"deviceid": "Device01", "date": "2022-01-03"}
+	response, err := handler.Process(request)
+	suite.NotNil(err)
+	suite.Equal(http.StatusGatewayTimeout, response.StatusCode)
+}
+
+func (suite *RecordListingTestSuite) TestListRecordsUntilInvocationDeadline() {
+
+	handler, mock, deviceIds := suite.handlerForTest(2)
+	mock.delays[deviceIds[1]] = 500 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), listDeadlineReserve+50*time.Millisecond)
+	defer cancel()
+	handler.SetInvocationContext(ctx)
+	suite.True(handler.listTimeoutOf(ctx) <= 50*time.Millisecond)
+
+	startTime := time.Now()
+	results := handler.listRecordsOfDevices(deviceIds, suite.start(), suite.end(), RecordFilter{location: time.UTC})
+	suite.True(time.Since(startTime) < 500*time.Millisecond)
+	suite.Nil(results[0].Err)
+	suite.Equal(errListTimeout, results[1].Err)
+
+	handler.SetInvocationContext(context.Background())
+	suite.Equal(handler.listTimeout, handler.listTimeoutOf(handler.ctx))
+}
+
+func (suite *RecordListingTestSuite) TestListRecordsInChunks() {
+
+	handler, mock, deviceIds := suite.handlerForTest(1)
+	handler.listChunk = 24 * time.Hour
+	mock.TimeTracker.(*timetracker.LocaLRepository).Add(timetracker.TimeTrackingRecord{DeviceId: deviceIds[0], Type: timetracker.WORKDAY, Timestamp: time.Date(2022, 1, 4, 0, 0, 0, 0, time.UTC)})
+
+	records, err := handler.listRecordsOfDevice(context.Background(), deviceIds[0], suite.start(), suite.start().AddDate(0, 0, 3), RecordFilter{location: time.UTC})
+	suite.Nil(err)
+	suite.Len(records, 2)
+	suite.Equal(3, mock.calls)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err2 := handler.listRecordsOfDevice(ctx, deviceIds[0], suite.start(), suite.start().AddDate(0, 0, 3), RecordFilter{location: time.UTC})
+	suite.Equal(errListTimeout, err2)
+	suite.Equal(3, mock.calls)
+}
+
+func (suite *RecordListingTestSuite) handlerForTest(deviceCount int) (*TimeTrackingRecordHandler, *timeTrackerListMock, []string) {
+
+	repo := timetracker.NewLocaLRepository()
+	deviceIds := []string{}
+	for idx := 0; idx < deviceCount; idx++ {
+		deviceId := fmt.Sprintf("Device%02d", idx)
+		deviceIds = append(deviceIds, deviceId)
+		repo.Add(timetracker.TimeTrackingRecord{DeviceId: deviceId, Type: timetracker.WORKDAY, Timestamp: time.Date(2022, 1, 3, 8, 0, 0, 0, time.UTC)})
+	}
+	mock := &timeTrackerListMock{TimeTracker: repo, delays: make(map[string]time.Duration), failedDevices: make(map[string]bool)}
+	handler := timeTrackingRecordHandlerForTest()
+	handler.timeTracker = mock
+	return handler, mock, deviceIds
+}
+
+func (suite *RecordListingTestSuite) start() time.Time {
+	return time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC)
+}
+
+func (suite *RecordListingTestSuite) end() time.Time {
+	return time.Date(2022, 1, 4, 0, 0, 0, 0, time.UTC)
+}
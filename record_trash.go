@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	config "github.com/tommzn/go-config"
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+// MaxTrashExpiry is used to invert expiry times of trash entries, as unix timestamp.
+const maxTrashExpiry = 9999999999
+
+// NewRecordTrash creates a trash which persists deleted records in the configured S3 bucket. Trash is shared
+// by all containers, so deleted records survive cold starts and are visible to the purge job.
+// Returns with an error if there's no S3 bucket.
+func newRecordTrash(conf config.Config) (RecordTrash, error) {
+
+	awsConf, err := getAwsConfig(conf)
+	if err != nil {
+		return nil, err
+	}
+	basePath := conf.Get("hob.trash.basepath", config.AsStringPtr("trash"))
+	return newS3RecordTrash(newS3Client(awsConf.region), *awsConf.bucket, *basePath), nil
+}
+
+// NewTrashEntry creates a trash entry for passed record which expires after given retention. Ids of trash entries
+// start with the device id of a record, followed by the inverted expiry time, so entries of a device are listed
+// with a single prefix, most recent deleted entries first, and expired entries can be detected without downloading them.
+func newTrashEntry(record timetracker.TimeTrackingRecord, deleted time.Time, retention time.Duration) TrashEntry {
+	expires := deleted.Add(retention)
+	return TrashEntry{
+		Id:      fmt.Sprintf("%s/%010d-%s", record.DeviceId, maxTrashExpiry-expires.Unix(), hashRequestBody(record.Key + "\n" + deleted.Format(time.RFC3339Nano))[:16]),
+		Record:  record,
+		Deleted: deleted,
+		Expires: expires,
+	}
+}
+
+// TrashEntryExpires returns the expiry time encoded in passed trash id.
+func trashEntryExpires(id string) (time.Time, error) {
+	parts := strings.Split(id[strings.LastIndex(id, "/")+1:], "-")
+	inverted, err := strconv.ParseInt(parts[0], 10, 64)
+	if len(parts) != 2 || err != nil {
+		return time.Time{}, errors.New("Invalid trash id: " + id)
+	}
+	return time.Unix(maxTrashExpiry-inverted, 0), nil
+}
+
+// IsTrashEntryExpired returns true if the trash entry for passed id has expired. Entries with invalid ids are expired.
+func isTrashEntryExpired(id string, now time.Time) bool {
+	expires, err := trashEntryExpires(id)
+	return err != nil || expires.Before(now)
+}
+
+// MoveToTrash puts passed record into trash and deletes it afterwards. If a record can't be deleted,
+// it's removed from trash again.
+func (handler *TimeTrackingRecordHandler) moveToTrash(record timetracker.TimeTrackingRecord) (TrashEntry, error) {
+
+	entry := newTrashEntry(record, time.Now(), handler.trashRetention)
+	if err := handler.trash.Put(entry); err != nil {
+		return entry, err
+	}
+	if err := handler.timeTrackingManager.Delete(record.Key); err != nil {
+		if removeErr := handler.trash.Remove(entry.Id); removeErr != nil {
+			handler.logger.Error(removeErr)
+		}
+		return entry, err
+	}
+	handler.logger.Infof("Moved time tracking record %s to trash: %s", record.Key, entry.Id)
+	return entry, nil
+}
+
+// RestoreFromTrash adds the record of passed trash entry again and removes the entry from trash.
+// Restored records get a new key.
+func (handler *TimeTrackingRecordHandler) restoreFromTrash(entry TrashEntry) (timetracker.TimeTrackingRecord, error) {
+
+	record := entry.Record
+	record.Key = ""
+	restoredRecord, err := handler.timeTrackingManager.Add(record)
+	if err != nil {
+		return restoredRecord, err
+	}
+	if err := handler.trash.Remove(entry.Id); err != nil {
+		handler.logger.Error(err)
+	}
+	handler.logger.Infof("Restored time tracking record %s from trash: %s", restoredRecord.Key, entry.Id)
+	return restoredRecord, nil
+}
+
+// PublicTrashEntry returns passed entry with opaque ids of the entry and of its record.
+func (handler *TimeTrackingRecordHandler) publicTrashEntry(entry TrashEntry) TrashEntry {
+	entry.Id = handler.recordIds.encode(entry.Id)
+	entry.Record.Key = handler.recordIds.encode(entry.Record.Key)
+	return entry
+}
+
+// NewS3RecordTrash returns a trash which persists deleted records in given bucket.
+func newS3RecordTrash(s3Client s3iface.S3API, bucket, basePath string) *S3RecordTrash {
+	return &S3RecordTrash{
+		s3Client: s3Client,
+		bucket:   bucket,
+		basePath: basePath,
+	}
+}
+
+// Put uploads passed entry.
+func (trash *S3RecordTrash) Put(entry TrashEntry) error {
+
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = trash.s3Client.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String(trash.bucket),
+		Key:    trash.objectKey(entry.Id),
+		Body:   bytes.NewReader(content),
+	})
+	return err
+}
+
+// Get downloads an entry for passed id. Returns nil if there's no such entry.
+func (trash *S3RecordTrash) Get(id string) (*TrashEntry, error) {
+	return trash.download(trash.objectKey(id))
+}
+
+// List downloads at most limit entries of passed device, or of all devices if device id is empty, which are not
+// expired. Entries are ordered by device id, most recent deleted first, and start after the entry of passed id.
+func (trash *S3RecordTrash) List(deviceId, after string, limit int, now time.Time) ([]TrashEntry, error) {
+
+	prefix := trash.prefix()
+	if deviceId != "" {
+		prefix += deviceId + "/"
+	}
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(trash.bucket),
+		Prefix: aws.String(prefix),
+	}
+	if after != "" {
+		input.StartAfter = trash.objectKey(after)
+	}
+
+	objectKeys := []*string{}
+	err := trash.s3Client.ListObjectsV2Pages(input, func(output *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, object := range output.Contents {
+			if !isTrashEntryExpired(trash.idOf(*object.Key), now) {
+				objectKeys = append(objectKeys, object.Key)
+			}
+		}
+		return len(objectKeys) < limit
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(objectKeys) > limit {
+		objectKeys = objectKeys[:limit]
+	}
+
+	entries := []TrashEntry{}
+	for _, objectKey := range objectKeys {
+		entry, err := trash.download(objectKey)
+		if err != nil {
+			return nil, err
+		}
+		if entry != nil {
+			entries = append(entries, *entry)
+		}
+	}
+	return entries, nil
+}
+
+// Expired returns ids of all expired entries. Expiry is taken from object keys, entries are not downloaded.
+func (trash *S3RecordTrash) Expired(now time.Time) ([]string, error) {
+
+	ids := []string{}
+	err := trash.s3Client.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+		Bucket: aws.String(trash.bucket),
+		Prefix: aws.String(trash.prefix()),
+	}, func(output *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, object := range output.Contents {
+			if id := trash.idOf(*object.Key); isTrashEntryExpired(id, now) {
+				ids = append(ids, id)
+			}
+		}
+		return true
+	})
+	return ids, err
+}
+
+// Remove deletes an entry from trash.
+func (trash *S3RecordTrash) Remove(id string) error {
+	_, err := trash.s3Client.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(trash.bucket),
+		Key:    trash.objectKey(id),
+	})
+	return err
+}
+
+// Download fetches and decodes an entry from passed object key.
+func (trash *S3RecordTrash) download(objectKey *string) (*TrashEntry, error) {
+
+	output, err := trash.s3Client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(trash.bucket),
+		Key:    objectKey,
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == s3.ErrCodeNoSuchKey {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer output.Body.Close()
+
+	content, err := io.ReadAll(output.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry TrashEntry
+	if err := json.Unmarshal(content, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Prefix returns the object key prefix of all trash entries.
+func (trash *S3RecordTrash) prefix() string {
+	if trash.basePath == "" {
+		return ""
+	}
+	return strings.TrimSuffix(trash.basePath, "/") + "/"
+}
+
+// IdOf returns the trash id of passed object key.
+func (trash *S3RecordTrash) idOf(objectKey string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(objectKey, trash.prefix()), ".json")
+}
+
+// ObjectKey returns a S3 object key for passed trash id.
+func (trash *S3RecordTrash) objectKey(id string) *string {
+	return aws.String(trash.prefix() + id + ".json")
+}
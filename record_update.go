@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+// UpdateRecord replaces a time tracking record. PUT requires a complete record, PATCH applies all passed
+// values to an existing record. Changes are validated, and checked for conflicts, with same rules used for
+// new records. The replaced record itself is ignored by conflict checks.
+func (handler *TimeTrackingRecordHandler) updateRecord(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	key, ok, err := handler.recordKeyFromRequest(request)
+	if !ok {
+		err := errors.New("Missing time tracking record id.")
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusBadRequest), err
+	}
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusBadRequest), err
+	}
+
+	var patch TimeTrackingRecordPatch
+	if err := json.Unmarshal([]byte(request.Body), &patch); err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusBadRequest), err
+	}
+
+	if _, _, err := parseRecordKey(key, handler.recordKeyPrefix); err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusBadRequest), err
+	}
+
+	existingRecord, err := findRecordByKey(handler.timeTracker, key, handler.recordKeyPrefix)
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusInternalServerError), err
+	}
+	if existingRecord == nil {
+		err := errors.New("Time tracking record not found.")
+		handler.logger.Error(err, " ", key)
+		return errorResponseWithStatus(err, http.StatusNotFound), err
+	}
+
+	var record timetracker.TimeTrackingRecord
+	if request.HTTPMethod == http.MethodPatch {
+		record = patch.applyTo(*existingRecord)
+	} else {
+		record = patch.applyTo(timetracker.TimeTrackingRecord{})
+	}
+
+	serverTime := time.Now()
+	record, err = handler.validateRecord(record)
+	if err != nil {
+		handler.logger.Error(err)
+		return withServerTime(errorResponseWithStatus(err, http.StatusBadRequest), serverTime), err
+	}
+	if response, err := handler.checkConflictsOfRequest(request, record, existingRecord.Key); err != nil {
+		return response, err
+	}
+	handler.logger.Debugf("Replace time tracking record %s with %+v", key, record)
+
+	newRecord, err := handler.replaceRecord(*existingRecord, record)
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusInternalServerError), err
+	}
+
+	newRecord.Key = handler.recordIds.encode(newRecord.Key)
+	responseContent, err := json.Marshal(newRecord)
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusInternalServerError), err
+	}
+	return withServerTime(responseWithContent(string(responseContent), http.StatusOK), serverTime), nil
+}
+
+// ReplaceRecord moves the record with given key to trash and adds passed record afterwards. If the new record
+// can't be added, the existing record will be restored from trash, so an update is never applied partially.
+// Restored records get a new key, so the returned error contains the new id of a restored record, or the trash
+// id of the existing record if it couldn't be restored.
+func (handler *TimeTrackingRecordHandler) replaceRecord(existingRecord, record timetracker.TimeTrackingRecord) (timetracker.TimeTrackingRecord, error) {
+
+	entry, err := handler.moveToTrash(existingRecord)
+	if err != nil {
+		return record, err
+	}
+
+	newRecord, err := handler.timeTrackingManager.Add(record)
+	if err != nil {
+		restoredRecord, restoreErr := handler.restoreFromTrash(entry)
+		if restoreErr != nil {
+			handler.logger.Errorf("Unable to restore time tracking record %+v, reason: %s", existingRecord, restoreErr)
+			return newRecord, fmt.Errorf("Unable to update time tracking record, it couldn't be restored and remains in trash with id %s, reason: %s", handler.recordIds.encode(entry.Id), err)
+		}
+		return newRecord, fmt.Errorf("Unable to update time tracking record, it has been restored with new id %s, reason: %s", handler.recordIds.encode(restoredRecord.Key), err)
+	}
+	return newRecord, nil
+}
+
+// ApplyTo returns passed record with all values of this patch applied to it.
+func (patch TimeTrackingRecordPatch) applyTo(record timetracker.TimeTrackingRecord) timetracker.TimeTrackingRecord {
+	if patch.DeviceId != nil {
+		record.DeviceId = *patch.DeviceId
+	}
+	if patch.Type != nil {
+		record.Type = *patch.Type
+	}
+	if patch.Timestamp != nil {
+		record.Timestamp = *patch.Timestamp
+	}
+	record.Key = ""
+	return record
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+type RecordUpdateTestSuite struct {
+	suite.Suite
+}
+
+func TestRecordUpdateTestSuite(t *testing.T) {
+	suite.Run(t, new(RecordUpdateTestSuite))
+}
+
+func (suite *RecordUpdateTestSuite) TestPutRecord() {
+
+	handler := timeTrackingRecordHandlerForTest()
+	record := suite.addRecord(handler)
+
+	timestamp := time.Date(2022, 1, 3, 10, 0, 0, 0, time.UTC)
+	request := timeTrackingRecordHandlerRequestForTest(http.MethodPut)
+	request.PathParameters = map[string]string{"id": recordIdCodecForTest().encode(record.Key)}
+	request.Body = `{"DeviceId":"Device01","Type":"vacation","Timestamp":"2022-01-03T10:00:00Z"}`
+
+	response, err := handler.Process(request)
+	suite.Nil(err)
+	suite.Equal(http.StatusOK, response.StatusCode)
+	suite.NotEqual("", response.Headers[serverTimeHeader])
+
+	var updatedRecord timetracker.TimeTrackingRecord
+	suite.Nil(json.Unmarshal([]byte(response.Body), &updatedRecord))
+	suite.Equal(timetracker.VACATION, updatedRecord.Type)
+	suite.True(timestamp.Equal(updatedRecord.Timestamp))
+
+	records := suite.listRecords(handler)
+	suite.Len(records, 1)
+	suite.Equal(updatedRecord.Key, recordIdCodecForTest().encode(records[0].Key))
+	suite.Equal(timetracker.VACATION, records[0].Type)
+}
+
+func (suite *RecordUpdateTestSuite) TestPatchRecord() {
+
+	handler := timeTrackingRecordHandlerForTest()
+	record := suite.addRecord(handler)
+
+	request := timeTrackingRecordHandlerRequestForTest(http.MethodPatch)
+	request.QueryStringParameters = map[string]string{"id": recordIdCodecForTest().encode(record.Key)}
+	request.Body = `{"Type":"illness"}`
+
+	response, err := handler.Process(request)
+	suite.Nil(err)
+	suite.Equal(http.StatusOK, response.StatusCode)
+
+	var updatedRecord timetracker.TimeTrackingRecord
+	suite.Nil(json.Unmarshal([]byte(response.Body), &updatedRecord))
+	suite.Equal(timetracker.ILLNESS, updatedRecord.Type)
+	suite.Equal(record.DeviceId, updatedRecord.DeviceId)
+	suite.True(record.Timestamp.Equal(updatedRecord.Timestamp))
+
+	records := suite.listRecords(handler)
+	suite.Len(records, 1)
+	suite.Equal(timetracker.ILLNESS, records[0].Type)
+}
+
+func (suite *RecordUpdateTestSuite) TestUpdateWithInvalidValues() {
+
+	handler := timeTrackingRecordHandlerForTest()
+	record := suite.addRecord(handler)
+
+	request1 := timeTrackingRecordHandlerRequestForTest(http.MethodPut)
+	request1.PathParameters = map[string]string{"id": recordIdCodecForTest().encode(record.Key)}
+	request1.Body = `{"Type":"vacation","Timestamp":"2022-01-03T10:00:00Z"}`
+	response1, err1 := handler.Process(request1)
+	suite.NotNil(err1)
+	suite.Equal(http.StatusBadRequest, response1.StatusCode)
+
+	request2 := timeTrackingRecordHandlerRequestForTest(http.MethodPatch)
+	request2.PathParameters = map[string]string{"id": recordIdCodecForTest().encode(record.Key)}
+	request2.Body = `{"Timestamp":"1970-01-01T10:00:00Z"}`
+	response2, err2 := handler.Process(request2)
+	suite.NotNil(err2)
+	suite.Equal(http.StatusBadRequest, response2.StatusCode)
+
+	request3 := timeTrackingRecordHandlerRequestForTest(http.MethodPatch)
+	request3.PathParameters = map[string]string{"id": recordIdCodecForTest().encode(record.Key)}
+	request3.Body = "xxx"
+	response3, err3 := handler.Process(request3)
+	suite.NotNil(err3)
+	suite.Equal(http.StatusBadRequest, response3.StatusCode)
+
+	request4 := timeTrackingRecordHandlerRequestForTest(http.MethodPatch)
+	request4.Body = `{"Type":"illness"}`
+	response4, err4 := handler.Process(request4)
+	suite.NotNil(err4)
+	suite.Equal(http.StatusBadRequest, response4.StatusCode)
+
+	records := suite.listRecords(handler)
+	suite.Len(records, 1)
+	suite.Equal(timetracker.WORKDAY, records[0].Type)
+}
+
+func (suite *RecordUpdateTestSuite) TestUpdateConflictingRecord() {
+
+	handler := timeTrackingRecordHandlerForTest()
+	record := suite.addRecord(handler)
+	_, err := handler.timeTrackingManager.Add(timetracker.TimeTrackingRecord{DeviceId: "Device01", Type: timetracker.WORKDAY, Timestamp: time.Date(2022, 1, 3, 17, 0, 0, 0, time.UTC)})
+	suite.Nil(err)
+
+	request := timeTrackingRecordHandlerRequestForTest(http.MethodPatch)
+	request.PathParameters = map[string]string{"id": recordIdCodecForTest().encode(record.Key)}
+	request.Body = `{"Type":"vacation"}`
+	response1, err1 := handler.Process(request)
+	suite.NotNil(err1)
+	suite.Equal(http.StatusConflict, response1.StatusCode)
+	var conflictResponse ConflictResponse
+	suite.Nil(json.Unmarshal([]byte(response1.Body), &conflictResponse))
+	suite.Len(conflictResponse.Conflicts, 1)
+	suite.Equal(CONFLICT_MIXED_ABSENCE, conflictResponse.Conflicts[0].Rule)
+	suite.Len(conflictResponse.Conflicts[0].Keys, 1)
+
+	request.Body = `{"Timestamp":"2022-01-03T17:00:00Z"}`
+	response2, err2 := handler.Process(request)
+	suite.NotNil(err2)
+	suite.Equal(http.StatusConflict, response2.StatusCode)
+
+	request.Body = `{"Timestamp":"2022-01-03T08:30:00Z"}`
+	response3, err3 := handler.Process(request)
+	suite.Nil(err3)
+	suite.Equal(http.StatusOK, response3.StatusCode)
+}
+
+func (suite *RecordUpdateTestSuite) TestUpdateNotExistingRecord() {
+
+	handler := timeTrackingRecordHandlerForTest()
+	suite.addRecord(handler)
+
+	request := timeTrackingRecordHandlerRequestForTest(http.MethodPatch)
+	request.PathParameters = map[string]string{"id": recordIdCodecForTest().encode("Device01/2022-01-03/5")}
+	request.Body = `{"Type":"illness"}`
+	response, err := handler.Process(request)
+	suite.NotNil(err)
+	suite.Equal(http.StatusNotFound, response.StatusCode)
+}
+
+func (suite *RecordUpdateTestSuite) TestRestoreRecordOnError() {
+
+	handler := timeTrackingRecordHandlerForTest()
+	record := suite.addRecord(handler)
+	handler.timeTrackingManager = &recordManagerErrorMock{TimeTrackingRepository: handler.timeTracker.(TimeTrackingRepository)}
+
+	request := timeTrackingRecordHandlerRequestForTest(http.MethodPatch)
+	request.PathParameters = map[string]string{"id": recordIdCodecForTest().encode(record.Key)}
+	request.Body = `{"Type":"illness"}`
+	response, err := handler.Process(request)
+	suite.NotNil(err)
+	suite.Equal(http.StatusInternalServerError, response.StatusCode)
+	suite.Contains(response.Body, "couldn't be restored")
+	entries, err := handler.trash.List("Device01", "", 10, time.Now())
+	suite.Nil(err)
+	suite.Len(entries, 1)
+	suite.Contains(response.Body, recordIdCodecForTest().encode(entries[0].Id))
+	suite.Nil(handler.trash.Remove(entries[0].Id))
+
+	handler.timeTrackingManager = handler.timeTracker.(TimeTrackingRepository)
+	record = suite.addRecord(handler)
+	handler.timeTrackingManager = &recordManagerFailOnceMock{TimeTrackingRepository: handler.timeTracker.(TimeTrackingRepository)}
+	request.PathParameters = map[string]string{"id": recordIdCodecForTest().encode(record.Key)}
+	response, err = handler.Process(request)
+	suite.NotNil(err)
+	suite.Equal(http.StatusInternalServerError, response.StatusCode)
+
+	records := suite.listRecords(handler)
+	suite.Len(records, 1)
+	suite.Equal(timetracker.WORKDAY, records[0].Type)
+	suite.Contains(response.Body, recordIdCodecForTest().encode(records[0].Key))
+	entries, err = handler.trash.List("Device01", "", 10, time.Now())
+	suite.Nil(err)
+	suite.Len(entries, 0)
+
+	handler.timeTrackingManager = handler.timeTracker.(TimeTrackingRepository)
+	request.PathParameters = map[string]string{"id": recordIdCodecForTest().encode(records[0].Key)}
+	response, err = handler.Process(request)
+	suite.Nil(err)
+	suite.Equal(http.StatusOK, response.StatusCode)
+	entries, err = handler.trash.List("Device01", "", 10, time.Now())
+	suite.Nil(err)
+	suite.Len(entries, 1)
+	suite.Equal(timetracker.WORKDAY, entries[0].Record.Type)
+}
+
+func (suite *RecordUpdateTestSuite) TestUpdateWithFailingRepository() {
+
+	handler := timeTrackingRecordHandlerForTest()
+	handler.timeTracker = &timeTrackerErrorMock{}
+
+	request := timeTrackingRecordHandlerRequestForTest(http.MethodPatch)
+	request.PathParameters = map[string]string{"id": recordIdCodecForTest().encode("Device01/2022-01-03/0")}
+	request.Body = `{"Type":"illness"}`
+	response, err := handler.Process(request)
+	suite.NotNil(err)
+	suite.Equal(http.StatusInternalServerError, response.StatusCode)
+}
+
+func (suite *RecordUpdateTestSuite) addRecord(handler *TimeTrackingRecordHandler) timetracker.TimeTrackingRecord {
+	record, err := handler.timeTrackingManager.Add(timetracker.TimeTrackingRecord{
+		DeviceId:  "Device01",
+		Type:      timetracker.WORKDAY,
+		Timestamp: time.Date(2022, 1, 3, 8, 0, 0, 0, time.UTC),
+	})
+	suite.Nil(err)
+	return record
+}
+
+func (suite *RecordUpdateTestSuite) listRecords(handler *TimeTrackingRecordHandler) []timetracker.TimeTrackingRecord {
+	start, end := dayRange(time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC))
+	records, err := handler.timeTracker.ListRecords("Device01", start, end)
+	suite.Nil(err)
+	return records
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"sort"
+	"time"
+
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+// FindRecord returns a record with given type and timestamp from passed list. Timestamps are compared
+// with a precision of seconds. Records with a key in passed exclude list are skipped.
+func findRecord(records []timetracker.TimeTrackingRecord, recordType timetracker.RecordType, timestamp time.Time, excludedKeys map[string]bool) *timetracker.TimeTrackingRecord {
+
+	timestamp = timestamp.UTC().Round(time.Second)
+	for idx, record := range records {
+		if !excludedKeys[record.Key] &&
+			record.Type == recordType &&
+			record.Timestamp.UTC().Round(time.Second).Equal(timestamp) {
+			return &records[idx]
+		}
+	}
+	return nil
+}
+
+// SortRecordsByTimestamp sorts passed records by timestamp in ascending order.
+func sortRecordsByTimestamp(records []timetracker.TimeTrackingRecord) {
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Timestamp.Before(records[j].Timestamp)
+	})
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+// SummarizeRecords calculates working time of passed records per day and per ISO week. Days are
+// determined in passed location. Passed holidays, names per date, are treated as non-working days.
+func summarizeRecords(deviceId string, records []timetracker.TimeTrackingRecord, start, end time.Time, location *time.Location, holidays map[string]string) TimeTrackingSummary {
+
+	summary := TimeTrackingSummary{
+		DeviceId:       deviceId,
+		From:           &APITime{Time: start},
+		To:             &APITime{Time: end},
+		Days:           summarizeDays(records, location),
+		Weeks:          []WeekSummary{},
+		IncompleteDays: []string{},
+		VacationDays:   []string{},
+		IllnessDays:    []string{},
+		HolidayDays:    []string{},
+	}
+
+	for date := range holidays {
+		summary.HolidayDays = append(summary.HolidayDays, date)
+	}
+	sort.Strings(summary.HolidayDays)
+
+	weeks := make(map[string]*WeekSummary)
+	for idx, day := range summary.Days {
+
+		summary.Days[idx].Holiday = holidays[day.Date]
+
+		summary.WorkingTime += day.WorkingTime
+		if day.Incomplete {
+			summary.IncompleteDays = append(summary.IncompleteDays, day.Date)
+		}
+		switch day.Absence {
+		case timetracker.VACATION:
+			summary.VacationDays = append(summary.VacationDays, day.Date)
+		case timetracker.ILLNESS:
+			summary.IllnessDays = append(summary.IllnessDays, day.Date)
+		}
+
+		week := isoWeekOfDate(day.Date)
+		if _, ok := weeks[week]; !ok {
+			summary.Weeks = append(summary.Weeks, WeekSummary{Week: week})
+			weeks[week] = &summary.Weeks[len(summary.Weeks)-1]
+		}
+		if day.WorkingTime > 0 {
+			weeks[week].WorkingTime += day.WorkingTime
+			weeks[week].WorkingDays++
+		}
+	}
+	for idx := range summary.Weeks {
+		summary.Weeks[idx] = *weeks[summary.Weeks[idx].Week]
+	}
+	return summary
+}
+
+// SummarizeDays groups passed records by day and pairs workday records of each day to work sessions.
+// Returned days are sorted by date.
+func summarizeDays(records []timetracker.TimeTrackingRecord, location *time.Location) []DaySummary {
+
+	recordsPerDay := make(map[string][]timetracker.TimeTrackingRecord)
+	for _, record := range records {
+		date := record.Timestamp.In(location).Format(dateLayout)
+		recordsPerDay[date] = append(recordsPerDay[date], record)
+	}
+
+	days := []DaySummary{}
+	for date, recordsOfDay := range recordsPerDay {
+		days = append(days, summarizeDay(date, recordsOfDay))
+	}
+	sort.Slice(days, func(i, j int) bool {
+		return days[i].Date < days[j].Date
+	})
+	return days
+}
+
+// SummarizeDay pairs workday records of a single day to work sessions. Vacation and illness
+// records are reported as absence.
+func summarizeDay(date string, records []timetracker.TimeTrackingRecord) DaySummary {
+
+	day := DaySummary{Date: date, Sessions: []WorkSession{}}
+	workdayRecords := []timetracker.TimeTrackingRecord{}
+	for _, record := range records {
+		switch record.Type {
+		case timetracker.WORKDAY:
+			workdayRecords = append(workdayRecords, record)
+		case timetracker.ILLNESS:
+			day.Absence = timetracker.ILLNESS
+		case timetracker.VACATION:
+			if day.Absence == "" {
+				day.Absence = timetracker.VACATION
+			}
+		}
+	}
+
+	sortRecordsByTimestamp(workdayRecords)
+	for idx := 1; idx < len(workdayRecords); idx += 2 {
+		duration := workdayRecords[idx].Timestamp.Sub(workdayRecords[idx-1].Timestamp)
+		day.Sessions = append(day.Sessions, WorkSession{
+			Start:    &APITime{Time: workdayRecords[idx-1].Timestamp},
+			End:      &APITime{Time: workdayRecords[idx].Timestamp},
+			Duration: int(duration.Minutes()),
+		})
+		day.WorkingTime += int(duration.Minutes())
+	}
+	day.Incomplete = len(workdayRecords)%2 == 1
+	return day
+}
+
+// IsoWeekOfDate returns the ISO week of passed date, e.g. 2022-W05.
+func isoWeekOfDate(date string) string {
+	t, _ := time.Parse(dateLayout, date)
+	year, week := t.ISOWeek()
+	return fmt.Sprintf("%04d-W%02d", year, week)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	log "github.com/tommzn/go-log"
+)
+
+// NewTimeTrackingSummaryHandler returns a handler to summarize working time. Time ranges, timezones and
+// records are determined by passed record handler, so same query parameters as for listings are supported.
+// Holidays are looked up in passed calendar.
+func newTimeTrackingSummaryHandler(recordHandler *TimeTrackingRecordHandler, calendar *HolidayCalendar, logger log.Logger) *TimeTrackingSummaryHandler {
+	return &TimeTrackingSummaryHandler{
+		logger:        logger,
+		recordHandler: recordHandler,
+		calendar:      calendar,
+	}
+}
+
+// Process returns working time of a device per day and week for a requested time range.
+func (handler *TimeTrackingSummaryHandler) Process(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	if request.HTTPMethod != http.MethodGet {
+		err := errors.New("Unsupported HTTP method.")
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusMethodNotAllowed), err
+	}
+
+	deviceId, ok := request.QueryStringParameters["deviceid"]
+	if !ok || deviceId == "" {
+		err := errors.New("Missing device id.")
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusBadRequest), err
+	}
+
+	start, end, err := handler.recordHandler.timeRangeFromRequest(request)
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusBadRequest), err
+	}
+
+	location, err := handler.recordHandler.locationFor(request.QueryStringParameters)
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusBadRequest), err
+	}
+
+	records, err := handler.recordHandler.listRecords([]string{deviceId}, start, end, RecordFilter{location: location})
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusInternalServerError), err
+	}
+	handler.logger.Debugf("Summarize %d record(s) of %s", len(records), deviceId)
+
+	holidays, err := handler.calendar.holidaysBetween(start, end, request.QueryStringParameters)
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, holidayErrorStatus(err)), err
+	}
+
+	responseContent, err := json.Marshal(summarizeRecords(deviceId, records, start, end, location, holidays))
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusInternalServerError), err
+	}
+	return responseWithContent(string(responseContent), http.StatusOK), nil
+}
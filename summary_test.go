@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/suite"
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+type TimeTrackingSummaryTestSuite struct {
+	suite.Suite
+}
+
+func TestTimeTrackingSummaryTestSuite(t *testing.T) {
+	suite.Run(t, new(TimeTrackingSummaryTestSuite))
+}
+
+func (suite *TimeTrackingSummaryTestSuite) TestSummarizeRecords() {
+
+	records := []timetracker.TimeTrackingRecord{
+		recordForTest(timetracker.WORKDAY, time.Date(2022, 1, 28, 13, 0, 0, 0, time.UTC)),
+		recordForTest(timetracker.WORKDAY, time.Date(2022, 1, 28, 8, 0, 0, 0, time.UTC)),
+		recordForTest(timetracker.WORKDAY, time.Date(2022, 1, 28, 17, 30, 0, 0, time.UTC)),
+		recordForTest(timetracker.WORKDAY, time.Date(2022, 1, 28, 14, 0, 0, 0, time.UTC)),
+		recordForTest(timetracker.WORKDAY, time.Date(2022, 1, 31, 8, 0, 0, 0, time.UTC)),
+		recordForTest(timetracker.WORKDAY, time.Date(2022, 1, 31, 16, 0, 0, 0, time.UTC)),
+		recordForTest(timetracker.WORKDAY, time.Date(2022, 2, 1, 9, 0, 0, 0, time.UTC)),
+		recordForTest(timetracker.VACATION, time.Date(2022, 2, 2, 9, 0, 0, 0, time.UTC)),
+		recordForTest(timetracker.ILLNESS, time.Date(2022, 2, 3, 9, 0, 0, 0, time.UTC)),
+	}
+	start := time.Date(2022, 1, 28, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2022, 2, 4, 0, 0, 0, 0, time.UTC)
+
+	summary := summarizeRecords("Device01", records, start, end, time.UTC, map[string]string{"2022-01-31": "Holiday", "2022-01-29": "Holiday"})
+	suite.Equal("Device01", summary.DeviceId)
+	suite.Equal(5*60+3*60+30+8*60, summary.WorkingTime)
+	suite.Len(summary.Days, 5)
+
+	suite.Equal("2022-01-28", summary.Days[0].Date)
+	suite.Len(summary.Days[0].Sessions, 2)
+	suite.Equal(5*60, summary.Days[0].Sessions[0].Duration)
+	suite.Equal(8, summary.Days[0].Sessions[0].Start.AsTime().Hour())
+	suite.Equal(8*60+30, summary.Days[0].WorkingTime)
+	suite.False(summary.Days[0].Incomplete)
+
+	suite.True(summary.Days[2].Incomplete)
+	suite.Equal(0, summary.Days[2].WorkingTime)
+	suite.Equal([]string{"2022-02-01"}, summary.IncompleteDays)
+	suite.Equal([]string{"2022-02-02"}, summary.VacationDays)
+	suite.Equal([]string{"2022-02-03"}, summary.IllnessDays)
+	suite.Equal([]string{"2022-01-29", "2022-01-31"}, summary.HolidayDays)
+	suite.Equal("Holiday", summary.Days[1].Holiday)
+	suite.Equal(8*60, summary.Days[1].WorkingTime)
+	suite.Equal(timetracker.ILLNESS, summary.Days[4].Absence)
+
+	suite.Len(summary.Weeks, 2)
+	suite.Equal(WeekSummary{Week: "2022-W04", WorkingTime: 8*60 + 30, WorkingDays: 1}, summary.Weeks[0])
+	suite.Equal(WeekSummary{Week: "2022-W05", WorkingTime: 8 * 60, WorkingDays: 1}, summary.Weeks[1])
+}
+
+func (suite *TimeTrackingSummaryTestSuite) TestSummarizeRecordsInTimezone() {
+
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	records := []timetracker.TimeTrackingRecord{
+		recordForTest(timetracker.WORKDAY, time.Date(2022, 1, 1, 22, 0, 0, 0, time.UTC)),
+		recordForTest(timetracker.WORKDAY, time.Date(2022, 1, 1, 23, 30, 0, 0, time.UTC)),
+	}
+	summary := summarizeRecords("Device01", records, time.Now(), time.Now(), berlin, map[string]string{})
+	suite.Len(summary.Days, 2)
+	suite.Equal("2022-01-01", summary.Days[0].Date)
+	suite.Equal("2022-01-02", summary.Days[1].Date)
+	suite.Equal([]string{"2022-01-01", "2022-01-02"}, summary.IncompleteDays)
+}
+
+func (suite *TimeTrackingSummaryTestSuite) TestProcessSummaryRequest() {
+
+	recordHandler := timeTrackingRecordHandlerForTest()
+	prepareForTest(recordHandler.timeTrackingManager)
+	handler := newTimeTrackingSummaryHandler(recordHandler, holidayCalendarForTest(), loggerForTest())
+
+	request1 := suite.requestForTest(map[string]string{"deviceid": "Device01", "from": "2021-12-31", "to": "2022-01-02"})
+	response1, err1 := handler.Process(request1)
+	suite.Nil(err1)
+	suite.Equal(http.StatusOK, response1.StatusCode)
+
+	var summary TimeTrackingSummary
+	suite.Nil(json.Unmarshal([]byte(response1.Body), &summary))
+	suite.Len(summary.Days, 3)
+	suite.Equal(8*60, summary.WorkingTime)
+	suite.Equal([]string{"2021-12-31", "2022-01-02"}, summary.IncompleteDays)
+	suite.Equal([]string{"2022-01-01"}, summary.HolidayDays)
+
+	request1_1 := suite.requestForTest(map[string]string{"deviceid": "Device01", "from": "2021-12-31", "to": "2022-01-02", "country": "US"})
+	response1_1, err1_1 := handler.Process(request1_1)
+	suite.Nil(err1_1)
+	suite.Nil(json.Unmarshal([]byte(response1_1.Body), &summary))
+	suite.Len(summary.HolidayDays, 0)
+
+	response2, err2 := handler.Process(suite.requestForTest(map[string]string{"from": "2021-12-31", "to": "2022-01-02"}))
+	suite.NotNil(err2)
+	suite.Equal(http.StatusBadRequest, response2.StatusCode)
+
+	response3, err3 := handler.Process(suite.requestForTest(map[string]string{"deviceid": "Device01"}))
+	suite.NotNil(err3)
+	suite.Equal(http.StatusBadRequest, response3.StatusCode)
+
+	request4 := suite.requestForTest(map[string]string{"deviceid": "Device01", "date": "2022-01-01"})
+	request4.HTTPMethod = http.MethodPost
+	response4, err4 := handler.Process(request4)
+	suite.NotNil(err4)
+	suite.Equal(http.StatusMethodNotAllowed, response4.StatusCode)
+}
+
+func (suite *TimeTrackingSummaryTestSuite) requestForTest(queryParams map[string]string) events.APIGatewayProxyRequest {
+	request := timeTrackingRecordHandlerRequestForTest(http.MethodGet)
+	request.Resource = "/timetrackingrecords/summary"
+	request.QueryStringParameters = queryParams
+	return request
+}
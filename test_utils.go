@@ -35,6 +35,18 @@ func (mock *handlerMock) Process(request events.APIGatewayProxyRequest) (events.
 	return events.APIGatewayProxyResponse{StatusCode: 200}, nil
 }
 
+// recordIdCodecForTest creates a codec for record ids with a static secret.
+func recordIdCodecForTest() *RecordIdCodec {
+	codec, _ := newRecordIdCodec("unittest-secret")
+	return codec
+}
+
+// timezonesForTest returns timezone settings from test config.
+func timezonesForTest() *TimezoneSettings {
+	timezones, _ := newTimezoneSettings(configForTest())
+	return timezones
+}
+
 func configForTest() config.Config {
 	configFile := "fixtures/testconfig.yml"
 	configLoader := config.NewFileConfigSource(&configFile)
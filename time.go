@@ -40,3 +40,10 @@ func (apiTime *APITime) MarshalJSON() ([]byte, error) {
 func (apiTime *APITime) AsTime() time.Time {
 	return apiTime.Time
 }
+
+// DayRange returns start and end of the day passed point in time belongs to, in UTC.
+func dayRange(t time.Time) (time.Time, time.Time) {
+	utc := t.UTC()
+	start := time.Date(utc.Year(), utc.Month(), utc.Day(), 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 0, 1).Add(-1 * time.Second)
+}
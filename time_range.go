@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+const dateLayout = "2006-01-02"
+
+var isoWeekPattern = regexp.MustCompile(`^(\d{4})-W(\d{2})$`)
+
+// TimeRangeFromRequest determines the half-open time range, [start, end), for a record query. Supported query
+// parameters are a single date, from/to as date or datetime, month as 2022-01 or an ISO week as 2022-W05.
+// Day boundaries are determined in the timezone of a query, see locationFor.
+// Returns with an error if there's no or an invalid time range or if it exceeds max time range.
+func (handler *TimeTrackingRecordHandler) timeRangeFromRequest(request events.APIGatewayProxyRequest) (time.Time, time.Time, error) {
+
+	start, end, err := handler.parseTimeRange(request.QueryStringParameters)
+	if err != nil {
+		return start, end, err
+	}
+	if end.Before(start) {
+		return start, end, errors.New("End of time range is before its start.")
+	}
+	if end.Sub(start) > handler.maxTimeRange {
+		return start, end, fmt.Errorf("Time range exceeds max duration of %s.", handler.maxTimeRange)
+	}
+	return start, end, nil
+}
+
+// ParseTimeRange extracts a half-open time range from passed query parameters. A date passed as to
+// includes the whole day, a datetime is the exclusive end of a range.
+func (handler *TimeTrackingRecordHandler) parseTimeRange(queryParams map[string]string) (time.Time, time.Time, error) {
+
+	location, err := handler.locationFor(queryParams)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	if dateStr, ok := queryParams["date"]; ok {
+		start, end := handler.timeRangeForDate(dateLayout, dateStr, location)
+		if start == nil || end == nil {
+			return time.Time{}, time.Time{}, errors.New("Unable to determin time rage for date: " + dateStr)
+		}
+		return *start, *end, nil
+	}
+
+	if monthStr, ok := queryParams["month"]; ok {
+		month, err := time.ParseInLocation("2006-01", monthStr, location)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		return month, month.AddDate(0, 1, 0), nil
+	}
+
+	if weekStr, ok := queryParams["week"]; ok {
+		start, err := startOfIsoWeek(weekStr, location)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		return start, start.AddDate(0, 0, 7), nil
+	}
+
+	fromStr, hasFrom := queryParams["from"]
+	toStr, hasTo := queryParams["to"]
+	if !hasFrom && !hasTo {
+		return time.Time{}, time.Time{}, errors.New("Missing date.")
+	}
+	if !hasFrom || !hasTo {
+		return time.Time{}, time.Time{}, errors.New("Time range requires from and to.")
+	}
+
+	start, isDate, err := parseDateOrDateTime(fromStr, location)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end, isDate, err := parseDateOrDateTime(toStr, location)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if isDate {
+		end = end.AddDate(0, 0, 1)
+	}
+	return start, end, nil
+}
+
+// ParseDateOrDateTime parses passed value as date in given location or as datetime.
+// Returns true if passed value is a date.
+func parseDateOrDateTime(value string, location *time.Location) (time.Time, bool, error) {
+
+	if date, err := time.ParseInLocation(dateLayout, value, location); err == nil {
+		return date, true, nil
+	}
+	var parseError error
+	for _, format := range dateFormatList {
+		dateTime, err := time.Parse(format, value)
+		if err == nil {
+			return dateTime, false, nil
+		}
+		parseError = err
+	}
+	return time.Time{}, false, parseError
+}
+
+// StartOfIsoWeek returns the start of passed ISO week, e.g. 2022-W05.
+func startOfIsoWeek(value string, location *time.Location) (time.Time, error) {
+
+	matches := isoWeekPattern.FindStringSubmatch(value)
+	if matches == nil {
+		return time.Time{}, errors.New("Invalid ISO week: " + value)
+	}
+	year, _ := strconv.Atoi(matches[1])
+	week, _ := strconv.Atoi(matches[2])
+	if week < 1 || week > 53 {
+		return time.Time{}, errors.New("Invalid ISO week: " + value)
+	}
+
+	// January 4th is always in week 1.
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, location)
+	weekday := int(jan4.Weekday())
+	if weekday == 0 {
+		weekday = 7
+	}
+	start := jan4.AddDate(0, 0, 1-weekday+(week-1)*7)
+	if _, isoWeek := start.ISOWeek(); isoWeek != week {
+		return time.Time{}, errors.New("Invalid ISO week: " + value)
+	}
+	return start, nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+type TimeRangeTestSuite struct {
+	suite.Suite
+}
+
+func TestTimeRangeTestSuite(t *testing.T) {
+	suite.Run(t, new(TimeRangeTestSuite))
+}
+
+func (suite *TimeRangeTestSuite) TestTimeRangeFromRequest() {
+
+	handler := timeTrackingRecordHandlerForTest()
+	location := handler.defaultLocation
+
+	start1, end1 := suite.assertTimeRange(handler, map[string]string{"date": "2022-01-01"})
+	suite.Equal(time.Date(2022, 1, 1, 0, 0, 0, 0, location), start1)
+	suite.Equal(time.Date(2022, 1, 2, 0, 0, 0, 0, location), end1)
+
+	start2, end2 := suite.assertTimeRange(handler, map[string]string{"month": "2022-02"})
+	suite.Equal(time.Date(2022, 2, 1, 0, 0, 0, 0, location), start2)
+	suite.Equal(time.Date(2022, 3, 1, 0, 0, 0, 0, location), end2)
+
+	start3, end3 := suite.assertTimeRange(handler, map[string]string{"week": "2022-W05"})
+	suite.Equal(time.Date(2022, 1, 31, 0, 0, 0, 0, location), start3)
+	suite.Equal(time.Date(2022, 2, 7, 0, 0, 0, 0, location), end3)
+
+	start4, end4 := suite.assertTimeRange(handler, map[string]string{"from": "2022-01-01", "to": "2022-01-31"})
+	suite.Equal(time.Date(2022, 1, 1, 0, 0, 0, 0, location), start4)
+	suite.Equal(time.Date(2022, 2, 1, 0, 0, 0, 0, location), end4)
+
+	start5, end5 := suite.assertTimeRange(handler, map[string]string{"from": "2022-01-01T08:00:00Z", "to": "2022-01-01T12:00:00Z"})
+	suite.Equal(time.Date(2022, 1, 1, 8, 0, 0, 0, time.UTC), start5.UTC())
+	suite.Equal(time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC), end5.UTC())
+}
+
+func (suite *TimeRangeTestSuite) TestInvalidTimeRanges() {
+
+	handler := timeTrackingRecordHandlerForTest()
+	for _, queryParams := range []map[string]string{
+		{},
+		{"from": "2022-01-01"},
+		{"from": "2022-01-31", "to": "2022-01-01"},
+		{"from": "2022-01-01", "to": "2023-01-01"},
+		{"month": "2022-13"},
+		{"week": "2022-W54"},
+		{"week": "2021-W53"},
+		{"date": "xxx"},
+		{"date": "2022-01-01", "tz": "Europe/Xxx"},
+		{"date": "2022-01-01", "tz": ""},
+	} {
+		request := timeTrackingRecordHandlerRequestForTest(http.MethodGet)
+		request.QueryStringParameters = queryParams
+		_, _, err := handler.timeRangeFromRequest(request)
+		suite.NotNil(err, "Expected error for %+v", queryParams)
+	}
+}
+
+func (suite *TimeRangeTestSuite) TestTimeRangeInTimezone() {
+
+	handler := timeTrackingRecordHandlerForTest()
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	suite.Nil(err)
+
+	start1, end1 := suite.assertTimeRange(handler, map[string]string{"date": "2022-01-02", "tz": "Europe/Berlin"})
+	suite.Equal(time.Date(2022, 1, 1, 23, 0, 0, 0, time.UTC), start1.UTC())
+	suite.Equal(time.Date(2022, 1, 2, 23, 0, 0, 0, time.UTC), end1.UTC())
+
+	start2, end2 := suite.assertTimeRange(handler, map[string]string{"date": "2022-03-27", "tz": "Europe/Berlin"})
+	suite.Equal(time.Date(2022, 3, 27, 0, 0, 0, 0, berlin), start2)
+	suite.Equal(23*time.Hour, end2.Sub(start2))
+
+	start3, end3 := suite.assertTimeRange(handler, map[string]string{"date": "2022-10-30", "tz": "Europe/Berlin"})
+	suite.Equal(time.Date(2022, 10, 30, 0, 0, 0, 0, berlin), start3)
+	suite.Equal(25*time.Hour, end3.Sub(start3))
+
+	start4, end4 := suite.assertTimeRange(handler, map[string]string{"month": "2022-03", "tz": "Europe/Berlin"})
+	suite.Equal(time.Date(2022, 2, 28, 23, 0, 0, 0, time.UTC), start4.UTC())
+	suite.Equal(time.Date(2022, 3, 31, 22, 0, 0, 0, time.UTC), end4.UTC())
+
+	start5, _ := suite.assertTimeRange(handler, map[string]string{"date": "2022-01-02", "deviceid": "Device03"})
+	suite.Equal(time.Date(2022, 1, 1, 23, 0, 0, 0, time.UTC), start5.UTC())
+
+	start6, _ := suite.assertTimeRange(handler, map[string]string{"date": "2022-01-02", "deviceids": "Device03,Device01"})
+	suite.Equal(time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC), start6.UTC())
+
+	start7, _ := suite.assertTimeRange(handler, map[string]string{"date": "2022-01-02", "deviceid": "Device03", "tz": "UTC"})
+	suite.Equal(time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC), start7.UTC())
+}
+
+func (suite *TimeRangeTestSuite) TestListRecordsInTimezone() {
+
+	handler := timeTrackingRecordHandlerForTest()
+	handler.timeTrackingManager.Add(timetracker.TimeTrackingRecord{DeviceId: "Device01", Type: timetracker.WORKDAY, Timestamp: time.Date(2022, 1, 1, 22, 59, 59, 0, time.UTC)})
+	handler.timeTrackingManager.Add(timetracker.TimeTrackingRecord{DeviceId: "Device01", Type: timetracker.WORKDAY, Timestamp: time.Date(2022, 1, 1, 23, 30, 0, 0, time.UTC)})
+	handler.timeTrackingManager.Add(timetracker.TimeTrackingRecord{DeviceId: "Device01", Type: timetracker.WORKDAY, Timestamp: time.Date(2022, 1, 2, 22, 59, 59, 0, time.UTC)})
+	handler.timeTrackingManager.Add(timetracker.TimeTrackingRecord{DeviceId: "Device01", Type: timetracker.WORKDAY, Timestamp: time.Date(2022, 1, 2, 23, 0, 0, 0, time.UTC)})
+
+	request := timeTrackingRecordHandlerRequestForTest(http.MethodGet)
+	request.QueryStringParameters = map[string]string{"deviceid": "Device01", "date": "2022-01-02", "tz": "Europe/Berlin"}
+	response, err := handler.Process(request)
+	suite.Nil(err)
+	suite.Equal(http.StatusOK, response.StatusCode)
+
+	var records []TimeTrackingRecord
+	suite.Nil(json.Unmarshal([]byte(response.Body), &records))
+	suite.Len(records, 2)
+	suite.Equal(time.Date(2022, 1, 1, 23, 30, 0, 0, time.UTC), records[0].Timestamp.AsTime().UTC())
+	suite.Equal(time.Date(2022, 1, 2, 22, 59, 59, 0, time.UTC), records[1].Timestamp.AsTime().UTC())
+}
+
+func (suite *TimeRangeTestSuite) TestStartOfIsoWeek() {
+
+	start1, err1 := startOfIsoWeek("2020-W53", time.UTC)
+	suite.Nil(err1)
+	suite.Equal(time.Date(2020, 12, 28, 0, 0, 0, 0, time.UTC), start1)
+
+	start2, err2 := startOfIsoWeek("2022-W01", time.UTC)
+	suite.Nil(err2)
+	suite.Equal(time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC), start2)
+}
+
+func (suite *TimeRangeTestSuite) assertTimeRange(handler *TimeTrackingRecordHandler, queryParams map[string]string) (time.Time, time.Time) {
+	request := timeTrackingRecordHandlerRequestForTest(http.MethodGet)
+	request.QueryStringParameters = queryParams
+	start, end, err := handler.timeRangeFromRequest(request)
+	suite.Nil(err)
+	return start, end
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	config "github.com/tommzn/go-config"
+)
+
+// NewTimestampValidator creates a validator with plausibility windows from passed config.
+// By default timestamps can be up to 2 years in the past and 1 year in the future and
+// a clock skew of 5 minutes is tolerated.
+func newTimestampValidator(conf config.Config) *TimestampValidator {
+	validator := &TimestampValidator{
+		maxPast:      2 * 365 * 24 * time.Hour,
+		maxFuture:    365 * 24 * time.Hour,
+		maxClockSkew: 5 * time.Minute,
+		now:          time.Now,
+	}
+	if conf != nil {
+		validator.maxPast = *conf.GetAsDuration("hob.timestamps.maxpast", &validator.maxPast)
+		validator.maxFuture = *conf.GetAsDuration("hob.timestamps.maxfuture", &validator.maxFuture)
+		validator.maxClockSkew = *conf.GetAsDuration("hob.timestamps.clockskew", &validator.maxClockSkew)
+	}
+	return validator
+}
+
+// Validate returns an error if passed timestamp is outside of plausibility windows.
+// Timestamps ahead of server time by less than tolerated clock skew are clamped to server time.
+func (validator *TimestampValidator) validate(timestamp time.Time) (time.Time, error) {
+
+	serverTime := validator.now()
+	if timestamp.Before(serverTime.Add(-1 * validator.maxPast)) {
+		return timestamp, fmt.Errorf("Timestamp %s is too far in the past.", timestamp.Format(time.RFC3339))
+	}
+	if timestamp.After(serverTime.Add(validator.maxFuture)) {
+		return timestamp, fmt.Errorf("Timestamp %s is too far in the future.", timestamp.Format(time.RFC3339))
+	}
+	if timestamp.After(serverTime) && !timestamp.After(serverTime.Add(validator.maxClockSkew)) {
+		return serverTime, nil
+	}
+	return timestamp, nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type TimestampValidatorTestSuite struct {
+	suite.Suite
+}
+
+func TestTimestampValidatorTestSuite(t *testing.T) {
+	suite.Run(t, new(TimestampValidatorTestSuite))
+}
+
+func (suite *TimestampValidatorTestSuite) TestValidateTimestamps() {
+
+	serverTime := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	validator := newTimestampValidator(emptyConfigForTest())
+	validator.now = func() time.Time { return serverTime }
+
+	timestamp1 := serverTime.Add(-1 * time.Hour)
+	validated1, err1 := validator.validate(timestamp1)
+	suite.Nil(err1)
+	suite.Equal(timestamp1, validated1)
+
+	validated2, err2 := validator.validate(serverTime.Add(2 * time.Minute))
+	suite.Nil(err2)
+	suite.Equal(serverTime, validated2)
+
+	timestamp3 := serverTime.Add(10 * 24 * time.Hour)
+	validated3, err3 := validator.validate(timestamp3)
+	suite.Nil(err3)
+	suite.Equal(timestamp3, validated3)
+
+	_, err4 := validator.validate(time.Unix(0, 0))
+	suite.NotNil(err4)
+
+	_, err5 := validator.validate(serverTime.AddDate(2, 0, 0))
+	suite.NotNil(err5)
+}
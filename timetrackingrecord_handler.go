@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -9,17 +10,39 @@ import (
 	"time"
 
 	"github.com/aws/aws-lambda-go/events"
+	config "github.com/tommzn/go-config"
 	log "github.com/tommzn/go-log"
 	timetracker "github.com/tommzn/hob-timetracker"
 )
 
 // NewReportGenerateRequestHandler returna handler to maintina, add and delete, time tracking records.
-func newTimeTrackingRecordHandler(manager timetracker.TimeTrackingRecordManager, timeTracker timetracker.TimeTracker, logger log.Logger) *TimeTrackingRecordHandler {
+// Settings, e.g. max time range for queries, are read from passed config.
+func newTimeTrackingRecordHandler(manager timetracker.TimeTrackingRecordManager, timeTracker timetracker.TimeTracker, timestampValidator *TimestampValidator, recordIds *RecordIdCodec, trash RecordTrash, conf config.Config, logger log.Logger) (*TimeTrackingRecordHandler, error) {
+
+	timezones, err := newTimezoneSettings(conf)
+	if err != nil {
+		return nil, err
+	}
 	return &TimeTrackingRecordHandler{
 		logger:              logger,
 		timeTrackingManager: manager,
 		timeTracker:         timeTracker,
-	}
+		timestampValidator:  timestampValidator,
+		maxTimeRange:        *conf.GetAsDuration("hob.records.maxtimerange", config.AsDurationPtr(93*24*time.Hour)),
+		defaultPageSize:     *conf.GetAsInt("hob.records.pagesize", config.AsIntPtr(500)),
+		maxPageSize:         *conf.GetAsInt("hob.records.maxpagesize", config.AsIntPtr(1000)),
+		TimezoneSettings:    timezones,
+		recordKeyPrefix:     conf.Get("aws.s3.basepath", nil),
+		conflictRules:       newConflictRules(conf),
+		recordIds:           recordIds,
+		listWorkers:         *conf.GetAsInt("hob.records.workers", config.AsIntPtr(8)),
+		listTimeout:         *conf.GetAsDuration("hob.records.listtimeout", config.AsDurationPtr(25*time.Second)),
+		listChunk:           *conf.GetAsDuration("hob.records.listchunk", config.AsDurationPtr(31*24*time.Hour)),
+		ctx:                 context.Background(),
+		trash:               trash,
+		trashRetention:      *conf.GetAsDuration("hob.trash.retention", config.AsDurationPtr(30*24*time.Hour)),
+		bulkDeleteTokenTtl:  *conf.GetAsDuration("hob.records.bulkdelete.tokenttl", config.AsDurationPtr(15*time.Minute)),
+	}, nil
 }
 
 // Process will generate and publish time tracking report for passed year/month.
@@ -30,6 +53,10 @@ func (handler *TimeTrackingRecordHandler) Process(request events.APIGatewayProxy
 	switch request.HTTPMethod {
 
 	case http.MethodGet:
+		if _, ok := request.PathParameters["id"]; ok {
+			return handler.getRecord(request)
+		}
+
 		deviceIds := deviceIdsFromRequest(request)
 		if len(deviceIds) == 0 {
 			err := errors.New("Missing device id.")
@@ -37,52 +64,70 @@ func (handler *TimeTrackingRecordHandler) Process(request events.APIGatewayProxy
 			return errorResponseWithStatus(err, http.StatusBadRequest), err
 		}
 
-		dateStr, ok2 := request.QueryStringParameters["date"]
-		if !ok2 {
-			err := errors.New("Missing date.")
+		timeRangeStart, timeRangeEnd, err := handler.timeRangeFromRequest(request)
+		if err != nil {
+			handler.logger.Error(err)
+			return errorResponseWithStatus(err, http.StatusBadRequest), err
+		}
+		handler.logger.Debugf("Receive GET for DeviceId: %s, Range: %s/%s", strings.Join(deviceIds, ","), timeRangeStart.Format(time.RFC3339), timeRangeEnd.Format(time.RFC3339))
+
+		filter, err := handler.filterFromRequest(request)
+		if err != nil {
 			handler.logger.Error(err)
 			return errorResponseWithStatus(err, http.StatusBadRequest), err
 		}
-		handler.logger.Debugf("Receive GET for DeviceId: %s, Date: %s", strings.Join(deviceIds, ","), dateStr)
 
-		timeRangeStart, timeRangeEnd := handler.timeRangeForDate("2006-01-02", dateStr)
-		if timeRangeStart == nil || timeRangeEnd == nil {
-			err := errors.New("Unable to determin time rage for date: " + dateStr)
+		page, err := handler.pageFromRequest(request)
+		if err != nil {
 			handler.logger.Error(err)
 			return errorResponseWithStatus(err, http.StatusBadRequest), err
 		}
 
-		repositoryRecords := []timetracker.TimeTrackingRecord{}
-		for _, deviceId := range deviceIds {
-			handler.logger.Debugf("Looking for records: deviceid: %s, range %s/%s", deviceId, timeRangeStart.Format(time.RFC3339), timeRangeEnd.Format(time.RFC3339))
-			recordsForDevice, err := handler.timeTracker.ListRecords(deviceId, *timeRangeStart, *timeRangeEnd)
-			handler.logger.Debugf("Found %d record(s) fordeviceid: %s", len(recordsForDevice), deviceId)
-			if err != nil {
-				handler.logger.Error(err)
-				return errorResponseWithStatus(err, http.StatusInternalServerError), err
+		listStart, listEnd := page.timeRange(timeRangeStart, timeRangeEnd)
+		listDeviceIds := page.devices(deviceIds)
+		results := handler.listRecordsOfDevices(listDeviceIds, listStart, listEnd, filter)
+		failedDevices := deviceErrors(results)
+		if len(failedDevices) > 0 && len(failedDevices) == len(listDeviceIds) {
+			handler.logger.Errorf("Unable to list records of all device(s): %+v", failedDevices)
+			if allTimedOut(results) {
+				return withDeviceErrors(errorResponseWithStatus(errListTimeout, http.StatusGatewayTimeout), results), errListTimeout
 			}
-			repositoryRecords = append(repositoryRecords, recordsForDevice...)
+			return withDeviceErrors(errorResponseWithStatus(errListFailed, http.StatusInternalServerError), results), errListFailed
+		}
+		if len(failedDevices) > 0 {
+			handler.logger.Errorf("Unable to list records of %d device(s): %+v", len(failedDevices), failedDevices)
 		}
 
 		records := []TimeTrackingRecord{}
-		for _, repositoryRecord := range repositoryRecords {
-			records = append(records, TimeTrackingRecord{Key: repositoryRecord.Key, DeviceId: repositoryRecord.DeviceId, Type: repositoryRecord.Type, Timestamp: &APITime{Time: repositoryRecord.Timestamp}})
+		for _, result := range results {
+			for _, repositoryRecord := range result.Records {
+				records = append(records, TimeTrackingRecord{Key: repositoryRecord.Key, DeviceId: repositoryRecord.DeviceId, Type: repositoryRecord.Type, Timestamp: &APITime{Time: repositoryRecord.Timestamp}})
+			}
 		}
 
-		if len(records) == 0 {
-			handler.logger.Errorf("No time tracking records found. (%s&%s)", strings.Join(deviceIds, ","), dateStr)
-			return responseWithStatus(http.StatusNotFound), nil
+		if len(records) == 0 && page.After == nil {
+			handler.logger.Errorf("No time tracking records found. (%s&%s/%s)", strings.Join(deviceIds, ","), timeRangeStart.Format(time.RFC3339), timeRangeEnd.Format(time.RFC3339))
+			return withDeviceErrors(responseWithStatus(http.StatusNotFound), results), nil
 		}
 
+		records, nextCursor := page.apply(records)
 		for idx, _ := range records {
-			records[idx].Key = queryExcapeKey(records[idx].Key)
+			records[idx].Key = handler.recordIds.encode(records[idx].Key)
+		}
+		if nextCursor != nil {
+			nextCursor.Key = handler.recordIds.encode(nextCursor.Key)
 		}
 		responseContent, err := json.Marshal(records)
 		if err != nil {
 			handler.logger.Error(err)
 			return errorResponseWithStatus(err, http.StatusInternalServerError), err
 		}
-		return responseWithContent(string(responseContent), http.StatusOK), nil
+		response := responseWithContent(string(responseContent), http.StatusOK)
+		// Pages of partial results can't be continued, because records of failed devices would be skipped.
+		if nextCursor != nil && len(failedDevices) == 0 {
+			response.Headers = map[string]string{"Link": nextLink(request, *nextCursor)}
+		}
+		return withDeviceErrors(response, results), nil
 
 	case http.MethodPost:
 
@@ -92,59 +137,193 @@ func (handler *TimeTrackingRecordHandler) Process(request events.APIGatewayProxy
 			return errorResponseWithStatus(err, http.StatusBadRequest), err
 		}
 
-		if record.DeviceId == "" ||
-			record.Type == "" ||
-			record.Timestamp.Before(time.Now().Add(-2*365*24*time.Hour)) ||
-			record.Timestamp.After(time.Now().Add(1*365*24*time.Hour)) {
-			err := errors.New("Invalid time tracking record.")
+		serverTime := time.Now()
+		record, err := handler.validateRecord(record)
+		if err != nil {
 			handler.logger.Error(err)
-			return errorResponseWithStatus(err, http.StatusBadRequest), err
+			return withServerTime(errorResponseWithStatus(err, http.StatusBadRequest), serverTime), err
 		}
 		handler.logger.Debugf("Receive new time tracking record: %+v", record)
 
+		if response, err := handler.checkConflictsOfRequest(request, record, ""); err != nil {
+			return response, err
+		}
+
 		newRecord, err := handler.timeTrackingManager.Add(record)
 		if err != nil {
 			handler.logger.Error(err)
 			return errorResponseWithStatus(err, http.StatusInternalServerError), err
 		}
 
+		newRecord.Key = handler.recordIds.encode(newRecord.Key)
 		responseContent, err := json.Marshal(newRecord)
 		if err != nil {
 			handler.logger.Error(err)
 			return errorResponseWithStatus(err, http.StatusInternalServerError), err
 		}
-		return responseWithContent(string(responseContent), http.StatusCreated), nil
+		return withServerTime(responseWithContent(string(responseContent), http.StatusCreated), serverTime), nil
 
 	case http.MethodDelete:
 
-		id, ok := request.QueryStringParameters["id"]
+		key, ok, err := handler.recordKeyFromRequest(request)
+		if !ok && len(deviceIdsFromRequest(request)) > 0 {
+			return handler.deleteRecords(request)
+		}
 		if !ok {
 			err := errors.New("Missing time tracking record id.")
 			handler.logger.Error(err)
 			return errorResponseWithStatus(err, http.StatusBadRequest), err
 		}
-		decodedId := queryUnexcapeKey(id)
-		handler.logger.Debug("Receive time tracking record delete for id: ", decodedId)
+		if err != nil {
+			handler.logger.Error(err)
+			return errorResponseWithStatus(err, http.StatusBadRequest), err
+		}
+		handler.logger.Debug("Receive time tracking record delete for key: ", key)
+		if _, _, err := parseRecordKey(key, handler.recordKeyPrefix); err != nil {
+			handler.logger.Error(err)
+			return errorResponseWithStatus(err, http.StatusBadRequest), err
+		}
 
-		err := handler.timeTrackingManager.Delete(decodedId)
+		record, err := findRecordByKey(handler.timeTracker, key, handler.recordKeyPrefix)
 		if err != nil {
 			handler.logger.Error(err)
 			return errorResponseWithStatus(err, http.StatusInternalServerError), err
 		}
-		return responseWithContent("", http.StatusNoContent), nil
+		if record == nil {
+			err := errors.New("Time tracking record not found.")
+			handler.logger.Error(err)
+			return errorResponseWithStatus(err, http.StatusNotFound), err
+		}
+
+		entry, err := handler.moveToTrash(*record)
+		if err != nil {
+			handler.logger.Error(err)
+			return errorResponseWithStatus(err, http.StatusInternalServerError), err
+		}
+		responseContent, err := json.Marshal(handler.publicTrashEntry(entry))
+		if err != nil {
+			handler.logger.Error(err)
+			return errorResponseWithStatus(err, http.StatusInternalServerError), err
+		}
+		return responseWithContent(string(responseContent), http.StatusOK), nil
+
+	case http.MethodPut, http.MethodPatch:
+		return handler.updateRecord(request)
 
 	default:
 		err := errors.New("Unsupported HTTP method.")
 		handler.logger.Error(err)
 		return errorResponseWithStatus(err, http.StatusMethodNotAllowed), err
 	}
+}
+
+// ListRecords returns all records of passed devices within given half-open time range, [start, end),
+// which match passed filter. Returns with an error if records of a device can't be listed.
+func (handler *TimeTrackingRecordHandler) listRecords(deviceIds []string, start, end time.Time, filter RecordFilter) ([]timetracker.TimeTrackingRecord, error) {
+
+	records := []timetracker.TimeTrackingRecord{}
+	for _, result := range handler.listRecordsOfDevices(deviceIds, start, end, filter) {
+		if result.Err != nil {
+			return records, result.Err
+		}
+		records = append(records, result.Records...)
+	}
+	return records, nil
+}
+
+// CheckConflictsOfRequest checks passed record for conflicts, unless a manager forces to skip this check
+// with query parameter force=true. Returns with status 403 if force is passed by other users.
+func (handler *TimeTrackingRecordHandler) checkConflictsOfRequest(request events.APIGatewayProxyRequest, record timetracker.TimeTrackingRecord, excludedKey string) (events.APIGatewayProxyResponse, error) {
+
+	if !isForced(request) {
+		return handler.checkConflicts(record, excludedKey)
+	}
+	if !handler.conflictRules.isManager(request) {
+		err := errors.New("Only managers are allowed to force adding conflicting records.")
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusForbidden), err
+	}
+	handler.logger.Info("Conflict check skipped for forced time tracking record: ", record)
+	return events.APIGatewayProxyResponse{}, nil
+}
+
+// CheckConflicts checks passed record against existing records of its day. A record with excluded key,
+// e.g. a record which will be replaced, is ignored. Returns with status 409 and details of all conflicts
+// if there're some.
+func (handler *TimeTrackingRecordHandler) checkConflicts(record timetracker.TimeTrackingRecord, excludedKey string) (events.APIGatewayProxyResponse, error) {
+
+	location := handler.locationOfDevice(record.DeviceId)
+	start := startOfDay(record.Timestamp, location)
+	existingRecords, err := handler.listRecords([]string{record.DeviceId}, start, start.AddDate(0, 0, 1), RecordFilter{location: location})
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusInternalServerError), err
+	}
+
+	conflicts := handler.conflictRules.check(record, withoutRecord(existingRecords, excludedKey))
+	if len(conflicts) == 0 {
+		return events.APIGatewayProxyResponse{}, nil
+	}
+
+	err = errors.New("Time tracking record conflicts with existing records.")
+	handler.logger.Error(err, " ", conflicts)
+	for idx := range conflicts {
+		for keyIdx, key := range conflicts[idx].Keys {
+			conflicts[idx].Keys[keyIdx] = handler.recordIds.encode(key)
+		}
+	}
+	responseContent, marshalErr := json.Marshal(ConflictResponse{Error: err.Error(), Conflicts: conflicts})
+	if marshalErr != nil {
+		handler.logger.Error(marshalErr)
+		return errorResponseWithStatus(marshalErr, http.StatusInternalServerError), marshalErr
+	}
+	return responseWithContent(string(responseContent), http.StatusConflict), err
+}
 
-	err := errors.New("Invalid request.")
-	handler.logger.Error(err)
-	return errorResponseWithStatus(err, http.StatusBadRequest), err
+// IsForced returns true if query parameter force=true is passed.
+func isForced(request events.APIGatewayProxyRequest) bool {
+	return strings.ToLower(request.QueryStringParameters["force"]) == "true"
 }
 
-func (handler *TimeTrackingRecordHandler) timeRangeForDate(layout, dateValue string) (*time.Time, *time.Time) {
+// WithoutRecord returns all passed records except the one with given key.
+func withoutRecord(records []timetracker.TimeTrackingRecord, key string) []timetracker.TimeTrackingRecord {
+	if key == "" {
+		return records
+	}
+	filteredRecords := []timetracker.TimeTrackingRecord{}
+	for _, record := range records {
+		if record.Key != key {
+			filteredRecords = append(filteredRecords, record)
+		}
+	}
+	return filteredRecords
+}
+
+// ValidateRecord checks if all mandatory values of a time tracking record are available
+// and if its timestamp is plausible. Returns passed record with a validated timestamp.
+func (handler *TimeTrackingRecordHandler) validateRecord(record timetracker.TimeTrackingRecord) (timetracker.TimeTrackingRecord, error) {
+	return validateRecordWith(record, handler.timestampValidator)
+}
+
+// ValidateRecordWith checks if all mandatory values of a time tracking record are available and if its
+// timestamp is plausible for passed validator. Returns passed record with a validated timestamp.
+func validateRecordWith(record timetracker.TimeTrackingRecord, timestampValidator *TimestampValidator) (timetracker.TimeTrackingRecord, error) {
+
+	if record.DeviceId == "" || record.Type == "" {
+		return record, errors.New("Invalid time tracking record.")
+	}
+
+	timestamp, err := timestampValidator.validate(record.Timestamp)
+	if err != nil {
+		return record, err
+	}
+	record.Timestamp = timestamp
+	return record, nil
+}
+
+// TimeRangeForDate returns the half-open range of passed date in given location. Start of next day is
+// calculated by date, so days of DST transitions have a duration of 23 or 25 hours.
+func (handler *TimeTrackingRecordHandler) timeRangeForDate(layout, dateValue string, location *time.Location) (*time.Time, *time.Time) {
 
 	date, err := time.Parse(layout, dateValue)
 	if err != nil {
@@ -152,31 +331,25 @@ func (handler *TimeTrackingRecordHandler) timeRangeForDate(layout, dateValue str
 		return nil, nil
 	}
 
-	location := time.Now().Location()
 	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, location)
-	return timePtr(start), timePtr(start.AddDate(0, 0, 1).Add(-1 * time.Second))
+	return timePtr(start), timePtr(time.Date(date.Year(), date.Month(), date.Day()+1, 0, 0, 0, 0, location))
 }
 
 func timePtr(t time.Time) *time.Time {
 	return &t
 }
 
-func queryExcapeKey(key string) string {
-	return url.QueryEscape(key)
-}
-
-func queryUnexcapeKey(key string) string {
-	unEscapedKey, _ := url.QueryUnescape(key)
-	return unEscapedKey
+func deviceIdsFromRequest(request events.APIGatewayProxyRequest) []string {
+	return deviceIdsFromQuery(request.QueryStringParameters)
 }
 
-func deviceIdsFromRequest(request events.APIGatewayProxyRequest) []string {
+func deviceIdsFromQuery(queryParams map[string]string) []string {
 
 	listOfDeviceIds := []string{}
-	if deviceId, ok := request.QueryStringParameters["deviceid"]; ok {
+	if deviceId, ok := queryParams["deviceid"]; ok {
 		listOfDeviceIds = append(listOfDeviceIds, deviceId)
 	}
-	if deviceIdStr, ok := request.QueryStringParameters["deviceids"]; ok {
+	if deviceIdStr, ok := queryParams["deviceids"]; ok {
 		if excapedDeviceIds, err := url.QueryUnescape(deviceIdStr); err == nil {
 			deviceIds := strings.Split(excapedDeviceIds, ",")
 			listOfDeviceIds = append(listOfDeviceIds, deviceIds...)
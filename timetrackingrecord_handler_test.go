@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"testing"
 	"time"
 
@@ -68,6 +69,16 @@ func (suite *TimeTrackingRecordHandlerTestSuite) TestListTimeTrackingRecords() {
 	suite.NotNil(err3_1)
 	suite.Equal(http.StatusBadRequest, res3_1.StatusCode)
 
+	request2_1 := suite.requestForTest("/timetrackingrecords", http.MethodGet)
+	request2_1.QueryStringParameters = map[string]string{"deviceid": "Device01", "from": "2021-12-31", "to": "2022-01-02"}
+	res2_1, err2_1 := handler.Process(request2_1)
+	suite.Nil(err2_1)
+	suite.Equal(http.StatusOK, res2_1.StatusCode)
+
+	var records2_1 []TimeTrackingRecord
+	suite.Nil(json.Unmarshal([]byte(res2_1.Body), &records2_1))
+	suite.Len(records2_1, 4)
+
 	request3_2 := suite.requestForTest("/timetrackingrecords", http.MethodGet)
 	request3_2.QueryStringParameters = map[string]string{"deviceid": "Device01"}
 	res3_2, err3_2 := handler.Process(request3_2)
@@ -106,7 +117,7 @@ func (suite *TimeTrackingRecordHandlerTestSuite) TestDeleteTimeTrackingRecords()
 	request2.QueryStringParameters = map[string]string{"id": records[0].Key}
 	res2, err2 := handler.Process(request2)
 	suite.Nil(err2)
-	suite.Equal(http.StatusNoContent, res2.StatusCode)
+	suite.Equal(http.StatusOK, res2.StatusCode)
 
 	res2_1, err2_1 := handler.Process(request1)
 	suite.Nil(err2_1)
@@ -116,6 +127,12 @@ func (suite *TimeTrackingRecordHandlerTestSuite) TestDeleteTimeTrackingRecords()
 	var records2 []TimeTrackingRecord
 	suite.Nil(json.Unmarshal([]byte(res2_1.Body), &records2))
 	suite.Len(records2, 1)
+
+	request3 := suite.requestForTest("/timetrackingrecords", http.MethodDelete)
+	request3.QueryStringParameters = map[string]string{"id": recordIdCodecForTest().encode("reports/2022/01/report.xlsx")}
+	res3, err3 := handler.Process(request3)
+	suite.NotNil(err3)
+	suite.Equal(http.StatusBadRequest, res3.StatusCode)
 }
 
 func (suite *TimeTrackingRecordHandlerTestSuite) TestJsonMarshalTime() {
@@ -129,23 +146,32 @@ func (suite *TimeTrackingRecordHandlerTestSuite) TestKeyEncoding() {
 
 	key := "timetracker/P5SJVQ20074C6774/2022/12/24/faa5260b-01d3-41ed-bde9-8eb7bbfe9c0a"
 
-	suite.Equal("timetracker%2FP5SJVQ20074C6774%2F2022%2F12%2F24%2Ffaa5260b-01d3-41ed-bde9-8eb7bbfe9c0a", queryExcapeKey(key))
-	suite.Equal(key, queryUnexcapeKey(queryExcapeKey(key)))
+	id := recordIdCodecForTest().encode(key)
+	suite.NotContains(id, "P5SJVQ20074C6774")
+	suite.Equal(url.PathEscape(id), id)
+	decodedKey, err := recordIdCodecForTest().decode(id)
+	suite.Nil(err)
+	suite.Equal(key, decodedKey)
 }
 
 func (suite *TimeTrackingRecordHandlerTestSuite) requestForTest(resource, httpMethod string) events.APIGatewayProxyRequest {
 	return events.APIGatewayProxyRequest{Resource: resource, HTTPMethod: httpMethod}
 }
 
+func timeTrackingRecordHandlerRequestForTest(httpMethod string) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{Resource: "/timetrackingrecords", HTTPMethod: httpMethod}
+}
+
 func timeTrackingRecordHandlerForTest() *TimeTrackingRecordHandler {
 	repo := timetracker.NewLocaLRepository()
-	return newTimeTrackingRecordHandler(repo, repo, loggerForTest())
+	handler, _ := newTimeTrackingRecordHandler(repo, repo, newTimestampValidator(configForTest()), recordIdCodecForTest(), newRecordTrashMock(), configForTest(), loggerForTest())
+	return handler
 }
 
 func prepareForTest(manager timetracker.TimeTrackingRecordManager) {
 
 	deviceId := "Device01"
-	location := time.Now().Location()
+	location := time.UTC
 	records := []timetracker.TimeTrackingRecord{}
 
 	records = append(records, timetracker.TimeTrackingRecord{
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	config "github.com/tommzn/go-config"
+)
+
+// NewTimezoneSettings reads the default timezone and timezones of single devices from passed config.
+// Timezones are IANA names, e.g. Europe/Berlin. UTC is used if there's no default timezone.
+func newTimezoneSettings(conf config.Config) (*TimezoneSettings, error) {
+
+	defaultLocation, err := time.LoadLocation(*conf.Get("hob.timezones.default", config.AsStringPtr("UTC")))
+	if err != nil {
+		return nil, err
+	}
+
+	deviceLocations := make(map[string]*time.Location)
+	for _, entry := range conf.GetAsSliceOfMaps("hob.timezones.devices") {
+		deviceId, ok := entry["deviceid"]
+		if !ok || deviceId == "" {
+			return nil, fmt.Errorf("Missing device id in timezone settings: %+v", entry)
+		}
+		location, err := time.LoadLocation(entry["timezone"])
+		if err != nil {
+			return nil, err
+		}
+		deviceLocations[deviceId] = location
+	}
+	return &TimezoneSettings{defaultLocation: defaultLocation, deviceLocations: deviceLocations}, nil
+}
+
+// LocationOfDevice returns the timezone of passed device or the default timezone.
+func (settings *TimezoneSettings) locationOfDevice(deviceId string) *time.Location {
+	if location, ok := settings.deviceLocations[deviceId]; ok {
+		return location
+	}
+	return settings.defaultLocation
+}
+
+// LocationFor returns the location used to determine day boundaries for passed query. A timezone
+// passed as tz query parameter takes precedence over the timezone of a requested device. The
+// default timezone is used if there're no or multiple devices with different timezones.
+func (handler *TimeTrackingRecordHandler) locationFor(queryParams map[string]string) (*time.Location, error) {
+
+	if tz, ok := queryParams["tz"]; ok {
+		location, err := time.LoadLocation(tz)
+		if err != nil || tz == "" {
+			return nil, errors.New("Invalid timezone: " + tz)
+		}
+		return location, nil
+	}
+
+	var deviceLocation *time.Location
+	for _, deviceId := range deviceIdsFromQuery(queryParams) {
+		location, ok := handler.deviceLocations[deviceId]
+		if !ok || (deviceLocation != nil && deviceLocation.String() != location.String()) {
+			return handler.defaultLocation, nil
+		}
+		deviceLocation = location
+	}
+	if deviceLocation != nil {
+		return deviceLocation, nil
+	}
+	return handler.defaultLocation, nil
+}
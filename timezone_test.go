@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	config "github.com/tommzn/go-config"
+)
+
+type TimezoneTestSuite struct {
+	suite.Suite
+}
+
+func TestTimezoneTestSuite(t *testing.T) {
+	suite.Run(t, new(TimezoneTestSuite))
+}
+
+func (suite *TimezoneTestSuite) TestTimezoneSettings() {
+
+	settings, err := newTimezoneSettings(configForTest())
+	suite.Nil(err)
+	suite.Equal("UTC", settings.defaultLocation.String())
+	suite.Len(settings.deviceLocations, 1)
+	suite.Equal("Europe/Berlin", settings.locationOfDevice("Device03").String())
+	suite.Equal("UTC", settings.locationOfDevice("Device01").String())
+
+	conf, _ := config.NewStaticConfigSource("hob:\n  timezones:\n    default: Europe/Berlin\n").Load()
+	settings2, err2 := newTimezoneSettings(conf)
+	suite.Nil(err2)
+	suite.Equal("Europe/Berlin", settings2.defaultLocation.String())
+	suite.Len(settings2.deviceLocations, 0)
+}
+
+func (suite *TimezoneTestSuite) TestInvalidTimezoneSettings() {
+
+	for _, yaml := range []string{
+		"hob:\n  timezones:\n    default: Europe/Xxx\n",
+		"hob:\n  timezones:\n    devices:\n      - deviceid: Device01\n        timezone: Europe/Xxx\n",
+		"hob:\n  timezones:\n    devices:\n      - timezone: Europe/Berlin\n",
+	} {
+		conf, _ := config.NewStaticConfigSource(yaml).Load()
+		_, err := newTimezoneSettings(conf)
+		suite.NotNil(err, "Expected error for %s", yaml)
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	log "github.com/tommzn/go-log"
+)
+
+// NewTimeTrackingTrashHandler returns a handler to list and restore deleted records. Trash and
+// time tracking repository are taken from passed record handler.
+func newTimeTrackingTrashHandler(recordHandler *TimeTrackingRecordHandler, logger log.Logger) *TimeTrackingTrashHandler {
+	return &TimeTrackingTrashHandler{
+		logger:        logger,
+		recordHandler: recordHandler,
+	}
+}
+
+// Process lists records in trash, GET /timetrackingrecords/trash, or restores a single record,
+// POST /timetrackingrecords/{id}/restore, identified by the trash id returned when it has been deleted.
+func (handler *TimeTrackingTrashHandler) Process(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	switch {
+	case request.Resource == "/timetrackingrecords/trash" && request.HTTPMethod == http.MethodGet:
+		return handler.listTrash(request)
+	case request.Resource == "/timetrackingrecords/{id}/restore" && request.HTTPMethod == http.MethodPost:
+		return handler.restore(request)
+	default:
+		err := errors.New("Unsupported HTTP method.")
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusMethodNotAllowed), err
+	}
+}
+
+// ListTrash returns a page of records in trash which aren't expired, optional filtered by a single device.
+// Records are ordered by device, most recent deleted records first. If there're more records, a link to
+// the next page is returned.
+func (handler *TimeTrackingTrashHandler) listTrash(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	deviceIds := deviceIdsFromRequest(request)
+	if len(deviceIds) > 1 {
+		err := errors.New("Trash can only be filtered by a single device.")
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusBadRequest), err
+	}
+	deviceId := ""
+	if len(deviceIds) == 1 {
+		deviceId = deviceIds[0]
+	}
+
+	limit := handler.recordHandler.defaultPageSize
+	if limitStr, ok := request.QueryStringParameters["limit"]; ok {
+		value, err := strconv.Atoi(limitStr)
+		if err != nil || value < 1 || value > handler.recordHandler.maxPageSize {
+			err := fmt.Errorf("Invalid limit, has to be between 1 and %d.", handler.recordHandler.maxPageSize)
+			handler.logger.Error(err)
+			return errorResponseWithStatus(err, http.StatusBadRequest), err
+		}
+		limit = value
+	}
+
+	after := ""
+	if cursor, ok := request.QueryStringParameters["cursor"]; ok {
+		id, err := handler.recordHandler.recordIds.decode(cursor)
+		if err != nil || (deviceId != "" && !strings.HasPrefix(id, deviceId+"/")) {
+			err := errors.New("Invalid cursor.")
+			handler.logger.Error(err)
+			return errorResponseWithStatus(err, http.StatusBadRequest), err
+		}
+		after = id
+	}
+
+	entries, err := handler.recordHandler.trash.List(deviceId, after, limit+1, time.Now())
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusInternalServerError), err
+	}
+
+	nextCursor := ""
+	if len(entries) > limit {
+		entries = entries[:limit]
+		nextCursor = handler.recordHandler.recordIds.encode(entries[limit-1].Id)
+	}
+	trashEntries := []TrashEntry{}
+	for _, entry := range entries {
+		trashEntries = append(trashEntries, handler.recordHandler.publicTrashEntry(entry))
+	}
+
+	responseContent, err := json.Marshal(trashEntries)
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusInternalServerError), err
+	}
+	response := responseWithContent(string(responseContent), http.StatusOK)
+	if nextCursor != "" {
+		response.Headers = map[string]string{"Link": nextLinkWithCursor(request, nextCursor)}
+	}
+	return response, nil
+}
+
+// Restore adds a record from trash to time tracking again and removes it from trash. Restored records
+// are checked for conflicts with existing records, same as new records.
+func (handler *TimeTrackingTrashHandler) restore(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	encodedId, ok := request.PathParameters["id"]
+	if !ok || encodedId == "" {
+		err := errors.New("Missing trash id.")
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusBadRequest), err
+	}
+	id, err := handler.recordHandler.recordIds.decode(encodedId)
+	if err != nil {
+		err := errors.New("Invalid trash id.")
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusBadRequest), err
+	}
+
+	entry, err := handler.recordHandler.trash.Get(id)
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusInternalServerError), err
+	}
+	if entry == nil || entry.Expires.Before(time.Now()) {
+		err := errors.New("Time tracking record not found in trash.")
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusNotFound), err
+	}
+
+	if response, err := handler.recordHandler.checkConflicts(entry.Record, ""); err != nil {
+		return response, err
+	}
+
+	restoredRecord, err := handler.recordHandler.restoreFromTrash(*entry)
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusInternalServerError), err
+	}
+
+	restoredRecord.Key = handler.recordHandler.recordIds.encode(restoredRecord.Key)
+	responseContent, err := json.Marshal(restoredRecord)
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusInternalServerError), err
+	}
+	return responseWithContent(string(responseContent), http.StatusCreated), nil
+}
+
+// NewTrashPurgeJob returns a job which removes expired records from passed trash.
+func newTrashPurgeJob(trash RecordTrash, logger log.Logger) *TrashPurgeJob {
+	return &TrashPurgeJob{
+		logger: logger,
+		trash:  trash,
+		now:    time.Now,
+	}
+}
+
+// Run removes all expired entries from trash. Expired entries are detected by their ids, they're not downloaded.
+func (job *TrashPurgeJob) Run(event events.CloudWatchEvent) error {
+
+	ids, err := job.trash.Expired(job.now())
+	if err != nil {
+		job.logger.Error(err)
+		return err
+	}
+
+	for _, id := range ids {
+		if err := job.trash.Remove(id); err != nil {
+			job.logger.Error(err)
+			return err
+		}
+	}
+	job.logger.Infof("Purged %d expired time tracking record(s) from trash", len(ids))
+	return nil
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/suite"
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+type TrashHandlerTestSuite struct {
+	suite.Suite
+}
+
+func TestTrashHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(TrashHandlerTestSuite))
+}
+
+func (suite *TrashHandlerTestSuite) TestDeleteAndRestoreRecord() {
+
+	recordHandler := timeTrackingRecordHandlerForTest()
+	handler := newTimeTrackingTrashHandler(recordHandler, loggerForTest())
+	record, _ := recordHandler.timeTrackingManager.Add(recordForTest(timetracker.WORKDAY, time.Date(2022, 1, 3, 8, 0, 0, 0, time.UTC)))
+
+	deleteRequest := timeTrackingRecordHandlerRequestForTest(http.MethodDelete)
+	deleteRequest.QueryStringParameters = map[string]string{"id": recordIdCodecForTest().encode(record.Key)}
+	response1, err1 := recordHandler.Process(deleteRequest)
+	suite.Nil(err1)
+	suite.Equal(http.StatusOK, response1.StatusCode)
+	suite.Len(suite.listRecords(recordHandler), 0)
+	var deletedEntry TrashEntry
+	suite.Nil(json.Unmarshal([]byte(response1.Body), &deletedEntry))
+	suite.Equal(recordIdCodecForTest().encode(record.Key), deletedEntry.Record.Key)
+
+	response2, err2 := recordHandler.Process(deleteRequest)
+	suite.NotNil(err2)
+	suite.Equal(http.StatusNotFound, response2.StatusCode)
+
+	entries := suite.listTrash(handler, map[string]string{"deviceid": "Device01"})
+	suite.Len(entries, 1)
+	suite.Equal(deletedEntry, entries[0])
+	suite.True(entries[0].Expires.After(entries[0].Deleted))
+	suite.Len(suite.listTrash(handler, map[string]string{"deviceid": "Device02"}), 0)
+
+	response3, err3 := handler.Process(suite.restoreRequest(entries[0].Id))
+	suite.Nil(err3)
+	suite.Equal(http.StatusCreated, response3.StatusCode)
+	suite.Len(suite.listRecords(recordHandler), 1)
+	suite.Len(suite.listTrash(handler, nil), 0)
+
+	response4, err4 := handler.Process(suite.restoreRequest(entries[0].Id))
+	suite.NotNil(err4)
+	suite.Equal(http.StatusNotFound, response4.StatusCode)
+
+	response5, err5 := handler.Process(events.APIGatewayProxyRequest{Resource: "/timetrackingrecords/trash", HTTPMethod: http.MethodDelete})
+	suite.NotNil(err5)
+	suite.Equal(http.StatusMethodNotAllowed, response5.StatusCode)
+
+	response6, err6 := handler.Process(suite.restoreRequest("xxx"))
+	suite.NotNil(err6)
+	suite.Equal(http.StatusBadRequest, response6.StatusCode)
+}
+
+func (suite *TrashHandlerTestSuite) TestListTrashPages() {
+
+	recordHandler := timeTrackingRecordHandlerForTest()
+	handler := newTimeTrackingTrashHandler(recordHandler, loggerForTest())
+	now := time.Now()
+	for idx := 0; idx < 3; idx++ {
+		record := recordForTest(timetracker.WORKDAY, time.Date(2022, 1, 3, 8+idx, 0, 0, 0, time.UTC))
+		record.Key = fmt.Sprintf("Device01/2022-01-03/%d", idx)
+		suite.Nil(recordHandler.trash.Put(newTrashEntry(record, now.Add(time.Duration(idx)*time.Minute), time.Hour)))
+	}
+	record := recordForTest(timetracker.WORKDAY, time.Date(2022, 1, 3, 8, 0, 0, 0, time.UTC))
+	record.DeviceId = "Device02"
+	suite.Nil(recordHandler.trash.Put(newTrashEntry(record, now, time.Hour)))
+	suite.Nil(recordHandler.trash.Put(newTrashEntry(record, now.Add(-2*time.Hour), time.Hour)))
+
+	response1, err1 := handler.Process(events.APIGatewayProxyRequest{Resource: "/timetrackingrecords/trash", HTTPMethod: http.MethodGet, QueryStringParameters: map[string]string{"deviceid": "Device01", "limit": "2"}})
+	suite.Nil(err1)
+	var entries1 []TrashEntry
+	suite.Nil(json.Unmarshal([]byte(response1.Body), &entries1))
+	suite.Len(entries1, 2)
+	suite.True(entries1[0].Deleted.After(entries1[1].Deleted))
+	suite.NotEqual("", response1.Headers["Link"])
+
+	cursor := suite.cursorFromLink(response1.Headers["Link"])
+	entries2 := suite.listTrash(handler, map[string]string{"deviceid": "Device01", "limit": "2", "cursor": cursor})
+	suite.Len(entries2, 1)
+	suite.True(entries1[1].Deleted.After(entries2[0].Deleted))
+
+	suite.Len(suite.listTrash(handler, nil), 4)
+	suite.Len(suite.listTrash(handler, map[string]string{"deviceid": "Device02"}), 1)
+
+	for _, queryParams := range []map[string]string{
+		{"deviceids": "Device01,Device02"},
+		{"limit": "0"},
+		{"cursor": "xxx"},
+		{"deviceid": "Device02", "cursor": cursor},
+	} {
+		response, err := handler.Process(events.APIGatewayProxyRequest{Resource: "/timetrackingrecords/trash", HTTPMethod: http.MethodGet, QueryStringParameters: queryParams})
+		suite.NotNil(err, "Expected error for %+v", queryParams)
+		suite.Equal(http.StatusBadRequest, response.StatusCode)
+	}
+}
+
+func (suite *TrashHandlerTestSuite) TestBulkDeleteMovesRecordsToTrash() {
+
+	recordHandler := timeTrackingRecordHandlerForTest()
+	handler := newTimeTrackingTrashHandler(recordHandler, loggerForTest())
+	recordHandler.timeTrackingManager.Add(recordForTest(timetracker.WORKDAY, time.Date(2022, 1, 3, 8, 0, 0, 0, time.UTC)))
+	recordHandler.timeTrackingManager.Add(recordForTest(timetracker.WORKDAY, time.Date(2022, 1, 3, 16, 0, 0, 0, time.UTC)))
+
+	request := timeTrackingRecordHandlerRequestForTest(http.MethodDelete)
+	request.QueryStringParameters = map[string]string{"deviceid": "Device01", "date": "2022-01-03", "dryRun": "true"}
+	response1, err1 := recordHandler.Process(request)
+	suite.Nil(err1)
+	var preview BulkDeleteResult
+	suite.Nil(json.Unmarshal([]byte(response1.Body), &preview))
+
+	request.QueryStringParameters = map[string]string{"deviceid": "Device01", "date": "2022-01-03", "token": preview.Token}
+	response2, err2 := recordHandler.Process(request)
+	suite.Nil(err2)
+	suite.Equal(http.StatusOK, response2.StatusCode)
+	suite.Len(suite.listRecords(recordHandler), 0)
+
+	entries := suite.listTrash(handler, nil)
+	suite.Len(entries, 2)
+	for _, entry := range entries {
+		response, err := handler.Process(suite.restoreRequest(entry.Id))
+		suite.Nil(err)
+		suite.Equal(http.StatusCreated, response.StatusCode)
+	}
+	suite.Len(suite.listRecords(recordHandler), 2)
+}
+
+func (suite *TrashHandlerTestSuite) TestPurgeExpiredRecords() {
+
+	trash := newRecordTrashMock()
+	now := time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC)
+	expiredEntry := newTrashEntry(recordForTest(timetracker.WORKDAY, now), now.Add(-48*time.Hour), 24*time.Hour)
+	entry := newTrashEntry(recordForTest(timetracker.WORKDAY, now), now.Add(-12*time.Hour), 24*time.Hour)
+	suite.Nil(trash.Put(expiredEntry))
+	suite.Nil(trash.Put(entry))
+
+	job := newTrashPurgeJob(trash, loggerForTest())
+	job.now = func() time.Time {
+		return now
+	}
+	suite.Nil(job.Run(events.CloudWatchEvent{}))
+
+	entries, err := trash.List("", "", 10, time.Time{})
+	suite.Nil(err)
+	suite.Len(entries, 1)
+	suite.Equal(entry.Id, entries[0].Id)
+
+	expires, err := trashEntryExpires(entry.Id)
+	suite.Nil(err)
+	suite.Equal(entry.Expires.Unix(), expires.Unix())
+	suite.True(isTrashEntryExpired("Device01/xxx", now))
+}
+
+func (suite *TrashHandlerTestSuite) TestNewRecordTrash() {
+
+	trash, err := newRecordTrash(configForTest())
+	suite.Nil(err)
+	suite.IsType(&S3RecordTrash{}, trash)
+	suite.Equal("trash", trash.(*S3RecordTrash).basePath)
+	suite.Equal("Device01/8355559999-abc", trash.(*S3RecordTrash).idOf(*trash.(*S3RecordTrash).objectKey("Device01/8355559999-abc")))
+
+	_, err2 := newRecordTrash(emptyConfigForTest())
+	suite.NotNil(err2)
+}
+
+func (suite *TrashHandlerTestSuite) listTrash(handler *TimeTrackingTrashHandler, queryParams map[string]string) []TrashEntry {
+
+	response, err := handler.Process(events.APIGatewayProxyRequest{Resource: "/timetrackingrecords/trash", HTTPMethod: http.MethodGet, QueryStringParameters: queryParams})
+	suite.Nil(err)
+	suite.Equal(http.StatusOK, response.StatusCode)
+	var entries []TrashEntry
+	suite.Nil(json.Unmarshal([]byte(response.Body), &entries))
+	return entries
+}
+
+func (suite *TrashHandlerTestSuite) listRecords(handler *TimeTrackingRecordHandler) []timetracker.TimeTrackingRecord {
+	records, err := handler.timeTracker.ListRecords("Device01", time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2022, 1, 31, 0, 0, 0, 0, time.UTC))
+	suite.Nil(err)
+	return records
+}
+
+func (suite *TrashHandlerTestSuite) cursorFromLink(link string) string {
+	parsedUrl, err := url.Parse(strings.TrimPrefix(strings.Split(link, ">")[0], "<"))
+	suite.Nil(err)
+	return parsedUrl.Query().Get("cursor")
+}
+
+func (suite *TrashHandlerTestSuite) restoreRequest(id string) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{Resource: "/timetrackingrecords/{id}/restore", HTTPMethod: http.MethodPost, PathParameters: map[string]string{"id": id}}
+}
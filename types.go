@@ -1,6 +1,14 @@
 package main
 
 import (
+	"container/list"
+	"context"
+	"crypto/cipher"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 	sqs "github.com/tommzn/aws-sqs"
 	log "github.com/tommzn/go-log"
 	timetracker "github.com/tommzn/hob-timetracker"
@@ -15,6 +23,17 @@ const (
 	LONG_PRESS   IotClickType = "LONG"
 )
 
+// WorkStatus is the state of a day derived from time tracking records.
+type WorkStatus string
+
+const (
+	WORKING       WorkStatus = "working"
+	DAY_CLOSED    WorkStatus = "closed"
+	NOT_STARTED   WorkStatus = "notstarted"
+	ABSENCE_ILL   WorkStatus = "illness"
+	ABSENCE_LEAVE WorkStatus = "vacation"
+)
+
 // RequestedResource is a resiurce used in API Gateway requests.
 type RequestedResource string
 
@@ -36,8 +55,97 @@ type RequestRouter struct {
 
 // CaptureRequestHandler process and persist captured request for time tracking records.
 type CaptureRequestHandler struct {
+	logger             log.Logger
+	timeTracker        timetracker.TimeTracker
+	clickTypeMapping   *ClickTypeMapping
+	timestampValidator *TimestampValidator
+
+	// Publisher is used to send capture events to a queue. Optional, in sync mode
+	// it's used as fallback if a capture can't be persisted.
+	publisher Publisher
+
+	// AsyncCapture defines if captures are send to a queue instead of persisting them directly.
+	asyncCapture bool
+
+	// RecordIds converts storage keys into opaque record ids.
+	recordIds *RecordIdCodec
+
+	// Timezones are used to determine the day of a capture.
+	timezones *TimezoneSettings
+}
+
+// TimezoneSettings contains the default timezone and timezones of single devices.
+type TimezoneSettings struct {
+
+	// DefaultLocation is used for all devices without a timezone.
+	defaultLocation *time.Location
+
+	// DeviceLocations contains timezones of single devices.
+	deviceLocations map[string]*time.Location
+}
+
+// CaptureQueueConsumer persists capture events and sends webhook deliveries received from AWS SQS.
+type CaptureQueueConsumer struct {
 	logger      log.Logger
 	timeTracker timetracker.TimeTracker
+
+	// WebhookNotifier sends webhook deliveries received from AWS SQS. Optional.
+	webhookNotifier *WebhookNotifier
+}
+
+// EventDispatcher forwards Lambda invocations to a request router or queue consumer, depending on event source.
+type EventDispatcher struct {
+	logger log.Logger
+
+	// RequestHandler processes requests from AWS API Gateway.
+	requestHandler Handler
+
+	// QueueConsumer processes messages from AWS SQS. Optional.
+	queueConsumer QueueConsumer
+
+	// ScheduledJobs are executed for scheduled events from AWS EventBridge. Optional.
+	scheduledJobs []ScheduledJob
+
+	// ContextReceivers get the context of each invocation. Optional.
+	contextReceivers []InvocationContextReceiver
+}
+
+// CaptureBatchRequestHandler process and persist a list of captured time tracking events.
+type CaptureBatchRequestHandler struct {
+	logger             log.Logger
+	timeTracker        TimeTrackingRepository
+	clickTypeMapping   *ClickTypeMapping
+	timestampValidator *TimestampValidator
+	recordIds          *RecordIdCodec
+	maxBatchSize       int
+}
+
+// TimestampValidator checks if timestamps of time tracking records are plausible.
+type TimestampValidator struct {
+
+	// MaxPast is the max duration a timestamp can be in the past.
+	maxPast time.Duration
+
+	// MaxFuture is the max duration a timestamp can be in the future.
+	maxFuture time.Duration
+
+	// MaxClockSkew is the max duration a timestamp can be ahead of server time
+	// to be clamped to server time.
+	maxClockSkew time.Duration
+
+	// Now returns current server time.
+	now func() time.Time
+}
+
+// ClickTypeMapping defines which time tracking record type is used for a click type.
+// Default mappings can be overwritten for single devices.
+type ClickTypeMapping struct {
+
+	// Defaults is the mapping of click types to record types used for all devices.
+	defaults map[IotClickType]timetracker.RecordType
+
+	// Devices contains device specific mappings, with device id as key.
+	devices map[string]map[IotClickType]timetracker.RecordType
 }
 
 // ReportGenerateRequestHandler will process request to generate and publish monthly time tracking reports.
@@ -46,11 +154,480 @@ type ReportGenerateRequestHandler struct {
 	publisher Publisher
 }
 
+// TimeTrackingSummaryHandler summarizes working time of time tracking records per day and week.
+type TimeTrackingSummaryHandler struct {
+	logger        log.Logger
+	recordHandler *TimeTrackingRecordHandler
+	calendar      *HolidayCalendar
+}
+
+// TimeTrackingBalanceHandler compares working time of time tracking records with target hours.
+type TimeTrackingBalanceHandler struct {
+	logger        log.Logger
+	recordHandler *TimeTrackingRecordHandler
+	calendar      *HolidayCalendar
+	targets       *WorkingTimeTargets
+
+	// StartDate is the first day of a running balance, e.g. 2022-01-01. Optional.
+	startDate *string
+
+	// MaxRange is the max duration of a time range records are listed for at once. Running balances are
+	// calculated in chunks of this duration.
+	maxRange time.Duration
+
+	// Now returns current server time.
+	now func() time.Time
+}
+
+// WorkingTimeTargets defines target hours per weekday, for all devices or for single devices.
+type WorkingTimeTargets struct {
+	defaults map[time.Weekday]time.Duration
+	devices  map[string]map[time.Weekday]time.Duration
+}
+
+// HolidayRequestHandler returns public holidays.
+type HolidayRequestHandler struct {
+	logger   log.Logger
+	calendar *HolidayCalendar
+}
+
+// HolidayCalendar looks up public holidays of a default or a requested country and region.
+type HolidayCalendar struct {
+	provider HolidayProvider
+	country  string
+	region   string
+}
+
+// StaticHolidayProvider returns public holidays from a static list, e.g. read from a file.
+type StaticHolidayProvider struct {
+	holidays []Holiday
+}
+
+// CalendarificHolidayProvider retrieves public holidays from Calendarific.
+type CalendarificHolidayProvider struct {
+	apiKey string
+	cache  map[string][]Holiday
+	mutex  sync.Mutex
+}
+
+// Holiday is a single public holiday.
+type Holiday struct {
+
+	// Date of a holiday, e.g. 2022-12-25.
+	Date string `json:"date"`
+
+	// Name of a holiday.
+	Name string `json:"name"`
+
+	// Country is an ISO 3166-1 country code.
+	Country string `json:"country"`
+
+	// Region of a country, e.g. BY. Holidays without a region apply to the whole country.
+	Region string `json:"region,omitempty"`
+}
+
+// TimeTrackingImportHandler imports time tracking records from CSV.
+type TimeTrackingImportHandler struct {
+	logger        log.Logger
+	recordHandler *TimeTrackingRecordHandler
+
+	// MaxRows is the max number of rows of an import.
+	maxRows int
+
+	// TimestampValidator checks timestamps of imported records, it accepts timestamps further in the past than for new records.
+	timestampValidator *TimestampValidator
+}
+
+// ImportColumns contains indices of CSV columns for record values.
+type ImportColumns struct {
+	deviceId, recordType, timestamp int
+}
+
+// ImportReport contains results for all rows of an import.
+type ImportReport struct {
+
+	// DryRun is true if records haven't been created.
+	DryRun bool `json:"dryrun"`
+
+	// Created is the number of created records.
+	Created int `json:"created"`
+
+	// Skipped is the number of duplicate records.
+	Skipped int `json:"skipped"`
+
+	// Failed is the number of invalid rows or rows which could not be persisted.
+	Failed int `json:"failed"`
+
+	// Rows contains a result for each row.
+	Rows []ImportRowResult `json:"rows"`
+}
+
+// ImportRowResult is the result of a single row of an import.
+type ImportRowResult struct {
+
+	// Row is the line number in CSV, starting with 1.
+	Row int `json:"row"`
+
+	// Status is created, skipped or failed.
+	Status ImportRowStatus `json:"status"`
+
+	// Opaque id of a created record.
+	Key string `json:"key,omitempty"`
+
+	// Error contains the reason if a row failed or has been skipped.
+	Error string `json:"error,omitempty"`
+}
+
+// ImportRowStatus is the result of an imported row.
+type ImportRowStatus string
+
+const (
+	IMPORT_CREATED ImportRowStatus = "created"
+	IMPORT_SKIPPED ImportRowStatus = "skipped"
+	IMPORT_FAILED  ImportRowStatus = "failed"
+)
+
+// CalendarFeedHandler renders time tracking records of a device as iCalendar feed.
+type CalendarFeedHandler struct {
+	sync.Mutex
+	logger        log.Logger
+	recordHandler *TimeTrackingRecordHandler
+
+	// Feeds contains all available calendar feeds per device id.
+	feeds map[string]CalendarFeed
+
+	// Past is the duration records are included in a feed before current time.
+	past time.Duration
+
+	// Future is the duration records are included in a feed after current time.
+	future time.Duration
+
+	// CacheTtl is the duration a rendered feed is reused for subsequent requests. Zero disables caching.
+	cacheTtl time.Duration
+
+	// Cache contains rendered feeds per device id and inclusion of work sessions.
+	cache map[string]cachedCalendarFeed
+
+	// Now returns current server time.
+	now func() time.Time
+}
+
+// CachedCalendarFeed is a rendered iCalendar feed.
+type cachedCalendarFeed struct {
+	content string
+	etag    string
+	expires time.Time
+}
+
+// CalendarFeed defines access to an iCalendar feed of a device.
+type CalendarFeed struct {
+
+	// DeviceId is the device a feed belongs to.
+	DeviceId string
+
+	// Token is a secret which has to be passed to access a feed.
+	Token string
+
+	// Workdays defines if work sessions are included by default.
+	Workdays bool
+}
+
+// CalendarEvent is a single event of an iCalendar feed.
+type CalendarEvent struct {
+
+	// Uid is an unique identifier of an event.
+	Uid string
+
+	// Summary is the title of an event.
+	Summary string
+
+	// Start of an event.
+	Start time.Time
+
+	// End of an event, exclusive.
+	End time.Time
+
+	// AllDay is true for events covering whole days, start and end are dates.
+	AllDay bool
+}
+
 // TimeTrackingRecordHandler is used to maintain time tracking records.
 type TimeTrackingRecordHandler struct {
 	logger              log.Logger
 	timeTrackingManager timetracker.TimeTrackingRecordManager
 	timeTracker         timetracker.TimeTracker
+	timestampValidator  *TimestampValidator
+
+	// MaxTimeRange is the max duration of a time range for record queries.
+	maxTimeRange time.Duration
+
+	// DefaultPageSize is the number of records returned if no limit is passed.
+	defaultPageSize int
+
+	// MaxPageSize is the max number of records which can be requested at once.
+	maxPageSize int
+
+	// TimezoneSettings are used to determine day boundaries of record queries.
+	*TimezoneSettings
+
+	// RecordKeyPrefix is the base path of all time tracking records. Optional.
+	recordKeyPrefix *string
+
+	// ConflictRules are checked before new records are added.
+	conflictRules *ConflictRules
+
+	// RecordIds converts storage keys into opaque record ids.
+	recordIds *RecordIdCodec
+
+	// Trash keeps deleted records until their retention expires.
+	trash RecordTrash
+
+	// TrashRetention is the duration deleted records are kept in trash.
+	trashRetention time.Duration
+
+	// BulkDeleteTokenTtl is the duration a confirmation token of a bulk delete preview is valid.
+	bulkDeleteTokenTtl time.Duration
+
+	// ListWorkers is the max number of devices whose records are listed in parallel.
+	listWorkers int
+
+	// ListTimeout is the max duration to list records of multiple devices. It's shortened to the
+	// deadline of current invocation.
+	listTimeout time.Duration
+
+	// ListChunk is the max time range listed at once for a device. Listings are cancelled between chunks.
+	listChunk time.Duration
+
+	// Ctx is the context of current Lambda invocation.
+	ctx context.Context
+}
+
+// RecordPage defines which records of a listing should be returned.
+type RecordPage struct {
+
+	// Limit is the max number of records for a page.
+	Limit int
+
+	// After is the last record of previous page. Records up to and including it, in current sort order, are skipped.
+	After *TimeTrackingRecord
+
+	// Sort order of records, timestamp, -timestamp or device.
+	Sort string
+
+	// Query is a fingerprint of current query.
+	Query string
+}
+
+// RecordFilter is used to filter records of a listing.
+type RecordFilter struct {
+
+	// Types is a list of record types. Records of all types are returned if it's empty.
+	Types []timetracker.RecordType
+
+	// Between is a time of day range records have to be captured in. Optional.
+	Between *TimeOfDayRange
+
+	// Location used to determine time of day of a record.
+	location *time.Location
+}
+
+// TimeTrackingSummary contains working time of a device for a time range.
+type TimeTrackingSummary struct {
+
+	// DeviceId is the device time tracking records have been captured by.
+	DeviceId string `json:"deviceid"`
+
+	// From is the start of the summarized time range.
+	From *APITime `json:"from"`
+
+	// To is the exclusive end of the summarized time range.
+	To *APITime `json:"to"`
+
+	// WorkingTime is the total working time in minutes.
+	WorkingTime int `json:"workingtime"`
+
+	// Days contains a summary for each day with time tracking records.
+	Days []DaySummary `json:"days"`
+
+	// Weeks contains working time per ISO week.
+	Weeks []WeekSummary `json:"weeks"`
+
+	// IncompleteDays is a list of days with an odd number of workday records.
+	IncompleteDays []string `json:"incompletedays"`
+
+	// VacationDays is a list of days with vacation records.
+	VacationDays []string `json:"vacationdays"`
+
+	// IllnessDays is a list of days with illness records.
+	IllnessDays []string `json:"illnessdays"`
+
+	// HolidayDays is a list of public holidays within a time range.
+	HolidayDays []string `json:"holidaydays"`
+}
+
+// DaySummary contains working time and absences of a single day.
+type DaySummary struct {
+
+	// Date of a day, e.g. 2022-01-31.
+	Date string `json:"date"`
+
+	// Sessions are pairs of workday records.
+	Sessions []WorkSession `json:"sessions"`
+
+	// WorkingTime is the sum of all sessions in minutes.
+	WorkingTime int `json:"workingtime"`
+
+	// Incomplete is true if there's an odd number of workday records.
+	// The last record without a pair isn't included in working time.
+	Incomplete bool `json:"incomplete,omitempty"`
+
+	// Absence is the record type of a vacation or illness record.
+	Absence timetracker.RecordType `json:"absence,omitempty"`
+
+	// Holiday is the name of a public holiday.
+	Holiday string `json:"holiday,omitempty"`
+}
+
+// WorkSession is a pair of workday records.
+type WorkSession struct {
+
+	// Start of a work session.
+	Start *APITime `json:"start"`
+
+	// End of a work session.
+	End *APITime `json:"end"`
+
+	// Duration of a work session in minutes.
+	Duration int `json:"duration"`
+}
+
+// WeekSummary contains working time of an ISO week.
+type WeekSummary struct {
+
+	// Week is an ISO week, e.g. 2022-W05.
+	Week string `json:"week"`
+
+	// WorkingTime is the sum of all working time of a week in minutes.
+	WorkingTime int `json:"workingtime"`
+
+	// WorkingDays is the number of days with working time.
+	WorkingDays int `json:"workingdays"`
+}
+
+// BalanceReport compares working time of a device with its target hours.
+type BalanceReport struct {
+
+	// DeviceId is the device time tracking records have been captured by.
+	DeviceId string `json:"deviceid"`
+
+	// From is the start of the requested time range.
+	From *APITime `json:"from"`
+
+	// To is the exclusive end of the requested time range.
+	To *APITime `json:"to"`
+
+	// Target is the sum of target hours of the requested time range in minutes.
+	Target int `json:"target"`
+
+	// WorkingTime is the sum of working time of the requested time range in minutes.
+	WorkingTime int `json:"workingtime"`
+
+	// Balance is over time, or under time if negative, of the requested time range in minutes.
+	Balance int `json:"balance"`
+
+	// RunningBalance is over or under time in minutes since start date until end of requested time range.
+	RunningBalance int `json:"runningbalance"`
+
+	// Since is the start date of a running balance.
+	Since string `json:"since"`
+
+	// Days contains balances of each day of the requested time range.
+	Days []DayBalance `json:"days"`
+
+	// Months contains balances of each month of the requested time range.
+	Months []MonthBalance `json:"months"`
+}
+
+// DayBalance compares working time of a single day with its target hours.
+type DayBalance struct {
+
+	// Date of a day, e.g. 2022-01-31.
+	Date string `json:"date"`
+
+	// Target hours of a day in minutes.
+	Target int `json:"target"`
+
+	// WorkingTime of a day in minutes.
+	WorkingTime int `json:"workingtime"`
+
+	// Balance is over time, or under time if negative, in minutes.
+	// Target hours of vacation or illness days are fulfilled.
+	Balance int `json:"balance"`
+
+	// Absence is the record type of a vacation or illness record.
+	Absence timetracker.RecordType `json:"absence,omitempty"`
+
+	// Holiday is the name of a public holiday. There're no target hours on holidays.
+	Holiday string `json:"holiday,omitempty"`
+}
+
+// MonthBalance compares working time of a month with its target hours.
+type MonthBalance struct {
+
+	// Month, e.g. 2022-01.
+	Month string `json:"month"`
+
+	// Target hours of a month in minutes.
+	Target int `json:"target"`
+
+	// WorkingTime of a month in minutes.
+	WorkingTime int `json:"workingtime"`
+
+	// Balance is over time, or under time if negative, in minutes.
+	Balance int `json:"balance"`
+}
+
+// BulkDeleteResult lists all time tracking records affected by a bulk delete.
+type BulkDeleteResult struct {
+
+	// DryRun is true for a preview, records haven't been deleted.
+	DryRun bool `json:"dryrun"`
+
+	// Count is the number of affected time tracking records.
+	Count int `json:"count"`
+
+	// Keys of all affected time tracking records.
+	Keys []string `json:"keys"`
+
+	// Token has to be passed to confirm deletion of previewed records.
+	Token string `json:"token,omitempty"`
+
+	// Deleted contains ids of all records deleted before a bulk delete has failed.
+	Deleted []string `json:"deleted,omitempty"`
+
+	// Error describes why a bulk delete has failed.
+	Error string `json:"error,omitempty"`
+}
+
+// TimeOfDayRange is a range between two times of day, as duration since midnight.
+type TimeOfDayRange struct {
+	From, To time.Duration
+}
+
+// RecordCursor points to the next page of a record listing by the last record of a page.
+type RecordCursor struct {
+
+	// Timestamp of the last record of a page.
+	Timestamp time.Time `json:"t"`
+
+	// DeviceId of the last record of a page.
+	DeviceId string `json:"d"`
+
+	// Key is the id of the last record of a page.
+	Key string `json:"k"`
+
+	// Query is a fingerprint of the query a cursor belongs to.
+	Query string `json:"q"`
 }
 
 // TimeTrackingCapture os a single captured time tracking event.
@@ -64,6 +641,145 @@ type TimeTrackingCapture struct {
 
 	// Timestamp is the point in time a time tracking event has occured.
 	Timestamp *APITime `json:"timestamp,omitempty"`
+
+	// RecordType is an optional record type which takes precedence over the click type mapping.
+	RecordType timetracker.RecordType `json:"recordtype,omitempty"`
+
+	// RemainingLife is the reported remaining battery life of a device in percent, if available.
+	RemainingLife *float64 `json:"remaininglife,omitempty"`
+}
+
+// CaptureResponse is returned for a captured time tracking event.
+type CaptureResponse struct {
+
+	// Opaque id of a created time tracking record, if available.
+	Key string `json:"key,omitempty"`
+
+	// DeviceId is an identifier of a device which captures a time tracking record.
+	DeviceId string `json:"deviceid"`
+
+	// RecordType is the resolved type of a captured time tracking record.
+	RecordType timetracker.RecordType `json:"recordtype"`
+
+	// Timestamp a time tracking record has been captured for.
+	Timestamp *APITime `json:"timestamp"`
+
+	// ServerTime is current time of the server, can be used by devices to correct their clocks.
+	ServerTime *APITime `json:"servertime"`
+
+	// State is the derived work state for the day of a captured time tracking record.
+	State *WorkState `json:"state,omitempty"`
+}
+
+// WorkState is the state of a day derived from its time tracking records.
+type WorkState struct {
+
+	// Status of a day, e.g. working or closed.
+	Status WorkStatus `json:"status"`
+
+	// Since is the point in time current work has been started, if status is working.
+	Since *APITime `json:"since,omitempty"`
+
+	// WorkingTime is the total working time of a day, in minutes.
+	WorkingTime int `json:"workingtime"`
+
+	// Message is a human readable description of a state, e.g. "working since 08:12".
+	Message string `json:"message"`
+}
+
+// CaptureBatchItemResult is the result of persisting a single item of a capture batch.
+type CaptureBatchItemResult struct {
+
+	// Index of an item in a capture batch.
+	Index int `json:"index"`
+
+	// Status is a HTTP status code for a single item.
+	Status int `json:"status"`
+
+	// Opaque id of a created time tracking record, if available.
+	Key string `json:"key,omitempty"`
+
+	// RecordType is the record type of a created time tracking record.
+	RecordType timetracker.RecordType `json:"recordtype,omitempty"`
+
+	// Timestamp a time tracking record has been created for.
+	Timestamp *APITime `json:"timestamp,omitempty"`
+
+	// Error contains a message if an item could not be persisted.
+	Error string `json:"error,omitempty"`
+}
+
+// IotOneClickEvent is the event send by AWS IoT 1-Click if a button has been clicked.
+type IotOneClickEvent struct {
+
+	// DeviceInfo contains details about the device which has been clicked.
+	DeviceInfo IotDeviceInfo `json:"deviceInfo"`
+
+	// DeviceEvent contains the click event.
+	DeviceEvent IotDeviceEvent `json:"deviceEvent"`
+
+	// PlacementInfo contains project and placement a device is assigned to.
+	PlacementInfo *IotPlacementInfo `json:"placementInfo,omitempty"`
+}
+
+// IotDeviceInfo describes a device in an AWS IoT 1-Click event.
+type IotDeviceInfo struct {
+
+	// DeviceId is the id, serial number, of a device.
+	DeviceId string `json:"deviceId"`
+
+	// Type of a device, e.g. button.
+	Type string `json:"type"`
+
+	// RemainingLife is the remaining battery life in percent.
+	RemainingLife *float64 `json:"remainingLife,omitempty"`
+
+	// Attributes are custom attributes assigned to a device.
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// IotDeviceEvent contains a button click in an AWS IoT 1-Click event.
+type IotDeviceEvent struct {
+
+	// ButtonClicked is the click on a button.
+	ButtonClicked IotButtonClicked `json:"buttonClicked"`
+}
+
+// IotButtonClicked is a single click on an AWS IoT 1-Click button.
+type IotButtonClicked struct {
+
+	// ClickType, single, double or long press.
+	ClickType IotClickType `json:"clickType"`
+
+	// ReportedTime is the point in time a button has been clicked.
+	ReportedTime *APITime `json:"reportedTime,omitempty"`
+}
+
+// IotPlacementInfo describes a placement in an AWS IoT 1-Click project.
+type IotPlacementInfo struct {
+
+	// ProjectName is the name of a AWS IoT 1-Click project.
+	ProjectName string `json:"projectName"`
+
+	// PlacementName is the name of a placement within a project.
+	PlacementName string `json:"placementName"`
+
+	// Attributes are custom attributes of a placement. Attributes "deviceid" and
+	// "recordtype" can be used to override device id or record type of a capture.
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// IotCoreButtonEvent is the event published by an AWS IoT button via AWS IoT Core.
+type IotCoreButtonEvent struct {
+
+	// SerialNumber of a button.
+	SerialNumber string `json:"serialNumber"`
+
+	// BatteryVoltage is the current battery voltage, e.g. 1590mV.
+	BatteryVoltage string `json:"batteryVoltage"`
+
+	// ClickType, single, double or long press.
+	ClickType IotClickType `json:"clickType"`
 }
 
 // TimeTrackingReport os a single captured time tracking event.
@@ -82,6 +798,68 @@ type TimeTrackingRecord struct {
 	Timestamp *APITime
 }
 
+// ConflictRules defines which records conflict with existing records of a day.
+type ConflictRules struct {
+
+	// Duplicates rejects records with same type and timestamp as an existing record.
+	duplicates bool
+
+	// MixedAbsence rejects vacation or illness records on days with workday records and vice versa.
+	mixedAbsence bool
+
+	// MaxClicks is the max number of workday records of a day. Disabled if it's zero.
+	maxClicks int
+
+	// ManagerGroups are groups of authorized users which are allowed to force adding conflicting records.
+	managerGroups []string
+}
+
+// RecordConflict describes a violated conflict rule.
+type RecordConflict struct {
+
+	// Rule is the name of a violated rule, e.g. duplicate.
+	Rule ConflictRule `json:"rule"`
+
+	// Message is a human readable description of a conflict.
+	Message string `json:"message"`
+
+	// Keys of existing records causing a conflict.
+	Keys []string `json:"keys"`
+}
+
+// ConflictRule is the name of a conflict rule.
+type ConflictRule string
+
+const (
+	CONFLICT_DUPLICATE     ConflictRule = "duplicate"
+	CONFLICT_MIXED_ABSENCE ConflictRule = "mixedabsence"
+	CONFLICT_MAX_CLICKS    ConflictRule = "maxclicks"
+)
+
+// ConflictResponse is returned if a new record conflicts with existing records.
+type ConflictResponse struct {
+
+	// Error is a message of a conflict response.
+	Error string `json:"error"`
+
+	// Conflicts contains all violated rules.
+	Conflicts []RecordConflict `json:"conflicts"`
+}
+
+// TimeTrackingRecordPatch contains values to change a time tracking record.
+// Values which are not passed, nil, will not be changed.
+type TimeTrackingRecordPatch struct {
+
+	// DeviceId is an identifier of a device which captures a time tracking record.
+	DeviceId *string
+
+	// Type of a time tracking event.
+	Type *timetracker.RecordType
+
+	// Timestamp is the point in time a time tracking event has occurred.
+	Timestamp *time.Time
+}
+
 // ReportGenerateRequest is used to triiger report generation for a specific year and month.
 type ReportGenerateRequest struct {
 
@@ -114,7 +892,309 @@ type SqsPublisher struct {
 	queue string
 }
 
+// IdempotentHandler replays stored responses for requests with an already used idempotency key.
+type IdempotentHandler struct {
+	logger log.Logger
+
+	// Handler requests are forwarded to if there's no stored response for an idempotency key.
+	handler Handler
+
+	// Store persists responses for idempotency keys.
+	store IdempotencyStore
+
+	// Ttl defines how long responses are replayed.
+	ttl time.Duration
+}
+
+// IdempotencyRecord is a stored response for an idempotency key.
+type IdempotencyRecord struct {
+
+	// RequestHash is a hash of the request body a response has been created for.
+	RequestHash string `json:"requesthash"`
+
+	// StatusCode of a stored response.
+	StatusCode int `json:"statuscode"`
+
+	// Headers of a stored response.
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Body of a stored response.
+	Body string `json:"body"`
+
+	// Expires is the point in time a stored response will no longer be replayed.
+	Expires time.Time `json:"expires"`
+
+	// InProgress is set for keys reserved by a request which hasn't been finished, yet.
+	InProgress bool `json:"inprogress,omitempty"`
+}
+
+// InMemoryIdempotencyStore keeps responses for idempotency keys in memory of a single container.
+type InMemoryIdempotencyStore struct {
+	sync.Mutex
+	records map[string]IdempotencyRecord
+
+	// MaxEntries is the max number of stored records. Expired records, and records which expire first,
+	// are removed if it's reached.
+	maxEntries int
+}
+
+// S3IdempotencyStore persists responses for idempotency keys in a AWS S3 bucket.
+type S3IdempotencyStore struct {
+	s3Client s3iface.S3API
+	bucket   string
+	basePath string
+}
+
+// WebhookEventType is the type of a time tracking event send to webhooks.
+type WebhookEventType string
+
+const (
+	RECORD_CREATED WebhookEventType = "record.created"
+	RECORD_DELETED WebhookEventType = "record.deleted"
+)
+
+// WebhookEvent is send to webhook subscribers.
+type WebhookEvent struct {
+
+	// Event is the type of this event.
+	Event WebhookEventType `json:"event"`
+
+	// Opaque id of a time tracking record, if available.
+	Key string `json:"key,omitempty"`
+
+	// DeviceId is an identifier of a device which captures a time tracking record, if available.
+	DeviceId string `json:"deviceid,omitempty"`
+
+	// RecordType is the type of a time tracking record, if available.
+	RecordType timetracker.RecordType `json:"recordtype,omitempty"`
+
+	// Timestamp of a time tracking record, if available.
+	Timestamp *APITime `json:"timestamp,omitempty"`
+
+	// Text is a human readable description of this event, used by chat tools like Slack or Teams.
+	Text string `json:"text"`
+}
+
+// WebhookSubscription defines a webhook and the events which should be send to it.
+type WebhookSubscription struct {
+
+	// Url of a webhook.
+	Url string
+
+	// Events is a list of event types send to a webhook. All events are send if it's empty.
+	Events []WebhookEventType
+
+	// RecordTypes is a list of record types send to a webhook. All record types are send if it's empty.
+	RecordTypes []timetracker.RecordType
+
+	// DeviceIds is a list of devices events are send for. Events of all devices are send if it's empty.
+	DeviceIds []string
+
+	// Secret is used to sign payloads with HMAC SHA256. Optional.
+	Secret string
+}
+
+// WebhookDelivery is a single entry in the delivery log of webhooks.
+type WebhookDelivery struct {
+
+	// Url of a webhook.
+	Url string `json:"url"`
+
+	// Event which has been send.
+	Event WebhookEventType `json:"event"`
+
+	// Key is the opaque id of the time tracking record of an event, if available.
+	Key string `json:"key,omitempty"`
+
+	// Attempts is the number of delivery attempts.
+	Attempts int `json:"attempts"`
+
+	// StatusCode of last delivery attempt.
+	StatusCode int `json:"statuscode"`
+
+	// Error of last delivery attempt, if it has failed.
+	Error string `json:"error,omitempty"`
+
+	// Timestamp of last delivery attempt.
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// WebhookNotifier sends time tracking events to webhook subscriptions.
+type WebhookNotifier struct {
+	logger        log.Logger
+	subscriptions []WebhookSubscription
+	httpClient    *http.Client
+
+	// MaxAttempts is the number of attempts to deliver an event to a webhook.
+	maxAttempts int
+
+	// Backoff is the wait time after first failed delivery attempt, doubled for each further attempt.
+	backoff time.Duration
+
+	// Publisher sends deliveries to a queue, so they're not lost if a Lambda container is frozen.
+	// Events are delivered before a request returns if there's no publisher.
+	publisher Publisher
+
+	// DeliveryLog persists all deliveries.
+	deliveryLog WebhookDeliveryLog
+}
+
+// InMemoryWebhookDeliveryLog keeps webhook deliveries in memory of a single container.
+type InMemoryWebhookDeliveryLog struct {
+	sync.Mutex
+	deliveries []WebhookDelivery
+
+	// MaxEntries is the max number of deliveries, oldest deliveries are removed if it's reached.
+	maxEntries int
+}
+
+// S3WebhookDeliveryLog persists webhook deliveries in a AWS S3 bucket.
+type S3WebhookDeliveryLog struct {
+	s3Client s3iface.S3API
+	bucket   string
+	basePath string
+}
+
+// WebhookDeliveryHandler lists logged webhook deliveries.
+type WebhookDeliveryHandler struct {
+	logger      log.Logger
+	deliveryLog WebhookDeliveryLog
+
+	// MaxLimit is the max number of deliveries returned by a single request.
+	maxLimit int
+}
+
+// NotifyingRepository sends notifications for created and deleted time tracking records.
+type NotifyingRepository struct {
+	repository TimeTrackingRepository
+	notifier   Notifier
+	recordIds  *RecordIdCodec
+	basePath   *string
+}
+
 // AwsConfig used for different AWS clients.
 type awsConfig struct {
 	region, bucket, basePath *string
 }
+
+// TrashEntry is a deleted time tracking record kept in trash until its retention expires.
+type TrashEntry struct {
+
+	// Id identifies a record in trash.
+	Id string `json:"id"`
+
+	// Record is the deleted time tracking record.
+	Record timetracker.TimeTrackingRecord `json:"record"`
+
+	// Deleted is the point in time a record has been moved to trash.
+	Deleted time.Time `json:"deleted"`
+
+	// Expires is the point in time a record will be purged from trash.
+	Expires time.Time `json:"expires"`
+}
+
+// S3RecordTrash persists deleted time tracking records in a AWS S3 bucket.
+type S3RecordTrash struct {
+	s3Client s3iface.S3API
+	bucket   string
+	basePath string
+}
+
+// TimeTrackingTrashHandler lists and restores deleted time tracking records.
+type TimeTrackingTrashHandler struct {
+	logger        log.Logger
+	recordHandler *TimeTrackingRecordHandler
+}
+
+// TrashPurgeJob removes expired records from trash. It's triggered by scheduled events.
+type TrashPurgeJob struct {
+	logger log.Logger
+	trash  RecordTrash
+	now    func() time.Time
+}
+
+// DeviceRecords contains listed records of a single device or an error if listing has failed.
+type DeviceRecords struct {
+
+	// DeviceId is the id of a listed device.
+	DeviceId string
+
+	// Records of a device in order returned by the repository.
+	Records []timetracker.TimeTrackingRecord
+
+	// Err is set if records of a device couldn't be listed.
+	Err error
+}
+
+// CachingRepository caches listed time tracking records in memory. Cached records of a device are
+// invalidated if records of this device are created or deleted through this repository.
+type CachingRepository struct {
+	sync.Mutex
+	repository TimeTrackingRepository
+
+	// BasePath of record keys, used to get device ids of deleted records. Optional.
+	basePath *string
+
+	// Ttl is the max duration records are cached.
+	ttl time.Duration
+
+	// MaxEntries is the max number of cached listings. Least recently used listings are evicted first.
+	maxEntries int
+
+	entries map[string]*list.Element
+	lru     *list.List
+
+	// Generations and epoch are incremented on invalidation of a single device or of all devices, so
+	// listings started before an invalidation are not cached.
+	generations map[string]uint64
+	epoch       uint64
+
+	stats CacheStats
+	now   func() time.Time
+}
+
+// CachedRecords is a cached result of a record listing.
+type cachedRecords struct {
+	key      string
+	deviceId string
+	records  []timetracker.TimeTrackingRecord
+	expires  time.Time
+}
+
+// CacheStats contains metrics of a record cache.
+type CacheStats struct {
+
+	// Hits is the number of listings served from cache.
+	Hits int64 `json:"hits"`
+
+	// Misses is the number of listings passed to the underlying repository.
+	Misses int64 `json:"misses"`
+
+	// HitRatio is the share of listings served from cache.
+	HitRatio float64 `json:"hitratio"`
+
+	// Evictions is the number of listings removed from cache to stay within max entries.
+	Evictions int64 `json:"evictions"`
+
+	// Invalidations is the number of listings removed from cache because of changed records.
+	Invalidations int64 `json:"invalidations"`
+
+	// Entries is the number of currently cached listings.
+	Entries int `json:"entries"`
+}
+
+// CacheStatsHandler returns metrics of a record cache.
+type CacheStatsHandler struct {
+	logger log.Logger
+	cache  *CachingRepository
+}
+
+// RecordIdCodec converts storage keys of time tracking records into opaque ids and back. Ids are
+// encrypted and authenticated, so they don't reveal storage layout and can't be crafted by clients.
+type RecordIdCodec struct {
+	aead  cipher.AEAD
+	ivKey []byte
+
+	// SigningKey is used to sign values passed to clients, e.g. confirmation tokens.
+	signingKey []byte
+}
@@ -1,9 +1,14 @@
 package main
 
 import (
+	"strings"
+	"time"
+
 	"github.com/aws/aws-lambda-go/events"
 )
 
+const serverTimeHeader = "X-Server-Time"
+
 // SuccessfulResponse returns a response with status code 200.
 func successfulResponse() events.APIGatewayProxyResponse {
 	return events.APIGatewayProxyResponse{StatusCode: 200}
@@ -34,3 +39,22 @@ func errorResponseWithStatus(err error, statusCode int) events.APIGatewayProxyRe
 		Body:       err.Error(),
 	}
 }
+
+// WithServerTime adds passed server time as header to given response, so clients are able to correct their clocks.
+func withServerTime(response events.APIGatewayProxyResponse, serverTime time.Time) events.APIGatewayProxyResponse {
+	if response.Headers == nil {
+		response.Headers = make(map[string]string)
+	}
+	response.Headers[serverTimeHeader] = serverTime.UTC().Format(time.RFC3339)
+	return response
+}
+
+// HeaderValue returns the value of a request header. Header names are case insensitive.
+func headerValue(request events.APIGatewayProxyRequest, name string) (string, bool) {
+	for key, value := range request.Headers {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+	return "", false
+}
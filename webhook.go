@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	config "github.com/tommzn/go-config"
+	log "github.com/tommzn/go-log"
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+const (
+	webhookEventHeader     = "X-Hob-Event"
+	webhookSignatureHeader = "X-Hob-Signature"
+)
+
+// NewWebhookNotifier creates a notifier with webhook subscriptions from passed config. Deliveries are published
+// to a queue by passed publisher and send by the queue consumer. Returns with an error if there're subscriptions,
+// but no publisher, because deliveries in background would be lost if a Lambda container is frozen.
+func newWebhookNotifier(conf config.Config, publisher Publisher, deliveryLog WebhookDeliveryLog, logger log.Logger) (*WebhookNotifier, error) {
+
+	subscriptions := []WebhookSubscription{}
+	for _, entry := range conf.GetAsSliceOfMaps("hob.webhooks.subscriptions") {
+		if entry["url"] == "" {
+			logger.Errorf("Skip webhook subscription without url: %+v", entry)
+			continue
+		}
+		subscription := WebhookSubscription{Url: entry["url"], Secret: entry["secret"], DeviceIds: splitList(entry["deviceids"])}
+		for _, event := range splitList(entry["events"]) {
+			subscription.Events = append(subscription.Events, WebhookEventType(strings.ToLower(event)))
+		}
+		for _, recordType := range splitList(entry["recordtypes"]) {
+			subscription.RecordTypes = append(subscription.RecordTypes, timetracker.RecordType(strings.ToLower(recordType)))
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+	if len(subscriptions) > 0 && publisher == nil {
+		return nil, errors.New("Webhook subscriptions require a webhook or capture queue!")
+	}
+
+	timeout := conf.GetAsDuration("hob.webhooks.timeout", config.AsDurationPtr(5*time.Second))
+	maxAttempts := conf.GetAsInt("hob.webhooks.maxattempts", config.AsIntPtr(3))
+	backoff := conf.GetAsDuration("hob.webhooks.backoff", config.AsDurationPtr(1*time.Second))
+	notifier := newWebhookNotifierWithSubscriptions(subscriptions, &http.Client{Timeout: *timeout}, *maxAttempts, *backoff, deliveryLog, logger)
+	notifier.publisher = publisher
+	return notifier, nil
+}
+
+// NewWebhookNotifierWithSubscriptions creates a notifier for passed subscriptions, which delivers events
+// before Notify returns. All deliveries are added to passed log.
+func newWebhookNotifierWithSubscriptions(subscriptions []WebhookSubscription, httpClient *http.Client, maxAttempts int, backoff time.Duration, deliveryLog WebhookDeliveryLog, logger log.Logger) *WebhookNotifier {
+	return &WebhookNotifier{
+		logger:        logger,
+		subscriptions: subscriptions,
+		httpClient:    httpClient,
+		maxAttempts:   maxAttempts,
+		backoff:       backoff,
+		deliveryLog:   deliveryLog,
+	}
+}
+
+// Notify sends passed event to all matching webhooks. If there's a publisher, a delivery for each matching
+// webhook is published to a queue. Otherwise, or if publishing fails, events are delivered before Notify returns,
+// because a Lambda container may be frozen afterwards.
+func (notifier *WebhookNotifier) Notify(event WebhookEvent) {
+
+	if event.Text == "" {
+		event.Text = webhookEventText(event)
+	}
+	for _, subscription := range notifier.subscriptions {
+		if subscription.matches(event) && !notifier.publish(subscription, event) {
+			notifier.deliver(subscription, event)
+		}
+	}
+}
+
+// DeliverQueued sends passed event to the subscription with given url. It's used by queue consumers to send
+// published deliveries. Returns with an error if all delivery attempts fail, so the delivery can be retried.
+// Deliveries for unknown subscriptions are dropped.
+func (notifier *WebhookNotifier) deliverQueued(url string, event WebhookEvent) error {
+
+	for _, subscription := range notifier.subscriptions {
+		if subscription.Url == url {
+			return notifier.deliver(subscription, event)
+		}
+	}
+	notifier.logger.Errorf("Drop webhook delivery for unknown subscription: %s", url)
+	return nil
+}
+
+// Publish sends a delivery of passed event to given subscription to the queue. Returns false if there's
+// no publisher or if publishing fails.
+func (notifier *WebhookNotifier) publish(subscription WebhookSubscription, event WebhookEvent) bool {
+
+	if notifier.publisher == nil {
+		return false
+	}
+	deliveryEvent, err := newWebhookDeliveryEvent(subscription, event)
+	if err == nil {
+		err = notifier.publisher.Send(deliveryEvent)
+	}
+	if err != nil {
+		notifier.logger.Errorf("Unable to publish webhook delivery to %s, reason: %s", subscription.Url, err)
+		return false
+	}
+	return true
+}
+
+// Deliver sends passed event to a webhook. Failed attempts are retried with exponential backoff.
+// Returns with the error of last attempt if all attempts fail.
+func (notifier *WebhookNotifier) deliver(subscription WebhookSubscription, event WebhookEvent) error {
+
+	delivery := WebhookDelivery{Url: subscription.Url, Event: event.Event, Key: event.Key, Timestamp: time.Now()}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		delivery.Error = err.Error()
+		notifier.logDelivery(delivery)
+		return err
+	}
+
+	backoff := notifier.backoff
+	for delivery.Attempts < notifier.maxAttempts {
+
+		if delivery.Attempts > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		delivery.Attempts++
+		delivery.Timestamp = time.Now()
+		delivery.StatusCode, err = notifier.send(subscription, event.Event, payload)
+		if err == nil {
+			delivery.Error = ""
+			break
+		}
+		delivery.Error = err.Error()
+		notifier.logger.Errorf("Webhook delivery to %s failed, attempt: %d, reason: %s", subscription.Url, delivery.Attempts, err)
+	}
+	notifier.logDelivery(delivery)
+	return err
+}
+
+// Send posts given payload to a webhook. Payload is signed if there's a secret for a subscription.
+func (notifier *WebhookNotifier) send(subscription WebhookSubscription, eventType WebhookEventType, payload []byte) (int, error) {
+
+	request, err := http.NewRequest(http.MethodPost, subscription.Url, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(webhookEventHeader, string(eventType))
+	if subscription.Secret != "" {
+		request.Header.Set(webhookSignatureHeader, "sha256="+signPayload(payload, subscription.Secret))
+	}
+
+	response, err := notifier.httpClient.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, fmt.Errorf("Unexpected status code: %d", response.StatusCode)
+	}
+	return response.StatusCode, nil
+}
+
+// LogDelivery adds passed delivery to delivery log. Errors of delivery log are only logged, they don't affect a delivery.
+func (notifier *WebhookNotifier) logDelivery(delivery WebhookDelivery) {
+
+	notifier.logger.Infof("Webhook delivery, url: %s, event: %s, attempts: %d, status: %d", delivery.Url, delivery.Event, delivery.Attempts, delivery.StatusCode)
+	if notifier.deliveryLog == nil {
+		return
+	}
+	if err := notifier.deliveryLog.Put(delivery); err != nil {
+		notifier.logger.Errorf("Unable to log webhook delivery to %s, reason: %s", delivery.Url, err)
+	}
+}
+
+// Matches returns true if passed event matches event types, record types and devices of a subscription.
+func (subscription WebhookSubscription) matches(event WebhookEvent) bool {
+
+	if len(subscription.Events) > 0 && !containsEventType(subscription.Events, event.Event) {
+		return false
+	}
+	if len(subscription.RecordTypes) > 0 && !containsRecordType(subscription.RecordTypes, event.RecordType) {
+		return false
+	}
+	if len(subscription.DeviceIds) > 0 && !containsString(subscription.DeviceIds, event.DeviceId) {
+		return false
+	}
+	return true
+}
+
+// SignPayload returns a hex encoded HMAC SHA256 signature of passed payload.
+func signPayload(payload []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookEventText returns a human readable description of passed event.
+func webhookEventText(event WebhookEvent) string {
+	text := string(event.Event)
+	if event.DeviceId != "" {
+		text = event.DeviceId + ": " + text
+	}
+	if event.RecordType != "" {
+		text += " (" + string(event.RecordType) + ")"
+	}
+	if event.Timestamp != nil {
+		text += " at " + event.Timestamp.UTC().Format(time.RFC3339)
+	}
+	return text
+}
+
+func containsEventType(eventTypes []WebhookEventType, eventType WebhookEventType) bool {
+	for _, value := range eventTypes {
+		if value == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func containsRecordType(recordTypes []timetracker.RecordType, recordType timetracker.RecordType) bool {
+	for _, value := range recordTypes {
+		if value == recordType {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// SplitList splits passed comma separated list and removes empty values.
+func splitList(value string) []string {
+	values := []string{}
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
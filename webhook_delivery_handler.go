@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	log "github.com/tommzn/go-log"
+)
+
+// NewWebhookDeliveryHandler returns a handler to list deliveries of passed webhook delivery log.
+func newWebhookDeliveryHandler(deliveryLog WebhookDeliveryLog, logger log.Logger) *WebhookDeliveryHandler {
+	return &WebhookDeliveryHandler{
+		logger:      logger,
+		deliveryLog: deliveryLog,
+		maxLimit:    1000,
+	}
+}
+
+// Process returns webhook deliveries of a day, in UTC, oldest first. Default is current day.
+func (handler *WebhookDeliveryHandler) Process(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	if request.HTTPMethod != http.MethodGet {
+		err := errors.New("Unsupported HTTP method.")
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusMethodNotAllowed), err
+	}
+
+	date := time.Now().UTC()
+	if dateStr, ok := request.QueryStringParameters["date"]; ok {
+		parsedDate, err := time.Parse(dateLayout, dateStr)
+		if err != nil {
+			err := errors.New("Invalid date, expected format: YYYY-MM-DD.")
+			handler.logger.Error(err)
+			return errorResponseWithStatus(err, http.StatusBadRequest), err
+		}
+		date = parsedDate
+	}
+
+	limit := handler.maxLimit
+	if limitStr, ok := request.QueryStringParameters["limit"]; ok {
+		value, err := strconv.Atoi(limitStr)
+		if err != nil || value < 1 || value > handler.maxLimit {
+			err := fmt.Errorf("Invalid limit, has to be between 1 and %d.", handler.maxLimit)
+			handler.logger.Error(err)
+			return errorResponseWithStatus(err, http.StatusBadRequest), err
+		}
+		limit = value
+	}
+	handler.logger.Debugf("List webhook deliveries of %s, limit: %d", date.Format(dateLayout), limit)
+
+	deliveries, err := handler.deliveryLog.List(date, limit)
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusInternalServerError), err
+	}
+
+	responseContent, err := json.Marshal(deliveries)
+	if err != nil {
+		handler.logger.Error(err)
+		return errorResponseWithStatus(err, http.StatusInternalServerError), err
+	}
+	return responseWithContent(string(responseContent), http.StatusOK), nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	config "github.com/tommzn/go-config"
+)
+
+// NewWebhookDeliveryLog creates a log for webhook deliveries depending on config.
+// Supported logs are "s3", which is default and shared by all containers, and "memory".
+func newWebhookDeliveryLog(conf config.Config) (WebhookDeliveryLog, error) {
+
+	logType := conf.Get("hob.webhooks.deliverylog.store", config.AsStringPtr("s3"))
+	switch strings.ToLower(*logType) {
+	case "memory":
+		maxEntries := conf.GetAsInt("hob.webhooks.deliverylog.maxentries", config.AsIntPtr(1000))
+		return newInMemoryWebhookDeliveryLog(*maxEntries), nil
+	case "s3":
+		awsConf, err := getAwsConfig(conf)
+		if err != nil {
+			return nil, err
+		}
+		basePath := conf.Get("hob.webhooks.deliverylog.basepath", config.AsStringPtr("webhooks/deliveries"))
+		return newS3WebhookDeliveryLog(newS3Client(awsConf.region), *awsConf.bucket, *basePath), nil
+	default:
+		return nil, errors.New("Unsupported webhook delivery log: " + *logType)
+	}
+}
+
+// NewInMemoryWebhookDeliveryLog returns an empty log which keeps at most max entries deliveries
+// in memory of a single container.
+func newInMemoryWebhookDeliveryLog(maxEntries int) *InMemoryWebhookDeliveryLog {
+	if maxEntries < 1 {
+		maxEntries = 1
+	}
+	return &InMemoryWebhookDeliveryLog{deliveries: []WebhookDelivery{}, maxEntries: maxEntries}
+}
+
+// Put appends passed delivery. Oldest entries are removed if max number of entries is reached.
+func (deliveryLog *InMemoryWebhookDeliveryLog) Put(delivery WebhookDelivery) error {
+
+	deliveryLog.Lock()
+	defer deliveryLog.Unlock()
+
+	deliveryLog.deliveries = append(deliveryLog.deliveries, delivery)
+	if len(deliveryLog.deliveries) > deliveryLog.maxEntries {
+		deliveryLog.deliveries = deliveryLog.deliveries[len(deliveryLog.deliveries)-deliveryLog.maxEntries:]
+	}
+	return nil
+}
+
+// List returns at most limit deliveries of passed day, in UTC, oldest first.
+func (deliveryLog *InMemoryWebhookDeliveryLog) List(date time.Time, limit int) ([]WebhookDelivery, error) {
+
+	deliveryLog.Lock()
+	defer deliveryLog.Unlock()
+
+	day := date.UTC().Format(dateLayout)
+	deliveries := []WebhookDelivery{}
+	for _, delivery := range deliveryLog.deliveries {
+		if len(deliveries) >= limit {
+			break
+		}
+		if delivery.Timestamp.UTC().Format(dateLayout) == day {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+// NewS3WebhookDeliveryLog returns a log which persists deliveries in given bucket.
+func newS3WebhookDeliveryLog(s3Client s3iface.S3API, bucket, basePath string) *S3WebhookDeliveryLog {
+	return &S3WebhookDeliveryLog{
+		s3Client: s3Client,
+		bucket:   bucket,
+		basePath: basePath,
+	}
+}
+
+// Put uploads passed delivery. Deliveries are stored per day, so a day can be listed with a single prefix.
+func (deliveryLog *S3WebhookDeliveryLog) Put(delivery WebhookDelivery) error {
+
+	content, err := json.Marshal(delivery)
+	if err != nil {
+		return err
+	}
+	timestamp := delivery.Timestamp.UTC()
+	id := timestamp.Format("150405.000000000") + "-" + hashRequestBody(delivery.Url + "\n" + string(content))[:16]
+	_, err = deliveryLog.s3Client.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String(deliveryLog.bucket),
+		Key:    aws.String(deliveryLog.prefix(timestamp) + id + ".json"),
+		Body:   bytes.NewReader(content),
+	})
+	return err
+}
+
+// List downloads at most limit deliveries of passed day, in UTC, oldest first.
+func (deliveryLog *S3WebhookDeliveryLog) List(date time.Time, limit int) ([]WebhookDelivery, error) {
+
+	output, err := deliveryLog.s3Client.ListObjectsV2(&s3.ListObjectsV2Input{
+		Bucket:  aws.String(deliveryLog.bucket),
+		Prefix:  aws.String(deliveryLog.prefix(date.UTC())),
+		MaxKeys: aws.Int64(int64(limit)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := []WebhookDelivery{}
+	for _, object := range output.Contents {
+		delivery, err := deliveryLog.download(object.Key)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+	return deliveries, nil
+}
+
+// Download fetches and decodes a delivery from passed object key.
+func (deliveryLog *S3WebhookDeliveryLog) download(objectKey *string) (*WebhookDelivery, error) {
+
+	output, err := deliveryLog.s3Client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(deliveryLog.bucket),
+		Key:    objectKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer output.Body.Close()
+
+	content, err := io.ReadAll(output.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var delivery WebhookDelivery
+	if err := json.Unmarshal(content, &delivery); err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// Prefix returns the object key prefix of all deliveries of passed day.
+func (deliveryLog *S3WebhookDeliveryLog) prefix(date time.Time) string {
+	prefix := date.Format(dateLayout) + "/"
+	if deliveryLog.basePath == "" {
+		return prefix
+	}
+	return strings.TrimSuffix(deliveryLog.basePath, "/") + "/" + prefix
+}
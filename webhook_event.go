@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	webhookDeliveryUrl   = "webhookurl"
+	webhookDeliveryEvent = "webhookevent"
+)
+
+// NewWebhookDeliveryEvent creates an event to deliver a webhook event to passed subscription, which can be published to a queue.
+// Secrets of a subscription are not included, they're taken from config on delivery.
+func newWebhookDeliveryEvent(subscription WebhookSubscription, event WebhookEvent) (*structpb.Struct, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	return structpb.NewStruct(map[string]interface{}{
+		webhookDeliveryUrl:   subscription.Url,
+		webhookDeliveryEvent: string(payload),
+	})
+}
+
+// IsWebhookDeliveryEvent returns true if passed event has been created to deliver a webhook event.
+func isWebhookDeliveryEvent(event *structpb.Struct) bool {
+	_, ok := event.GetFields()[webhookDeliveryUrl]
+	return ok
+}
+
+// FromWebhookDeliveryEvent extracts webhook url and webhook event from passed delivery event.
+func fromWebhookDeliveryEvent(event *structpb.Struct) (string, WebhookEvent, error) {
+
+	fields := event.GetFields()
+	url := fields[webhookDeliveryUrl].GetStringValue()
+	if url == "" {
+		return "", WebhookEvent{}, errors.New("Missing webhook url in delivery event.")
+	}
+
+	var webhookEvent WebhookEvent
+	if err := json.Unmarshal([]byte(fields[webhookDeliveryEvent].GetStringValue()), &webhookEvent); err != nil {
+		return "", WebhookEvent{}, err
+	}
+	return url, webhookEvent, nil
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	config "github.com/tommzn/go-config"
+	timetracker "github.com/tommzn/hob-timetracker"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+type WebhookTestSuite struct {
+	suite.Suite
+}
+
+func TestWebhookTestSuite(t *testing.T) {
+	suite.Run(t, new(WebhookTestSuite))
+}
+
+func (suite *WebhookTestSuite) TestDeliverEvents() {
+
+	receiver := newWebhookReceiverForTest(0)
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	subscriptions := []WebhookSubscription{
+		{Url: server.URL + "/all", Secret: "secret"},
+		{Url: server.URL + "/illness", Events: []WebhookEventType{RECORD_CREATED}, RecordTypes: []timetracker.RecordType{timetracker.ILLNESS}},
+		{Url: server.URL + "/device02", DeviceIds: []string{"Device02"}},
+	}
+	deliveryLog := newInMemoryWebhookDeliveryLog(10)
+	notifier := newWebhookNotifierWithSubscriptions(subscriptions, server.Client(), 3, time.Millisecond, deliveryLog, loggerForTest())
+
+	notifier.Notify(WebhookEvent{Event: RECORD_CREATED, DeviceId: "Device01", RecordType: timetracker.ILLNESS, Timestamp: &APITime{Time: time.Now()}})
+
+	suite.Len(receiver.requests, 2)
+	suite.Len(suite.deliveries(deliveryLog), 2)
+	for _, request := range receiver.requests {
+		suite.Equal(string(RECORD_CREATED), request.Header.Get(webhookEventHeader))
+		if request.URL.Path == "/all" {
+			suite.Equal("sha256="+signPayload(request.body, "secret"), request.Header.Get(webhookSignatureHeader))
+		} else {
+			suite.Equal("", request.Header.Get(webhookSignatureHeader))
+		}
+		var event WebhookEvent
+		suite.Nil(json.Unmarshal(request.body, &event))
+		suite.Equal("Device01", event.DeviceId)
+		suite.NotEqual("", event.Text)
+	}
+}
+
+func (suite *WebhookTestSuite) TestRetryDelivery() {
+
+	receiver := newWebhookReceiverForTest(2)
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	deliveryLog := newInMemoryWebhookDeliveryLog(10)
+	notifier := newWebhookNotifierWithSubscriptions([]WebhookSubscription{{Url: server.URL}}, server.Client(), 3, time.Millisecond, deliveryLog, loggerForTest())
+	notifier.Notify(WebhookEvent{Event: RECORD_DELETED, Key: "Device01/2022-01-03/0"})
+
+	deliveries := suite.deliveries(deliveryLog)
+	suite.Len(deliveries, 1)
+	suite.Equal("Device01/2022-01-03/0", deliveries[0].Key)
+	suite.Equal(3, deliveries[0].Attempts)
+	suite.Equal(http.StatusOK, deliveries[0].StatusCode)
+	suite.Equal("", deliveries[0].Error)
+
+	receiver.failures = 5
+	notifier.Notify(WebhookEvent{Event: RECORD_DELETED, Key: "Device01/2022-01-03/0"})
+
+	deliveries = suite.deliveries(deliveryLog)
+	suite.Len(deliveries, 2)
+	suite.Equal(3, deliveries[1].Attempts)
+	suite.Equal(http.StatusInternalServerError, deliveries[1].StatusCode)
+	suite.NotEqual("", deliveries[1].Error)
+}
+
+func (suite *WebhookTestSuite) TestPublishDeliveries() {
+
+	publisher := newSqsMock()
+	subscriptions := []WebhookSubscription{{Url: "http://localhost/01"}, {Url: "http://localhost/02", DeviceIds: []string{"Device02"}}}
+	deliveryLog := newInMemoryWebhookDeliveryLog(10)
+	notifier := newWebhookNotifierWithSubscriptions(subscriptions, http.DefaultClient, 1, time.Millisecond, deliveryLog, loggerForTest())
+	notifier.publisher = publisher
+
+	notifier.Notify(WebhookEvent{Event: RECORD_CREATED, DeviceId: "Device01"})
+	suite.Len(publisher.messages, 1)
+	suite.Len(suite.deliveries(deliveryLog), 0)
+
+	deliveryEvent, ok := publisher.messages[0].(*structpb.Struct)
+	suite.True(ok)
+	suite.True(isWebhookDeliveryEvent(deliveryEvent))
+	url, event, err := fromWebhookDeliveryEvent(deliveryEvent)
+	suite.Nil(err)
+	suite.Equal("http://localhost/01", url)
+	suite.Equal("Device01", event.DeviceId)
+	suite.NotEqual("", event.Text)
+}
+
+func (suite *WebhookTestSuite) TestSubscriptionsFromConfig() {
+
+	notifier, err := newWebhookNotifier(configForTest(), newSqsMock(), newInMemoryWebhookDeliveryLog(10), loggerForTest())
+	suite.Nil(err)
+	suite.Len(notifier.subscriptions, 1)
+	suite.Equal([]string{"Device01", "Device02"}, notifier.subscriptions[0].DeviceIds)
+	suite.Equal([]WebhookEventType{RECORD_CREATED}, notifier.subscriptions[0].Events)
+
+	_, err2 := newWebhookNotifier(configForTest(), nil, newInMemoryWebhookDeliveryLog(10), loggerForTest())
+	suite.NotNil(err2)
+
+	notifier3, err3 := newWebhookNotifier(emptyConfigForTest(), nil, newInMemoryWebhookDeliveryLog(10), loggerForTest())
+	suite.Nil(err3)
+	suite.Len(notifier3.subscriptions, 0)
+}
+
+func (suite *WebhookTestSuite) TestDeliveryLog() {
+
+	deliveryLog1, err1 := newWebhookDeliveryLog(emptyConfigForTest())
+	suite.NotNil(err1)
+	suite.Nil(deliveryLog1)
+
+	conf, _ := config.NewStaticConfigSource("hob:\n  webhooks:\n    deliverylog:\n      store: memory\n      maxentries: 2\n").Load()
+	deliveryLog2, err2 := newWebhookDeliveryLog(conf)
+	suite.Nil(err2)
+
+	timestamp := time.Date(2022, 1, 3, 8, 0, 0, 0, time.UTC)
+	for idx := 0; idx < 3; idx++ {
+		suite.Nil(deliveryLog2.Put(WebhookDelivery{Url: "http://localhost/01", Event: RECORD_CREATED, Attempts: idx + 1, Timestamp: timestamp.Add(time.Duration(idx) * time.Hour)}))
+	}
+	suite.Nil(deliveryLog2.Put(WebhookDelivery{Url: "http://localhost/01", Event: RECORD_CREATED, Timestamp: timestamp.AddDate(0, 0, 1)}))
+
+	deliveries, err := deliveryLog2.List(timestamp, 10)
+	suite.Nil(err)
+	suite.Len(deliveries, 1)
+	suite.Equal(3, deliveries[0].Attempts)
+
+	handler := newWebhookDeliveryHandler(deliveryLog2, loggerForTest())
+	request := timeTrackingRecordHandlerRequestForTest(http.MethodGet)
+	request.Resource = "/webhooks/deliveries"
+	request.QueryStringParameters = map[string]string{"date": "2022-01-04", "limit": "5"}
+	response1, err1 := handler.Process(request)
+	suite.Nil(err1)
+	suite.Equal(http.StatusOK, response1.StatusCode)
+	suite.Nil(json.Unmarshal([]byte(response1.Body), &deliveries))
+	suite.Len(deliveries, 1)
+
+	request.QueryStringParameters = map[string]string{"date": "04.01.2022"}
+	response2, err2 := handler.Process(request)
+	suite.NotNil(err2)
+	suite.Equal(http.StatusBadRequest, response2.StatusCode)
+
+	request.QueryStringParameters = map[string]string{"limit": "0"}
+	response3, err3 := handler.Process(request)
+	suite.NotNil(err3)
+	suite.Equal(http.StatusBadRequest, response3.StatusCode)
+}
+
+func (suite *WebhookTestSuite) deliveries(deliveryLog WebhookDeliveryLog) []WebhookDelivery {
+	deliveries, err := deliveryLog.List(time.Now(), 100)
+	suite.Nil(err)
+	return deliveries
+}
+
+// webhookReceiverForTest records all received requests and fails a given number of times.
+type webhookReceiverForTest struct {
+	sync.Mutex
+	failures int
+	requests []receivedRequestForTest
+}
+
+type receivedRequestForTest struct {
+	*http.Request
+	body []byte
+}
+
+func newWebhookReceiverForTest(failures int) *webhookReceiverForTest {
+	return &webhookReceiverForTest{failures: failures, requests: []receivedRequestForTest{}}
+}
+
+func (receiver *webhookReceiverForTest) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	receiver.Lock()
+	defer receiver.Unlock()
+
+	if receiver.failures > 0 {
+		receiver.failures--
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	receiver.requests = append(receiver.requests, receivedRequestForTest{Request: r, body: body})
+	w.WriteHeader(http.StatusOK)
+}
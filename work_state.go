@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+// DeriveWorkState calculates the state of a day from passed time tracking records.
+// Absences take precedence over workday records. Workday records are paired to work sessions,
+// an odd number of records means work is in progress. Working time of an open session is counted
+// until now, but at most until the end of its day in passed location, e.g. for backdated captures.
+func deriveWorkState(records []timetracker.TimeTrackingRecord, now time.Time, location *time.Location) WorkState {
+
+	workdayRecords := []timetracker.TimeTrackingRecord{}
+	for _, record := range records {
+		switch record.Type {
+		case timetracker.ILLNESS:
+			return WorkState{Status: ABSENCE_ILL, Message: "reported sick"}
+		case timetracker.VACATION:
+			return WorkState{Status: ABSENCE_LEAVE, Message: "on vacation"}
+		case timetracker.WORKDAY:
+			workdayRecords = append(workdayRecords, record)
+		}
+	}
+
+	if len(workdayRecords) == 0 {
+		return WorkState{Status: NOT_STARTED, Message: "not started"}
+	}
+
+	sortRecordsByTimestamp(workdayRecords)
+	workingTime := time.Duration(0)
+	for idx := 1; idx < len(workdayRecords); idx += 2 {
+		workingTime += workdayRecords[idx].Timestamp.Sub(workdayRecords[idx-1].Timestamp)
+	}
+
+	if len(workdayRecords)%2 == 1 {
+		since := workdayRecords[len(workdayRecords)-1].Timestamp
+		until := now
+		if endOfDay := startOfDay(since, location).AddDate(0, 0, 1); until.After(endOfDay) {
+			until = endOfDay
+		}
+		if until.After(since) {
+			workingTime += until.Sub(since)
+		}
+		return WorkState{
+			Status:      WORKING,
+			Since:       &APITime{Time: since},
+			WorkingTime: int(workingTime.Minutes()),
+			Message:     "working since " + since.In(location).Format("15:04"),
+		}
+	}
+	return WorkState{
+		Status:      DAY_CLOSED,
+		WorkingTime: int(workingTime.Minutes()),
+		Message:     "day closed, " + formatDuration(workingTime),
+	}
+}
+
+// FormatDuration formats passed duration as hours and minutes, e.g. 8h03m.
+func formatDuration(duration time.Duration) string {
+	minutes := int(duration.Minutes())
+	return fmt.Sprintf("%dh%02dm", minutes/60, minutes%60)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	timetracker "github.com/tommzn/hob-timetracker"
+)
+
+type WorkStateTestSuite struct {
+	suite.Suite
+}
+
+func TestWorkStateTestSuite(t *testing.T) {
+	suite.Run(t, new(WorkStateTestSuite))
+}
+
+func (suite *WorkStateTestSuite) TestDeriveWorkState() {
+
+	day := time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC)
+	now := day.Add(12 * time.Hour)
+
+	state1 := deriveWorkState([]timetracker.TimeTrackingRecord{}, now, time.UTC)
+	suite.Equal(NOT_STARTED, state1.Status)
+
+	records := []timetracker.TimeTrackingRecord{
+		recordForTest(timetracker.WORKDAY, day.Add(8*time.Hour+12*time.Minute)),
+	}
+	state2 := deriveWorkState(records, now, time.UTC)
+	suite.Equal(WORKING, state2.Status)
+	suite.Equal("working since 08:12", state2.Message)
+	suite.Equal(228, state2.WorkingTime)
+
+	records = append(records, recordForTest(timetracker.WORKDAY, day.Add(16*time.Hour+15*time.Minute)))
+	state3 := deriveWorkState(records, now, time.UTC)
+	suite.Equal(DAY_CLOSED, state3.Status)
+	suite.Equal("day closed, 8h03m", state3.Message)
+	suite.Nil(state3.Since)
+
+	records = append(records, recordForTest(timetracker.ILLNESS, day.Add(17*time.Hour)))
+	state4 := deriveWorkState(records, now, time.UTC)
+	suite.Equal(ABSENCE_ILL, state4.Status)
+}
+
+func (suite *WorkStateTestSuite) TestDeriveWorkStateOfPastDay() {
+
+	day := time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC)
+	records := []timetracker.TimeTrackingRecord{
+		recordForTest(timetracker.WORKDAY, day.Add(16*time.Hour)),
+	}
+
+	state1 := deriveWorkState(records, day.AddDate(0, 0, 5), time.UTC)
+	suite.Equal(WORKING, state1.Status)
+	suite.Equal(480, state1.WorkingTime)
+
+	location, _ := time.LoadLocation("Europe/Berlin")
+	state2 := deriveWorkState(records, day.AddDate(0, 0, 5), location)
+	suite.Equal(420, state2.WorkingTime)
+	suite.Equal("working since 17:00", state2.Message)
+}
+
+func (suite *WorkStateTestSuite) TestFormatDuration() {
+
+	suite.Equal("8h03m", formatDuration(8*time.Hour+3*time.Minute))
+	suite.Equal("0h00m", formatDuration(0))
+}
+
+func recordForTest(recordType timetracker.RecordType, timestamp time.Time) timetracker.TimeTrackingRecord {
+	return timetracker.TimeTrackingRecord{DeviceId: "Device01", Type: recordType, Timestamp: timestamp}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	config "github.com/tommzn/go-config"
+)
+
+// NewWorkingTimeTargets reads target hours per weekday from passed config. Each entry defines target
+// hours for a comma separated list of weekdays, optional for a single device. If there's no config,
+// 8 hours from monday to friday are used.
+func newWorkingTimeTargets(conf config.Config) (*WorkingTimeTargets, error) {
+
+	targets := &WorkingTimeTargets{
+		defaults: make(map[time.Weekday]time.Duration),
+		devices:  make(map[string]map[time.Weekday]time.Duration),
+	}
+
+	entries := conf.GetAsSliceOfMaps("hob.balance.targets")
+	if len(entries) == 0 {
+		for weekday := time.Monday; weekday <= time.Friday; weekday++ {
+			targets.defaults[weekday] = 8 * time.Hour
+		}
+		return targets, nil
+	}
+
+	for _, entry := range entries {
+
+		hours, err := time.ParseDuration(entry["hours"])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid target hours in %+v, reason: %s", entry, err)
+		}
+
+		weekdays, err := parseWeekdays(entry["weekdays"])
+		if err != nil {
+			return nil, err
+		}
+
+		targetsOfWeekdays := targets.defaults
+		if deviceId, ok := entry["deviceid"]; ok && deviceId != "" {
+			if _, ok := targets.devices[deviceId]; !ok {
+				targets.devices[deviceId] = make(map[time.Weekday]time.Duration)
+			}
+			targetsOfWeekdays = targets.devices[deviceId]
+		}
+		for _, weekday := range weekdays {
+			targetsOfWeekdays[weekday] = hours
+		}
+	}
+	return targets, nil
+}
+
+// TargetFor returns target hours of passed device for given weekday. Device specific targets
+// take precedence over default targets.
+func (targets *WorkingTimeTargets) targetFor(deviceId string, weekday time.Weekday) time.Duration {
+	if deviceTargets, ok := targets.devices[deviceId]; ok {
+		if target, ok := deviceTargets[weekday]; ok {
+			return target
+		}
+	}
+	return targets.defaults[weekday]
+}
+
+// ParseWeekdays converts a comma separated list of weekdays, e.g. monday,tuesday or mon,tue.
+func parseWeekdays(value string) ([]time.Weekday, error) {
+
+	weekdays := []time.Weekday{}
+	for _, name := range splitList(value) {
+		weekday, ok := weekdayByName(name)
+		if !ok {
+			return nil, fmt.Errorf("Invalid weekday: %s", name)
+		}
+		weekdays = append(weekdays, weekday)
+	}
+	if len(weekdays) == 0 {
+		return nil, errors.New("Missing weekdays.")
+	}
+	return weekdays, nil
+}
+
+// WeekdayByName returns the weekday for passed name or its abbreviation.
+func weekdayByName(name string) (time.Weekday, bool) {
+	name = strings.ToLower(name)
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		weekdayName := strings.ToLower(weekday.String())
+		if name == weekdayName || (len(name) == 3 && strings.HasPrefix(weekdayName, name)) {
+			return weekday, true
+		}
+	}
+	return time.Sunday, false
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	config "github.com/tommzn/go-config"
+)
+
+type WorkingTimeTargetsTestSuite struct {
+	suite.Suite
+}
+
+func TestWorkingTimeTargetsTestSuite(t *testing.T) {
+	suite.Run(t, new(WorkingTimeTargetsTestSuite))
+}
+
+func (suite *WorkingTimeTargetsTestSuite) TestDefaultTargets() {
+
+	targets, err := newWorkingTimeTargets(configForTest())
+	suite.Nil(err)
+	suite.Equal(8*time.Hour, targets.targetFor("Device01", time.Monday))
+	suite.Equal(8*time.Hour, targets.targetFor("Device01", time.Friday))
+	suite.Equal(time.Duration(0), targets.targetFor("Device01", time.Saturday))
+	suite.Equal(time.Duration(0), targets.targetFor("Device01", time.Sunday))
+}
+
+func (suite *WorkingTimeTargetsTestSuite) TestConfiguredTargets() {
+
+	conf, _ := config.NewStaticConfigSource("hob:\n  balance:\n    targets:\n      - weekdays: monday, tue,wed,thu\n        hours: 8h\n      - weekdays: fri\n        hours: 6h\n      - weekdays: fri\n        hours: 4h30m\n        deviceid: Device02\n").Load()
+	targets, err := newWorkingTimeTargets(conf)
+	suite.Nil(err)
+	suite.Equal(8*time.Hour, targets.targetFor("Device01", time.Tuesday))
+	suite.Equal(6*time.Hour, targets.targetFor("Device01", time.Friday))
+	suite.Equal(8*time.Hour, targets.targetFor("Device02", time.Monday))
+	suite.Equal(4*time.Hour+30*time.Minute, targets.targetFor("Device02", time.Friday))
+	suite.Equal(time.Duration(0), targets.targetFor("Device02", time.Saturday))
+}
+
+func (suite *WorkingTimeTargetsTestSuite) TestInvalidTargets() {
+
+	for _, yaml := range []string{
+		"hob:\n  balance:\n    targets:\n      - weekdays: monday\n        hours: xxx\n",
+		"hob:\n  balance:\n    targets:\n      - weekdays: xxx\n        hours: 8h\n",
+		"hob:\n  balance:\n    targets:\n      - hours: 8h\n",
+	} {
+		conf, _ := config.NewStaticConfigSource(yaml).Load()
+		_, err := newWorkingTimeTargets(conf)
+		suite.NotNil(err, "Expected error for %s", yaml)
+	}
+}